@@ -0,0 +1,38 @@
+// riskctl 风险评分模型的离线工具，与链码使用同一个评分库
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "simulate":
+		if err := runSimulate(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "模拟失败: %v\n", err)
+			os.Exit(1)
+		}
+	case "help", "-h", "--help":
+		printUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+// printUsage 打印帮助信息
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "用法: riskctl <命令> [参数]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "命令:")
+	fmt.Fprintln(os.Stderr, "  simulate [参数] <事件文件.csv|事件文件.json>  回放风险事件，输出风险评分和风险等级的变化轨迹")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "运行 riskctl simulate -h 查看模拟参数")
+}
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/scoring"
+)
+
+// 轨迹记录类型
+const (
+	stepEvent = "event" // 风险事件
+	stepDecay = "decay" // 周期性衰减攻击画像指数
+)
+
+// simEvent 待回放的风险事件
+type simEvent struct {
+	Time         time.Time `json:"timestamp"`    // 事件时间
+	DID          string    `json:"did"`          // 设备DID
+	BehaviorType string    `json:"behaviorType"` // 行为类型
+}
+
+// simRun 一组模型参数的模拟配置
+type simRun struct {
+	Label          string                     `json:"label"`               // 参数组合的标签，未扫描参数时为 default
+	ProfileVersion int                        `json:"profileVersion"`      // 作为基准的风险评分配置版本
+	Params         scoring.Params             `json:"params"`              // 模型参数，即基准配置的参数加上扫描参数
+	Overrides      map[string]float64         `json:"overrides,omitempty"` // 覆盖的规则基础分数和权重
+	Steps          []simStep                  `json:"steps"`               // 风险评分轨迹
+	rules          map[string]models.RiskRule // 按行为类型索引的规则
+}
+
+// simStep 风险评分轨迹中的一条记录
+type simStep struct {
+	Time         time.Time `json:"time"`                   // 时间
+	DID          string    `json:"did"`                    // 设备DID
+	Kind         string    `json:"kind"`                   // 记录类型: event, decay
+	BehaviorType string    `json:"behaviorType,omitempty"` // 行为类型，衰减时为空
+	Category     string    `json:"category,omitempty"`     // 行为类别，衰减时为空
+	ScoreBefore  float64   `json:"scoreBefore"`            // 事件前的风险评分
+	Score        float64   `json:"score"`                  // 事件后的风险评分
+	AttackIndex  float64   `json:"attackIndex"`            // 事件或衰减后的攻击画像指数
+	Tier         string    `json:"tier"`                   // 事件后所处的风险等级
+}
+
+// sweepFlags 收集 -sweep 参数，每个参数为 名称=值1,值2,...
+type sweepFlags []string
+
+func (s *sweepFlags) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *sweepFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// sweepDimension 一个扫描参数及其取值
+type sweepDimension struct {
+	name   string
+	values []float64
+}
+
+// runSimulate 执行 riskctl simulate
+func runSimulate(args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	rulesPath := flags.String("rules", "", "风险规则JSON文件（RiskContract:ListRiskRules 的输出），为空时使用默认规则")
+	policyPath := flags.String("policy", "", "风险等级策略JSON文件（RiskContract:GetRiskTierPolicy 的输出），为空时使用默认策略")
+	profilePath := flags.String("profile", "", "风险评分配置JSON文件（RiskContract:GetScoringProfile 的输出），为空时使用默认配置")
+	format := flags.String("format", "csv", "输出格式: csv, json")
+	outPath := flags.String("o", "", "输出文件，为空时输出到标准输出")
+	decayInterval := flags.Duration("decay-interval", 24*time.Hour, "周期性衰减攻击画像指数的间隔，与蜜点后台的维护周期一致，0 表示不衰减")
+	untilStr := flags.String("until", "", "模拟结束时间（RFC3339），在最后一个事件之后继续衰减到该时间，为空时到最后一个事件为止")
+	var sweeps sweepFlags
+	flags.Var(&sweeps, "sweep", "扫描参数，格式 名称=值1,值2,...，可重复指定，取各参数取值的全部组合。"+
+		"名称为 maxScore、delta、alpha、lambda、vetoScore、score.<行为类型> 或 weight.<行为类型>")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: riskctl simulate [参数] <事件文件.csv|事件文件.json>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("需要指定一个事件文件")
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("无效的输出格式: %s", *format)
+	}
+	if *decayInterval < 0 {
+		return fmt.Errorf("衰减间隔不能为负数")
+	}
+
+	rules, err := loadRules(*rulesPath)
+	if err != nil {
+		return err
+	}
+	profile, err := loadProfile(*profilePath)
+	if err != nil {
+		return err
+	}
+	policy, err := loadPolicy(*policyPath, profile.Params.MaxScore)
+	if err != nil {
+		return err
+	}
+	events, err := loadEvents(flags.Arg(0), rules)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("事件文件中没有事件")
+	}
+
+	until := events[len(events)-1].Time
+	if *untilStr != "" {
+		until, err = time.Parse(time.RFC3339, *untilStr)
+		if err != nil {
+			return fmt.Errorf("结束时间格式无效: %v", err)
+		}
+		if until.Before(events[len(events)-1].Time) {
+			return fmt.Errorf("结束时间早于最后一个事件")
+		}
+	}
+
+	runs, err := buildRuns(sweeps, rules, profile)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		simulate(run, events, policy, *decayInterval, until)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(runs)
+	}
+	return writeCSV(out, runs)
+}
+
+// simulate 按时间顺序回放事件，在事件之间按衰减间隔衰减攻击画像指数，计算过程与链码一致
+func simulate(run *simRun, events []simEvent, policy *models.RiskTierPolicy, decayInterval time.Duration, until time.Time) {
+	states := make(map[string]*scoring.State)
+	var dids []string // 按首次出现的顺序记录设备，保证衰减记录的顺序确定
+	nextDecay := events[0].Time.Add(decayInterval)
+
+	// decayUntil 执行截至 t（含）的全部周期性衰减，与蜜点后台一样跳过攻击画像指数为0的设备
+	decayUntil := func(t time.Time) {
+		for decayInterval > 0 && !nextDecay.After(t) {
+			for _, did := range dids {
+				state := states[did]
+				if state.AttackIndex == 0 {
+					continue
+				}
+				*state = run.Params.Decay(*state, nextDecay)
+				run.Steps = append(run.Steps, simStep{
+					Time:        nextDecay,
+					DID:         did,
+					Kind:        stepDecay,
+					ScoreBefore: state.RiskScore,
+					Score:       state.RiskScore,
+					AttackIndex: state.AttackIndex,
+					Tier:        tierName(policy, state.RiskScore),
+				})
+			}
+			nextDecay = nextDecay.Add(decayInterval)
+		}
+	}
+
+	for _, event := range events {
+		decayUntil(event.Time)
+
+		state, ok := states[event.DID]
+		if !ok {
+			// 设备注册时风险评分为0，上次事件时间为注册时间，以首个事件的时间代替
+			state = &scoring.State{AttackProfile: []string{}, LastEventTime: event.Time}
+			states[event.DID] = state
+			dids = append(dids, event.DID)
+		}
+
+		rule := run.rules[event.BehaviorType]
+		scoreBefore := state.RiskScore
+		*state = run.Params.Apply(*state, rule.ScoringRule(), event.Time)
+		run.Steps = append(run.Steps, simStep{
+			Time:         event.Time,
+			DID:          event.DID,
+			Kind:         stepEvent,
+			BehaviorType: event.BehaviorType,
+			Category:     rule.Category,
+			ScoreBefore:  scoreBefore,
+			Score:        state.RiskScore,
+			AttackIndex:  state.AttackIndex,
+			Tier:         tierName(policy, state.RiskScore),
+		})
+	}
+
+	decayUntil(until)
+}
+
+// buildRuns 以风险评分配置为基准，按扫描参数生成全部参数组合，未指定扫描参数时只有基准配置一组
+func buildRuns(sweeps sweepFlags, rules map[string]models.RiskRule, profile *models.ScoringProfile) ([]*simRun, error) {
+	var dimensions []sweepDimension
+	for _, sweep := range sweeps {
+		parts := strings.SplitN(sweep, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("扫描参数格式无效: %q，应为 名称=值1,值2,...", sweep)
+		}
+		name := parts[0]
+		if err := checkSweepName(name, rules); err != nil {
+			return nil, err
+		}
+
+		dimension := sweepDimension{name: name}
+		for _, valueStr := range strings.Split(parts[1], ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
+			if err != nil {
+				return nil, fmt.Errorf("扫描参数 %s 的取值无效: %q", name, valueStr)
+			}
+			dimension.values = append(dimension.values, value)
+		}
+		dimensions = append(dimensions, dimension)
+	}
+
+	// 逐个参数展开，前面的参数变化最慢
+	combinations := [][]float64{{}}
+	for _, dimension := range dimensions {
+		var expanded [][]float64
+		for _, combination := range combinations {
+			for _, value := range dimension.values {
+				next := append(append([]float64{}, combination...), value)
+				expanded = append(expanded, next)
+			}
+		}
+		combinations = expanded
+	}
+
+	runs := make([]*simRun, 0, len(combinations))
+	for _, combination := range combinations {
+		run := &simRun{
+			Label:          "default",
+			ProfileVersion: profile.Version,
+			Params:         profile.Params,
+			rules:          make(map[string]models.RiskRule, len(rules)),
+		}
+		for behaviorType, rule := range rules {
+			run.rules[behaviorType] = rule
+		}
+
+		var labels []string
+		for i, dimension := range dimensions {
+			value := combination[i]
+			labels = append(labels, dimension.name+"="+strconv.FormatFloat(value, 'g', -1, 64))
+			applySweepValue(run, dimension.name, value)
+		}
+		if len(labels) > 0 {
+			run.Label = strings.Join(labels, ",")
+		}
+		if err := run.Params.Validate(); err != nil {
+			return nil, fmt.Errorf("参数组合 %s 无效: %v", run.Label, err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// checkSweepName 检查扫描参数名称
+func checkSweepName(name string, rules map[string]models.RiskRule) error {
+	switch name {
+	case "maxScore", "delta", "alpha", "lambda", "vetoScore":
+		return nil
+	}
+	for _, prefix := range []string{"score.", "weight."} {
+		if strings.HasPrefix(name, prefix) {
+			behaviorType := strings.TrimPrefix(name, prefix)
+			if _, ok := rules[behaviorType]; !ok {
+				return fmt.Errorf("扫描参数 %s 中的行为类型不存在: %s", name, behaviorType)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("未知的扫描参数: %s", name)
+}
+
+// applySweepValue 将扫描参数的取值应用到模拟配置
+func applySweepValue(run *simRun, name string, value float64) {
+	switch name {
+	case "maxScore":
+		run.Params.MaxScore = value
+	case "delta":
+		run.Params.Delta = value
+	case "alpha":
+		run.Params.Alpha = value
+	case "lambda":
+		run.Params.Lambda = value
+	case "vetoScore":
+		run.Params.VetoScore = value
+	default:
+		if run.Overrides == nil {
+			run.Overrides = make(map[string]float64)
+		}
+		run.Overrides[name] = value
+
+		if strings.HasPrefix(name, "score.") {
+			behaviorType := strings.TrimPrefix(name, "score.")
+			rule := run.rules[behaviorType]
+			rule.Score = value
+			run.rules[behaviorType] = rule
+		} else {
+			behaviorType := strings.TrimPrefix(name, "weight.")
+			rule := run.rules[behaviorType]
+			rule.Weight = value
+			run.rules[behaviorType] = rule
+		}
+	}
+}
+
+// loadRules 加载风险规则，只保留生效的规则
+func loadRules(path string) (map[string]models.RiskRule, error) {
+	ruleList := models.DefaultRiskRules
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取风险规则文件失败: %v", err)
+		}
+		ruleList = nil
+		if err := json.Unmarshal(data, &ruleList); err != nil {
+			return nil, fmt.Errorf("解析风险规则文件失败: %v", err)
+		}
+	}
+
+	rules := make(map[string]models.RiskRule, len(ruleList))
+	for _, rule := range ruleList {
+		if rule.Status == models.RuleStatusDeprecated {
+			continue
+		}
+		rules[rule.BehaviorType] = rule
+	}
+	return rules, nil
+}
+
+// loadProfile 加载风险评分配置
+func loadProfile(path string) (*models.ScoringProfile, error) {
+	if path == "" {
+		profile := models.DefaultScoringProfile
+		return &profile, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取风险评分配置文件失败: %v", err)
+	}
+	var profile models.ScoringProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("解析风险评分配置文件失败: %v", err)
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("风险评分配置无效: %v", err)
+	}
+	return &profile, nil
+}
+
+// loadPolicy 加载风险等级策略，等级须覆盖到风险评分配置的最大风险分数
+func loadPolicy(path string, maxScore float64) (*models.RiskTierPolicy, error) {
+	if path == "" {
+		policy := models.DefaultRiskTierPolicy
+		return &policy, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取风险等级策略文件失败: %v", err)
+	}
+	var policy models.RiskTierPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("解析风险等级策略文件失败: %v", err)
+	}
+	if err := policy.Validate(maxScore); err != nil {
+		return nil, fmt.Errorf("风险等级策略无效: %v", err)
+	}
+	return &policy, nil
+}
+
+// loadEvents 加载待回放的事件并按时间排序，CSV的列为 time,did,behaviorType（可带表头），
+// JSON为事件数组或 RiskContract:GetRiskEventHistory 返回的 {"events": [...]}
+func loadEvents(path string, rules map[string]models.RiskRule) ([]simEvent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取事件文件失败: %v", err)
+	}
+
+	var events []simEvent
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		events, err = parseJSONEvents(data)
+	} else {
+		events, err = parseCSVEvents(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, event := range events {
+		if event.Time.IsZero() || event.DID == "" || event.BehaviorType == "" {
+			return nil, fmt.Errorf("第 %d 个事件缺少时间、设备DID或行为类型", i+1)
+		}
+		if _, ok := rules[event.BehaviorType]; !ok {
+			return nil, fmt.Errorf("第 %d 个事件的行为类型不在规则中: %s", i+1, event.BehaviorType)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// parseJSONEvents 解析JSON格式的事件
+func parseJSONEvents(data []byte) ([]simEvent, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var page struct {
+			Events []simEvent `json:"events"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("解析事件文件失败: %v", err)
+		}
+		return page.Events, nil
+	}
+
+	var events []simEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("解析事件文件失败: %v", err)
+	}
+	return events, nil
+}
+
+// parseCSVEvents 解析CSV格式的事件，首行的第一列不是时间时视为表头
+func parseCSVEvents(data []byte) ([]simEvent, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析事件文件失败: %v", err)
+	}
+
+	var events []simEvent
+	for i, record := range records {
+		t, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("第 %d 行的时间格式无效: %q", i+1, record[0])
+		}
+		events = append(events, simEvent{Time: t, DID: record[1], BehaviorType: record[2]})
+	}
+	return events, nil
+}
+
+// writeCSV 以CSV格式输出全部参数组合的轨迹
+func writeCSV(out io.Writer, runs []*simRun) error {
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"run", "time", "did", "kind", "behaviorType", "category", "scoreBefore", "score", "attackIndex", "tier"})
+	for _, run := range runs {
+		for _, step := range run.Steps {
+			writer.Write([]string{
+				run.Label,
+				step.Time.Format(time.RFC3339),
+				step.DID,
+				step.Kind,
+				step.BehaviorType,
+				step.Category,
+				formatFloat(step.ScoreBefore),
+				formatFloat(step.Score),
+				formatFloat(step.AttackIndex),
+				step.Tier,
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// tierName 返回风险评分所属风险等级的名称
+func tierName(policy *models.RiskTierPolicy, score float64) string {
+	tier := policy.TierForScore(score)
+	if tier == nil {
+		return ""
+	}
+	return tier.Name
+}
+
+// formatFloat 格式化CSV中的数值
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 4, 64)
+}
@@ -0,0 +1,229 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// anyRole 任意已分配角色的身份都可以调用
+var anyRole = []string{models.RoleAdmin, models.RoleOracle, models.RoleDevice, models.RoleVendor}
+
+// AssignRole 为证书中没有 role 属性的身份分配角色，仅管理员可以调用
+// 设备角色的身份证书CN必须为设备DID，供应商角色不能通过链上绑定分配
+func (c *IdentityContract) AssignRole(ctx contractapi.TransactionContextInterface, mspID string, commonName string, role string) (*models.RoleBinding, error) {
+	admin, err := requireRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if mspID == "" || commonName == "" {
+		return nil, fmt.Errorf("MSP ID和证书CN不能为空")
+	}
+	if !models.ValidRole(role) {
+		return nil, fmt.Errorf("无效的角色: %s", role)
+	}
+	if role == models.RoleDevice && !utils.ValidateDID(commonName) {
+		return nil, fmt.Errorf("设备身份证书的CN必须为设备DID: %s", commonName)
+	}
+	// 供应商名称只能由证书属性声明，不能通过链上绑定分配
+	if role == models.RoleVendor {
+		return nil, fmt.Errorf("供应商角色须由证书属性 %s 和 %s 声明", models.RoleAttribute, models.VendorAttribute)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	binding := &models.RoleBinding{
+		DocType:    models.RoleBindingObjectType,
+		MSPID:      mspID,
+		CommonName: commonName,
+		Role:       role,
+		AssignedBy: admin.MSPID + "/" + admin.CommonName,
+		AssignedAt: time.Unix(timestamp.Seconds, int64(timestamp.Nanos)),
+	}
+
+	bindingKey, err := roleBindingKey(ctx, mspID, commonName)
+	if err != nil {
+		return nil, err
+	}
+	bindingJSON, err := json.Marshal(binding)
+	if err != nil {
+		return nil, fmt.Errorf("角色绑定序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(bindingKey, bindingJSON)
+	if err != nil {
+		return nil, fmt.Errorf("存储角色绑定时出错: %v", err)
+	}
+
+	return binding, nil
+}
+
+// RevokeRole 删除身份的链上角色绑定，仅管理员可以调用
+func (c *IdentityContract) RevokeRole(ctx contractapi.TransactionContextInterface, mspID string, commonName string) error {
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	binding, err := getRoleBinding(ctx, mspID, commonName)
+	if err != nil {
+		return err
+	}
+	if binding == nil {
+		return fmt.Errorf("身份 %s/%s 没有角色绑定", mspID, commonName)
+	}
+
+	bindingKey, err := roleBindingKey(ctx, mspID, commonName)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().DelState(bindingKey)
+	if err != nil {
+		return fmt.Errorf("删除角色绑定时出错: %v", err)
+	}
+
+	return nil
+}
+
+// GetCallerIdentity 获取当前调用者的身份和角色，未分配角色的身份也可以调用
+func (c *IdentityContract) GetCallerIdentity(ctx contractapi.TransactionContextInterface) (*models.CallerIdentity, error) {
+	return getCallerIdentity(ctx)
+}
+
+// getCallerIdentity 解析调用者身份和角色
+// 角色依次取自证书属性 role、链上角色绑定和证书组织单元 OU=admin
+func getCallerIdentity(ctx contractapi.TransactionContextInterface) (*models.CallerIdentity, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者MSP ID失败: %v", err)
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者证书失败: %v", err)
+	}
+
+	caller := &models.CallerIdentity{
+		MSPID:      mspID,
+		CommonName: cert.Subject.CommonName,
+	}
+
+	role, found, err := ctx.GetClientIdentity().GetAttributeValue(models.RoleAttribute)
+	if err != nil {
+		return nil, fmt.Errorf("读取调用者证书属性失败: %v", err)
+	}
+	if found {
+		caller.Role = role
+		caller.RoleSource = models.RoleSourceAttribute
+	} else {
+		binding, err := getRoleBinding(ctx, mspID, caller.CommonName)
+		if err != nil {
+			return nil, err
+		}
+		if binding != nil {
+			caller.Role = binding.Role
+			caller.RoleSource = models.RoleSourceBinding
+		} else {
+			for _, ou := range cert.Subject.OrganizationalUnit {
+				if ou == models.AdminOU {
+					caller.Role = models.RoleAdmin
+					caller.RoleSource = models.RoleSourceOU
+					break
+				}
+			}
+		}
+	}
+
+	// 设备身份与设备DID绑定，证书CN必须为设备DID
+	if caller.Role == models.RoleDevice {
+		did, err := utils.ExtractDIDFromSubject(cert.Subject.String())
+		if err != nil {
+			return nil, fmt.Errorf("设备身份证书的CN必须为设备DID: %v", err)
+		}
+		caller.DID = did
+	}
+
+	// 供应商身份只能操作证书属性 vendor 声明的供应商
+	if caller.Role == models.RoleVendor {
+		vendor, found, err := ctx.GetClientIdentity().GetAttributeValue(models.VendorAttribute)
+		if err != nil {
+			return nil, fmt.Errorf("读取调用者证书属性失败: %v", err)
+		}
+		if !found || vendor == "" {
+			return nil, fmt.Errorf("供应商身份证书缺少 %s 属性", models.VendorAttribute)
+		}
+		caller.Vendor = vendor
+	}
+
+	return caller, nil
+}
+
+// requireRole 要求调用者具有指定角色之一，返回调用者身份
+func requireRole(ctx contractapi.TransactionContextInterface, roles ...string) (*models.CallerIdentity, error) {
+	caller, err := getCallerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller.Role == "" {
+		return nil, fmt.Errorf("权限不足: 身份 %s/%s 未分配角色", caller.MSPID, caller.CommonName)
+	}
+
+	for _, role := range roles {
+		if caller.Role == role {
+			return caller, nil
+		}
+	}
+
+	return nil, fmt.Errorf("权限不足: %s 角色不能执行该操作", caller.Role)
+}
+
+// requireDeviceAccess 要求调用者可以访问指定设备的记录
+// 设备角色只能访问自身DID的记录，其他角色须在 roles 中
+func requireDeviceAccess(ctx contractapi.TransactionContextInterface, did string, roles ...string) error {
+	caller, err := requireRole(ctx, append([]string{models.RoleDevice}, roles...)...)
+	if err != nil {
+		return err
+	}
+	if caller.Role == models.RoleDevice && caller.DID != did {
+		return fmt.Errorf("权限不足: 设备 %s 只能访问自身的记录", caller.DID)
+	}
+
+	return nil
+}
+
+// getRoleBinding 读取身份的链上角色绑定，不存在时返回nil
+func getRoleBinding(ctx contractapi.TransactionContextInterface, mspID, commonName string) (*models.RoleBinding, error) {
+	bindingKey, err := roleBindingKey(ctx, mspID, commonName)
+	if err != nil {
+		return nil, err
+	}
+
+	bindingJSON, err := ctx.GetStub().GetState(bindingKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取角色绑定时出错: %v", err)
+	}
+	if bindingJSON == nil {
+		return nil, nil
+	}
+
+	var binding models.RoleBinding
+	err = json.Unmarshal(bindingJSON, &binding)
+	if err != nil {
+		return nil, fmt.Errorf("角色绑定反序列化失败: %v", err)
+	}
+
+	return &binding, nil
+}
+
+// roleBindingKey 生成角色绑定的复合键
+func roleBindingKey(ctx contractapi.TransactionContextInterface, mspID, commonName string) (string, error) {
+	bindingKey, err := ctx.GetStub().CreateCompositeKey(models.RoleBindingObjectType, []string{mspID, commonName})
+	if err != nil {
+		return "", fmt.Errorf("创建角色绑定复合键失败: %v", err)
+	}
+	return bindingKey, nil
+}
@@ -0,0 +1,153 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+func TestRequireRole(t *testing.T) {
+	did := utils.GenerateDID("camera", "C1", "acme", "SN001")
+
+	tests := []struct {
+		name       string
+		identity   *testIdentity
+		binding    string
+		roles      []string
+		wantRole   string
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name:       "证书属性声明角色",
+			identity:   newTestIdentity("Org1MSP", "oracle@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleOracle}),
+			roles:      []string{models.RoleOracle},
+			wantRole:   models.RoleOracle,
+			wantSource: models.RoleSourceAttribute,
+		},
+		{
+			name:       "链上角色绑定",
+			identity:   newTestIdentity("Org1MSP", "User1@org1.chain.com", nil, nil),
+			binding:    models.RoleOracle,
+			roles:      []string{models.RoleOracle},
+			wantRole:   models.RoleOracle,
+			wantSource: models.RoleSourceBinding,
+		},
+		{
+			name:       "OU=admin 视为管理员",
+			identity:   newTestIdentity("Org1MSP", "Admin@org1.chain.com", []string{models.AdminOU}, nil),
+			roles:      []string{models.RoleAdmin},
+			wantRole:   models.RoleAdmin,
+			wantSource: models.RoleSourceOU,
+		},
+		{
+			name:     "证书属性优先于链上绑定",
+			identity: newTestIdentity("Org1MSP", "User1@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleOracle}),
+			binding:  models.RoleAdmin,
+			roles:    []string{models.RoleAdmin},
+			wantErr:  true,
+		},
+		{
+			name:     "链上绑定优先于 OU=admin",
+			identity: newTestIdentity("Org1MSP", "Admin@org1.chain.com", []string{models.AdminOU}, nil),
+			binding:  models.RoleOracle,
+			roles:    []string{models.RoleAdmin},
+			wantErr:  true,
+		},
+		{
+			name:     "未分配角色",
+			identity: newTestIdentity("Org1MSP", "User1@org1.chain.com", nil, nil),
+			roles:    []string{models.RoleAdmin, models.RoleOracle},
+			wantErr:  true,
+		},
+		{
+			name:     "角色不在允许范围内",
+			identity: newTestIdentity("Org1MSP", "oracle@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleOracle}),
+			roles:    []string{models.RoleAdmin},
+			wantErr:  true,
+		},
+		{
+			name:       "设备证书CN为设备DID",
+			identity:   newTestIdentity("Org1MSP", did, nil, map[string]string{models.RoleAttribute: models.RoleDevice}),
+			roles:      []string{models.RoleDevice},
+			wantRole:   models.RoleDevice,
+			wantSource: models.RoleSourceAttribute,
+		},
+		{
+			name:     "设备证书CN不是DID",
+			identity: newTestIdentity("Org1MSP", "sensor-1", nil, map[string]string{models.RoleAttribute: models.RoleDevice}),
+			roles:    []string{models.RoleDevice},
+			wantErr:  true,
+		},
+		{
+			name:     "供应商证书缺少 vendor 属性",
+			identity: newTestIdentity("Org1MSP", "vendor@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleVendor}),
+			roles:    []string{models.RoleVendor},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, stub := newTestContext(t, tt.identity)
+			if tt.binding != "" {
+				stub.startTx("tx-binding", eventTime0)
+				key, err := roleBindingKey(ctx, tt.identity.mspID, tt.identity.cert.Subject.CommonName)
+				if err != nil {
+					t.Fatalf("roleBindingKey() error = %v", err)
+				}
+				binding := `{"docType":"` + models.RoleBindingObjectType + `","role":"` + tt.binding + `"}`
+				if err := stub.PutState(key, []byte(binding)); err != nil {
+					t.Fatalf("PutState() error = %v", err)
+				}
+			}
+
+			caller, err := requireRole(ctx, tt.roles...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("requireRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if caller.Role != tt.wantRole || caller.RoleSource != tt.wantSource {
+				t.Errorf("角色 = %s (%s), want %s (%s)", caller.Role, caller.RoleSource, tt.wantRole, tt.wantSource)
+			}
+			if caller.Role == models.RoleDevice && caller.DID != did {
+				t.Errorf("DID = %s, want %s", caller.DID, did)
+			}
+		})
+	}
+}
+
+func TestRequireDeviceAccess(t *testing.T) {
+	did := utils.GenerateDID("camera", "C1", "acme", "SN001")
+	other := utils.GenerateDID("camera", "C1", "acme", "SN002")
+	device := newTestIdentity("Org1MSP", did, nil, map[string]string{models.RoleAttribute: models.RoleDevice})
+	oracle := newTestIdentity("Org1MSP", "oracle@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleOracle})
+	admin := newTestIdentity("Org1MSP", "Admin@org1.chain.com", []string{models.AdminOU}, nil)
+
+	tests := []struct {
+		name     string
+		identity *testIdentity
+		did      string
+		roles    []string
+		wantErr  bool
+	}{
+		{name: "设备访问自身记录", identity: device, did: did, roles: []string{models.RoleAdmin}},
+		{name: "设备访问其他设备的记录", identity: device, did: other, roles: []string{models.RoleAdmin}, wantErr: true},
+		{name: "允许的角色访问任意设备", identity: oracle, did: other, roles: []string{models.RoleAdmin, models.RoleOracle}},
+		{name: "不允许的角色", identity: oracle, did: did, roles: []string{models.RoleAdmin}, wantErr: true},
+		{name: "管理员", identity: admin, did: other, roles: []string{models.RoleAdmin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := newTestContext(t, tt.identity)
+			err := requireDeviceAccess(ctx, tt.did, tt.roles...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("requireDeviceAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
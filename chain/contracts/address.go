@@ -0,0 +1,489 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// BindDeviceAddress 登记设备的网络地址绑定，供蜜点按告警的源地址识别设备
+// vlan 为空时表示不带标签；validFrom 为空时从交易时间起生效，validUntil 为空时长期有效（DHCP租约应填写租约到期时间）
+// 同一VLAN和IP此前的绑定在新绑定生效时自动失效；同一设备在同一地址上的有效绑定会被续期，不另建记录
+func (c *IdentityContract) BindDeviceAddress(ctx contractapi.TransactionContextInterface, did string, ip string, mac string, vlan string, validFrom string, validUntil string) (*models.AddressBinding, error) {
+	// 检查调用者权限
+	caller, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle)
+	if err != nil {
+		return nil, err
+	}
+
+	ip, err = normalizeIP(ip)
+	if err != nil {
+		return nil, err
+	}
+	mac, err = normalizeMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+	vlanID, err := parseVLAN(vlan)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备DID %s 已退役", did)
+	}
+
+	// 使用交易时间戳确保确定性
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	from, err := parseBindingTime(validFrom, txTime)
+	if err != nil {
+		return nil, fmt.Errorf("生效时间格式无效: %v", err)
+	}
+	until, err := parseBindingTime(validUntil, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("失效时间格式无效: %v", err)
+	}
+	if !until.IsZero() && !until.After(from) {
+		return nil, fmt.Errorf("失效时间必须晚于生效时间")
+	}
+
+	records, err := getAddressBindings(ctx, vlanID, ip)
+	if err != nil {
+		return nil, err
+	}
+	var overlapping []*addressBindingRecord
+	for _, record := range records {
+		binding := record.binding
+		if !binding.ValidUntil.IsZero() && !binding.ValidUntil.After(from) {
+			continue
+		}
+
+		// 同一设备续租时延长原绑定的有效期
+		if binding.DID == did && binding.MAC == mac && binding.ActiveAt(from) {
+			binding.ValidUntil = until
+			if err := putAddressBinding(ctx, record.key, binding); err != nil {
+				return nil, err
+			}
+			return binding, nil
+		}
+
+		if !binding.ValidFrom.Before(from) {
+			return nil, fmt.Errorf("VLAN %d 的地址 %s 在 %s 之后已绑定到设备 %s", vlanID, ip, from.UTC().Format(time.RFC3339), binding.DID)
+		}
+		overlapping = append(overlapping, record)
+	}
+
+	// 地址被重新分配，原绑定在新绑定生效时失效
+	for _, record := range overlapping {
+		record.binding.ValidUntil = from
+		if err := putAddressBinding(ctx, record.key, record.binding); err != nil {
+			return nil, err
+		}
+	}
+
+	binding := &models.AddressBinding{
+		DocType:    models.AddressBindingObjectType,
+		DID:        did,
+		IP:         ip,
+		MAC:        mac,
+		VLAN:       vlanID,
+		ValidFrom:  from,
+		ValidUntil: until,
+		BoundBy:    caller.MSPID + "/" + caller.CommonName,
+		TxID:       ctx.GetStub().GetTxID(),
+	}
+	bindingKey, err := ctx.GetStub().CreateCompositeKey(models.AddressBindingObjectType, []string{strconv.Itoa(vlanID), ip, eventTimeKey(from), did})
+	if err != nil {
+		return nil, fmt.Errorf("创建地址绑定复合键失败: %v", err)
+	}
+	if err := putAddressBinding(ctx, bindingKey, binding); err != nil {
+		return nil, err
+	}
+
+	// 同一MAC地址可能出现在不同的VLAN和IP上，单独建立MAC地址索引
+	if mac != "" {
+		macKey, err := ctx.GetStub().CreateCompositeKey(models.AddressBindingMACIndex, []string{mac, eventTimeKey(from), did})
+		if err != nil {
+			return nil, fmt.Errorf("创建MAC地址索引复合键失败: %v", err)
+		}
+		err = ctx.GetStub().PutState(macKey, []byte(bindingKey))
+		if err != nil {
+			return nil, fmt.Errorf("存储MAC地址索引时出错: %v", err)
+		}
+	}
+
+	return binding, nil
+}
+
+// ReleaseDeviceAddress 提前结束设备在某一地址上的绑定，例如DHCP租约被释放
+func (c *IdentityContract) ReleaseDeviceAddress(ctx contractapi.TransactionContextInterface, did string, ip string, vlan string) (*models.AddressBinding, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	ip, err := normalizeIP(ip)
+	if err != nil {
+		return nil, err
+	}
+	vlanID, err := parseVLAN(vlan)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	records, err := getAddressBindings(ctx, vlanID, ip)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		binding := record.binding
+		if binding.DID != did || !binding.ActiveAt(txTime) {
+			continue
+		}
+		binding.ValidUntil = txTime
+		if err := putAddressBinding(ctx, record.key, binding); err != nil {
+			return nil, err
+		}
+		return binding, nil
+	}
+
+	return nil, fmt.Errorf("设备 %s 在VLAN %d 的地址 %s 上没有有效的绑定", did, vlanID, ip)
+}
+
+// SetEdgeDevice 设置子网的边缘设备，子网内未绑定地址上的告警归属到该设备
+func (c *IdentityContract) SetEdgeDevice(ctx contractapi.TransactionContextInterface, cidr string, vlan string, edgeDID string) (*models.EdgeSubnet, error) {
+	// 检查调用者权限
+	caller, err := requireRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的子网: %s", cidr)
+	}
+	vlanID, err := parseVLAN(vlan)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, edgeDID)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备DID %s 已退役", edgeDID)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	subnet := &models.EdgeSubnet{
+		DocType:   models.EdgeSubnetObjectType,
+		CIDR:      network.String(),
+		VLAN:      vlanID,
+		EdgeDID:   edgeDID,
+		UpdatedBy: caller.MSPID + "/" + caller.CommonName,
+		UpdatedAt: time.Unix(timestamp.Seconds, int64(timestamp.Nanos)),
+		TxID:      ctx.GetStub().GetTxID(),
+	}
+
+	subnetKey, err := ctx.GetStub().CreateCompositeKey(models.EdgeSubnetObjectType, []string{strconv.Itoa(vlanID), subnet.CIDR})
+	if err != nil {
+		return nil, fmt.Errorf("创建子网复合键失败: %v", err)
+	}
+	subnetJSON, err := json.Marshal(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("子网序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(subnetKey, subnetJSON)
+	if err != nil {
+		return nil, fmt.Errorf("存储子网时出错: %v", err)
+	}
+
+	return subnet, nil
+}
+
+// RemoveEdgeDevice 删除子网的边缘设备设置
+func (c *IdentityContract) RemoveEdgeDevice(ctx contractapi.TransactionContextInterface, cidr string, vlan string) error {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("无效的子网: %s", cidr)
+	}
+	vlanID, err := parseVLAN(vlan)
+	if err != nil {
+		return err
+	}
+
+	subnetKey, err := ctx.GetStub().CreateCompositeKey(models.EdgeSubnetObjectType, []string{strconv.Itoa(vlanID), network.String()})
+	if err != nil {
+		return fmt.Errorf("创建子网复合键失败: %v", err)
+	}
+	subnetJSON, err := ctx.GetStub().GetState(subnetKey)
+	if err != nil {
+		return fmt.Errorf("读取子网时出错: %v", err)
+	}
+	if subnetJSON == nil {
+		return fmt.Errorf("VLAN %d 的子网 %s 未设置边缘设备", vlanID, network.String())
+	}
+
+	err = ctx.GetStub().DelState(subnetKey)
+	if err != nil {
+		return fmt.Errorf("删除子网时出错: %v", err)
+	}
+
+	return nil
+}
+
+// ResolveAddress 将告警的源地址解析为设备DID
+// 依次按MAC地址、VLAN和IP地址查找在 at 时刻有效的绑定，都未找到时归属到地址所在子网的边缘设备
+// mac 和 vlan 可以为空；at 为 RFC3339 格式的告警时间，为空时使用交易时间
+func (c *IdentityContract) ResolveAddress(ctx contractapi.TransactionContextInterface, ip string, mac string, vlan string, at string) (*models.AddressResolution, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	ip, err := normalizeIP(ip)
+	if err != nil {
+		return nil, err
+	}
+	mac, err = normalizeMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+	vlanID, err := parseVLAN(vlan)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	t, err := parseBindingTime(at, time.Unix(timestamp.Seconds, int64(timestamp.Nanos)))
+	if err != nil {
+		return nil, fmt.Errorf("告警时间格式无效: %v", err)
+	}
+
+	if mac != "" {
+		binding, err := findMACBinding(ctx, mac, t)
+		if err != nil {
+			return nil, err
+		}
+		if binding != nil {
+			return &models.AddressResolution{DID: binding.DID, MatchedBy: models.AddressMatchMAC, Binding: binding}, nil
+		}
+	}
+
+	records, err := getAddressBindings(ctx, vlanID, ip)
+	if err != nil {
+		return nil, err
+	}
+	// 绑定按生效时间升序排列，取最后一个有效的绑定
+	var matched *models.AddressBinding
+	for _, record := range records {
+		if record.binding.ActiveAt(t) {
+			matched = record.binding
+		}
+	}
+	if matched != nil {
+		return &models.AddressResolution{DID: matched.DID, MatchedBy: models.AddressMatchIP, Binding: matched}, nil
+	}
+
+	subnet, err := findEdgeSubnet(ctx, vlanID, ip)
+	if err != nil {
+		return nil, err
+	}
+	if subnet != nil {
+		return &models.AddressResolution{DID: subnet.EdgeDID, MatchedBy: models.AddressMatchEdge, Subnet: subnet}, nil
+	}
+
+	return nil, fmt.Errorf("未找到地址对应的设备: VLAN %d, IP %s", vlanID, ip)
+}
+
+// addressBindingRecord 地址绑定及其复合键
+type addressBindingRecord struct {
+	key     string
+	binding *models.AddressBinding
+}
+
+// getAddressBindings 按生效时间升序读取某一VLAN和IP上的全部地址绑定
+func getAddressBindings(ctx contractapi.TransactionContextInterface, vlanID int, ip string) ([]*addressBindingRecord, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.AddressBindingObjectType, []string{strconv.Itoa(vlanID), ip})
+	if err != nil {
+		return nil, fmt.Errorf("查询地址绑定时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []*addressBindingRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var binding models.AddressBinding
+		err = json.Unmarshal(queryResponse.Value, &binding)
+		if err != nil {
+			return nil, fmt.Errorf("地址绑定反序列化失败: %v", err)
+		}
+		records = append(records, &addressBindingRecord{key: queryResponse.Key, binding: &binding})
+	}
+
+	return records, nil
+}
+
+// findMACBinding 通过MAC地址索引查找在指定时间有效的最新绑定，未找到时返回nil
+func findMACBinding(ctx contractapi.TransactionContextInterface, mac string, t time.Time) (*models.AddressBinding, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.AddressBindingMACIndex, []string{mac})
+	if err != nil {
+		return nil, fmt.Errorf("查询MAC地址索引时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	// 索引按生效时间升序排列，取最后一个有效的绑定
+	var matched *models.AddressBinding
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		bindingJSON, err := ctx.GetStub().GetState(string(queryResponse.Value))
+		if err != nil {
+			return nil, fmt.Errorf("读取地址绑定时出错: %v", err)
+		}
+		if bindingJSON == nil {
+			continue
+		}
+		var binding models.AddressBinding
+		err = json.Unmarshal(bindingJSON, &binding)
+		if err != nil {
+			return nil, fmt.Errorf("地址绑定反序列化失败: %v", err)
+		}
+		if binding.ActiveAt(t) {
+			matched = &binding
+		}
+	}
+
+	return matched, nil
+}
+
+// findEdgeSubnet 查找VLAN内包含该IP的最小子网，未找到时返回nil
+func findEdgeSubnet(ctx contractapi.TransactionContextInterface, vlanID int, ip string) (*models.EdgeSubnet, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.EdgeSubnetObjectType, []string{strconv.Itoa(vlanID)})
+	if err != nil {
+		return nil, fmt.Errorf("查询子网时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	address := net.ParseIP(ip)
+	var matched *models.EdgeSubnet
+	matchedBits := -1
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var subnet models.EdgeSubnet
+		err = json.Unmarshal(queryResponse.Value, &subnet)
+		if err != nil {
+			return nil, fmt.Errorf("子网反序列化失败: %v", err)
+		}
+		_, network, err := net.ParseCIDR(subnet.CIDR)
+		if err != nil || !network.Contains(address) {
+			continue
+		}
+		if bits, _ := network.Mask.Size(); bits > matchedBits {
+			matched = &subnet
+			matchedBits = bits
+		}
+	}
+
+	return matched, nil
+}
+
+// putAddressBinding 将地址绑定写入账本
+func putAddressBinding(ctx contractapi.TransactionContextInterface, key string, binding *models.AddressBinding) error {
+	bindingJSON, err := json.Marshal(binding)
+	if err != nil {
+		return fmt.Errorf("地址绑定序列化失败: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(key, bindingJSON)
+	if err != nil {
+		return fmt.Errorf("存储地址绑定时出错: %v", err)
+	}
+
+	return nil
+}
+
+// normalizeIP 验证IP地址并转换为标准格式
+func normalizeIP(ip string) (string, error) {
+	address := net.ParseIP(ip)
+	if address == nil {
+		return "", fmt.Errorf("无效的IP地址: %s", ip)
+	}
+	return address.String(), nil
+}
+
+// normalizeMAC 验证MAC地址并转换为小写冒号分隔格式，为空时返回空字符串
+func normalizeMAC(mac string) (string, error) {
+	if mac == "" {
+		return "", nil
+	}
+	address, err := net.ParseMAC(mac)
+	if err != nil {
+		return "", fmt.Errorf("无效的MAC地址: %s", mac)
+	}
+	return strings.ToLower(address.String()), nil
+}
+
+// parseVLAN 解析VLAN ID，为空时表示不带标签
+func parseVLAN(vlan string) (int, error) {
+	if vlan == "" {
+		return 0, nil
+	}
+	vlanID, err := strconv.Atoi(vlan)
+	if err != nil || vlanID < 0 || vlanID > models.MaxVLAN {
+		return 0, fmt.Errorf("无效的VLAN ID: %s", vlan)
+	}
+	return vlanID, nil
+}
+
+// parseBindingTime 解析RFC3339格式的时间，为空时返回默认值
+func parseBindingTime(value string, defaultTime time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultTime, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
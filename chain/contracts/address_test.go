@@ -0,0 +1,79 @@
+package contracts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+func TestResolveAddress(t *testing.T) {
+	ctx, stub := newAdminContext(t)
+	contract := &IdentityContract{}
+	deviceA := utils.GenerateDID("camera", "C1", "acme", "SN001")
+	deviceB := utils.GenerateDID("camera", "C1", "acme", "SN002")
+	edge := utils.GenerateDID("gateway", "G1", "acme", "SN003")
+	t0 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	stub.startTx("tx-devices", t0.Add(-time.Hour))
+	for _, did := range []string{deviceA, deviceB, edge} {
+		if err := putDeviceInfo(ctx, &models.DeviceInfo{DID: did, Status: models.StatusActive}); err != nil {
+			t.Fatalf("putDeviceInfo() error = %v", err)
+		}
+	}
+
+	// 10.0.0.5 先绑定设备A，一小时后重新分配给设备B
+	stub.startTx("tx-bind-a", t0)
+	if _, err := contract.BindDeviceAddress(ctx, deviceA, "10.0.0.5", "AA:BB:CC:DD:EE:01", "", "", ""); err != nil {
+		t.Fatalf("BindDeviceAddress(A) error = %v", err)
+	}
+	stub.startTx("tx-bind-b", t0.Add(time.Hour))
+	if _, err := contract.BindDeviceAddress(ctx, deviceB, "10.0.0.5", "", "", "", ""); err != nil {
+		t.Fatalf("BindDeviceAddress(B) error = %v", err)
+	}
+	stub.startTx("tx-edge", t0.Add(time.Hour))
+	if _, err := contract.SetEdgeDevice(ctx, "10.0.0.0/24", "", edge); err != nil {
+		t.Fatalf("SetEdgeDevice() error = %v", err)
+	}
+
+	at := func(d time.Duration) string { return t0.Add(d).Format(time.RFC3339) }
+
+	tests := []struct {
+		name          string
+		ip            string
+		mac           string
+		vlan          string
+		at            string
+		wantDID       string
+		wantMatchedBy string
+		wantErr       bool
+	}{
+		{name: "按MAC地址匹配", ip: "10.0.0.9", mac: "aa:bb:cc:dd:ee:01", at: at(30 * time.Minute), wantDID: deviceA, wantMatchedBy: models.AddressMatchMAC},
+		{name: "重新分配前按IP匹配", ip: "10.0.0.5", at: at(30 * time.Minute), wantDID: deviceA, wantMatchedBy: models.AddressMatchIP},
+		{name: "重新分配后按IP匹配", ip: "10.0.0.5", at: at(2 * time.Hour), wantDID: deviceB, wantMatchedBy: models.AddressMatchIP},
+		{name: "MAC绑定已失效时按IP匹配", ip: "10.0.0.5", mac: "aa:bb:cc:dd:ee:01", at: at(2 * time.Hour), wantDID: deviceB, wantMatchedBy: models.AddressMatchIP},
+		{name: "绑定生效前归属到边缘设备", ip: "10.0.0.5", at: at(-time.Minute), wantDID: edge, wantMatchedBy: models.AddressMatchEdge},
+		{name: "未绑定的地址归属到边缘设备", ip: "10.0.0.200", at: at(2 * time.Hour), wantDID: edge, wantMatchedBy: models.AddressMatchEdge},
+		{name: "告警时间为空时使用交易时间", ip: "10.0.0.5", wantDID: deviceB, wantMatchedBy: models.AddressMatchIP},
+		{name: "其他VLAN没有边缘设备", ip: "10.0.0.200", vlan: "20", at: at(2 * time.Hour), wantErr: true},
+		{name: "子网外的地址", ip: "10.0.1.1", at: at(2 * time.Hour), wantErr: true},
+		{name: "无效的IP地址", ip: "10.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub.startTx("tx-resolve", t0.Add(3*time.Hour))
+			resolution, err := contract.ResolveAddress(ctx, tt.ip, tt.mac, tt.vlan, tt.at)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if resolution.DID != tt.wantDID || resolution.MatchedBy != tt.wantMatchedBy {
+				t.Errorf("ResolveAddress() = %s (%s), want %s (%s)", resolution.DID, resolution.MatchedBy, tt.wantDID, tt.wantMatchedBy)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package contracts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// CreateAuthChallenge 为设备生成认证挑战
+// 随机数由交易ID派生，各背书节点计算结果一致；挑战须通过提交交易写入账本后才能使用
+func (c *IdentityContract) CreateAuthChallenge(ctx contractapi.TransactionContextInterface, did string) (*models.AuthChallenge, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.PublicKey == "" {
+		return nil, fmt.Errorf("设备DID %s 未登记公钥", did)
+	}
+	if deviceInfo.Status == models.StatusInactive || deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备状态为 %s, 非活跃状态", deviceInfo.Status)
+	}
+
+	// 使用交易时间戳确保确定性
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	nonceHash := sha256.Sum256([]byte(ctx.GetStub().GetTxID() + did))
+	nonce := hex.EncodeToString(nonceHash[:])
+	challenge := &models.AuthChallenge{
+		DocType:   models.AuthChallengeObjectType,
+		DID:       did,
+		Nonce:     nonce,
+		Message:   models.AuthChallengeMessage(did, nonce),
+		IssuedAt:  txTime,
+		ExpiresAt: txTime.Add(models.AuthChallengeTTL),
+	}
+
+	challengeKey, err := ctx.GetStub().CreateCompositeKey(models.AuthChallengeObjectType, []string{did, nonce})
+	if err != nil {
+		return nil, fmt.Errorf("创建认证挑战复合键失败: %v", err)
+	}
+	challengeJSON, err := json.Marshal(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("认证挑战序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(challengeKey, challengeJSON)
+	if err != nil {
+		return nil, fmt.Errorf("存储认证挑战时出错: %v", err)
+	}
+
+	return challenge, nil
+}
+
+// VerifyAuthResponse 验证设备对认证挑战的签名
+// 挑战无论验证成功与否都会被删除，防止重放和暴力尝试
+func (c *IdentityContract) VerifyAuthResponse(ctx contractapi.TransactionContextInterface, did, nonce, signature string) (bool, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin); err != nil {
+		return false, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return false, err
+	}
+	if deviceInfo.PublicKey == "" {
+		return false, fmt.Errorf("设备DID %s 未登记公钥", did)
+	}
+
+	// 读取认证挑战
+	challengeKey, err := ctx.GetStub().CreateCompositeKey(models.AuthChallengeObjectType, []string{did, nonce})
+	if err != nil {
+		return false, fmt.Errorf("创建认证挑战复合键失败: %v", err)
+	}
+	challengeJSON, err := ctx.GetStub().GetState(challengeKey)
+	if err != nil {
+		return false, fmt.Errorf("读取认证挑战时出错: %v", err)
+	}
+	if challengeJSON == nil {
+		return false, fmt.Errorf("认证挑战不存在或已使用")
+	}
+
+	var challenge models.AuthChallenge
+	err = json.Unmarshal(challengeJSON, &challenge)
+	if err != nil {
+		return false, fmt.Errorf("认证挑战反序列化失败: %v", err)
+	}
+
+	// 挑战只能使用一次
+	err = ctx.GetStub().DelState(challengeKey)
+	if err != nil {
+		return false, fmt.Errorf("删除认证挑战时出错: %v", err)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return false, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	if txTime.After(challenge.ExpiresAt) {
+		return false, nil
+	}
+
+	// 挑战签发后设备可能已被暂停或退役，此时认证失败
+	switch deviceInfo.Status {
+	case models.StatusInactive, models.StatusDecommissioned:
+		return false, nil
+	}
+
+	// 使用设备登记的公钥验证签名
+	digest := sha256.Sum256([]byte(challenge.Message))
+	if err := utils.VerifyECDSASignature(deviceInfo.PublicKey, digest[:], signature); err != nil {
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,93 @@
+package contracts
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+func TestVerifyAuthResponse(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	did := utils.GenerateDID("camera", "C1", "acme", "SN001")
+
+	tests := []struct {
+		name   string
+		status string
+		delay  time.Duration
+		badSig bool
+		wantOK bool
+	}{
+		{name: "活跃设备", status: models.StatusActive, wantOK: true},
+		{name: "风险设备", status: models.StatusRisky, wantOK: true},
+		{name: "挑战签发后设备被暂停", status: models.StatusInactive},
+		{name: "挑战签发后设备被退役", status: models.StatusDecommissioned},
+		{name: "挑战已过期", status: models.StatusActive, delay: models.AuthChallengeTTL + time.Second},
+		{name: "签名无效", status: models.StatusActive, badSig: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, stub := newAdminContext(t)
+			contract := &IdentityContract{}
+			stub.startTx("tx-device", eventTime0)
+			deviceInfo := &models.DeviceInfo{DID: did, PublicKey: publicKey, Status: models.StatusActive}
+			if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+				t.Fatalf("putDeviceInfo() error = %v", err)
+			}
+
+			stub.startTx("tx-challenge", eventTime0)
+			challenge, err := contract.CreateAuthChallenge(ctx, did)
+			if err != nil {
+				t.Fatalf("CreateAuthChallenge() error = %v", err)
+			}
+
+			stub.startTx("tx-status", eventTime0)
+			deviceInfo.Status = tt.status
+			if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+				t.Fatalf("putDeviceInfo() error = %v", err)
+			}
+
+			message := challenge.Message
+			if tt.badSig {
+				message += "x"
+			}
+			digest := sha256.Sum256([]byte(message))
+			sig, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+			if err != nil {
+				t.Fatalf("SignASN1() error = %v", err)
+			}
+
+			stub.startTx("tx-verify", eventTime0.Add(tt.delay))
+			ok, err := contract.VerifyAuthResponse(ctx, did, challenge.Nonce, base64.StdEncoding.EncodeToString(sig))
+			if err != nil {
+				t.Fatalf("VerifyAuthResponse() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("VerifyAuthResponse() = %v, want %v", ok, tt.wantOK)
+			}
+
+			// 挑战无论验证成功与否都已删除
+			stub.startTx("tx-replay", eventTime0)
+			if _, err := contract.VerifyAuthResponse(ctx, did, challenge.Nonce, base64.StdEncoding.EncodeToString(sig)); err == nil {
+				t.Error("重放已使用的挑战应返回错误")
+			}
+		})
+	}
+}
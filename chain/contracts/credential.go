@@ -0,0 +1,340 @@
+package contracts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// IssueCredential 为已注册设备签发可验证凭证
+// 链码生成不含签名的凭证并登记其哈希和状态，签发者用提交交易的身份私钥对凭证哈希签名后交给设备持有
+func (c *IdentityContract) IssueCredential(ctx contractapi.TransactionContextInterface, did string) (*models.VerifiableCredential, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status == models.StatusInactive || deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备状态为 %s, 不能签发凭证", deviceInfo.Status)
+	}
+
+	// 签发者公钥取自提交交易的证书，验证凭证时用于验证签名
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者MSP ID失败: %v", err)
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者证书失败: %v", err)
+	}
+	issuerPublicKey, err := utils.PublicKeyToPEM(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用交易时间戳确保确定性
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC()
+	expiresAt := txTime.Add(models.CredentialValidity)
+	txID := ctx.GetStub().GetTxID()
+
+	vc := &models.VerifiableCredential{
+		Context:        []string{models.CredentialContext},
+		ID:             models.CredentialIDPrefix + txID,
+		Type:           []string{"VerifiableCredential", models.CredentialType},
+		Issuer:         models.OrganizationDID(mspID),
+		IssuanceDate:   txTime.Format(time.RFC3339),
+		ExpirationDate: expiresAt.Format(time.RFC3339),
+		CredentialSubject: models.CredentialSubject{
+			ID:     deviceInfo.DID,
+			Name:   deviceInfo.Name,
+			Model:  deviceInfo.Model,
+			Vendor: deviceInfo.Vendor,
+		},
+	}
+	hash, err := utils.HashCredential(vc)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个设备只保留一个有效凭证，重新签发时吊销旧凭证
+	records, err := getCredentialRecords(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Status != models.CredentialStatusActive {
+			continue
+		}
+		record.Status = models.CredentialStatusRevoked
+		record.RevokedAt = txTime
+		record.RevocationReason = "凭证已重新签发"
+		if err := putCredentialRecord(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+
+	record := &models.CredentialRecord{
+		ID:              vc.ID,
+		DID:             did,
+		Hash:            hex.EncodeToString(hash),
+		Status:          models.CredentialStatusActive,
+		Issuer:          vc.Issuer,
+		IssuerPublicKey: issuerPublicKey,
+		IssuedAt:        txTime,
+		ExpiresAt:       expiresAt,
+		TxID:            txID,
+	}
+	if err := putCredentialRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	// 发送凭证签发事件
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("凭证记录序列化失败: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("CredentialIssued", recordJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送凭证签发事件失败: %v", err)
+	}
+
+	return vc, nil
+}
+
+// VerifyCredential 验证设备出示的可验证凭证，检查链上登记、内容完整性、签名、吊销状态、有效期和设备状态
+func (c *IdentityContract) VerifyCredential(ctx contractapi.TransactionContextInterface, vcJSON string) (*models.CredentialVerification, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	var vc models.VerifiableCredential
+	err := json.Unmarshal([]byte(vcJSON), &vc)
+	if err != nil {
+		return nil, fmt.Errorf("凭证JSON解析失败: %v", err)
+	}
+
+	did := vc.CredentialSubject.ID
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
+	}
+
+	result := &models.CredentialVerification{
+		CredentialID: vc.ID,
+		DID:          did,
+	}
+
+	if vc.Proof == nil || vc.Proof.ProofValue == "" {
+		result.Reason = "凭证缺少签名"
+		return result, nil
+	}
+
+	// 凭证必须在链上登记过
+	record, err := getCredentialRecord(ctx, did, vc.ID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		result.Reason = "凭证未在链上登记"
+		return result, nil
+	}
+
+	// 凭证内容必须与签发时一致
+	hash, err := utils.HashCredential(&vc)
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(hash) != record.Hash {
+		result.Reason = "凭证内容与链上登记的哈希不一致"
+		return result, nil
+	}
+
+	// 签名必须由签发者私钥生成
+	err = utils.VerifyECDSASignature(record.IssuerPublicKey, hash, vc.Proof.ProofValue)
+	if err != nil {
+		result.Reason = fmt.Sprintf("凭证签名无效: %v", err)
+		return result, nil
+	}
+
+	if record.Status == models.CredentialStatusRevoked {
+		result.Reason = fmt.Sprintf("凭证已吊销: %s", record.RevocationReason)
+		return result, nil
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	if txTime.After(record.ExpiresAt) {
+		result.Reason = "凭证已过期"
+		return result, nil
+	}
+
+	// 暂停或退役设备的凭证不再有效
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	switch deviceInfo.Status {
+	case models.StatusInactive:
+		result.Reason = "设备已暂停"
+		return result, nil
+	case models.StatusDecommissioned:
+		result.Reason = "设备已退役"
+		return result, nil
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// RevokeCredential 吊销设备当前有效的凭证
+func (c *IdentityContract) RevokeCredential(ctx contractapi.TransactionContextInterface, did string, reason string) (*models.CredentialRecord, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("吊销原因不能为空")
+	}
+
+	records, err := getCredentialRecords(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	var record *models.CredentialRecord
+	for _, r := range records {
+		if r.Status == models.CredentialStatusActive {
+			record = r
+			break
+		}
+	}
+	if record == nil {
+		return nil, fmt.Errorf("设备DID %s 没有有效的凭证", did)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	record.Status = models.CredentialStatusRevoked
+	record.RevokedAt = time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC()
+	record.RevocationReason = reason
+	if err := putCredentialRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	// 发送凭证吊销事件
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("凭证记录序列化失败: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("CredentialRevoked", recordJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送凭证吊销事件失败: %v", err)
+	}
+
+	return record, nil
+}
+
+// GetCredentialRecords 获取设备的全部凭证记录
+func (c *IdentityContract) GetCredentialRecords(ctx contractapi.TransactionContextInterface, did string) ([]*models.CredentialRecord, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
+	}
+
+	return getCredentialRecords(ctx, did)
+}
+
+// getCredentialRecords 按DID查询凭证记录
+func getCredentialRecords(ctx contractapi.TransactionContextInterface, did string) ([]*models.CredentialRecord, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.CredentialObjectType, []string{did})
+	if err != nil {
+		return nil, fmt.Errorf("查询凭证记录时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []*models.CredentialRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var record models.CredentialRecord
+		err = json.Unmarshal(queryResponse.Value, &record)
+		if err != nil {
+			return nil, fmt.Errorf("凭证记录反序列化失败: %v", err)
+		}
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+// getCredentialRecord 读取凭证记录，不存在时返回nil
+func getCredentialRecord(ctx contractapi.TransactionContextInterface, did, credentialID string) (*models.CredentialRecord, error) {
+	recordKey, err := ctx.GetStub().CreateCompositeKey(models.CredentialObjectType, []string{did, credentialID})
+	if err != nil {
+		return nil, fmt.Errorf("创建凭证记录复合键失败: %v", err)
+	}
+
+	recordJSON, err := ctx.GetStub().GetState(recordKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取凭证记录时出错: %v", err)
+	}
+	if recordJSON == nil {
+		return nil, nil
+	}
+
+	var record models.CredentialRecord
+	err = json.Unmarshal(recordJSON, &record)
+	if err != nil {
+		return nil, fmt.Errorf("凭证记录反序列化失败: %v", err)
+	}
+
+	return &record, nil
+}
+
+// putCredentialRecord 写入凭证记录
+func putCredentialRecord(ctx contractapi.TransactionContextInterface, record *models.CredentialRecord) error {
+	record.DocType = models.CredentialObjectType
+	recordKey, err := ctx.GetStub().CreateCompositeKey(models.CredentialObjectType, []string{record.DID, record.ID})
+	if err != nil {
+		return fmt.Errorf("创建凭证记录复合键失败: %v", err)
+	}
+
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("凭证记录序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(recordKey, recordJSON)
+	if err != nil {
+		return fmt.Errorf("存储凭证记录时出错: %v", err)
+	}
+
+	return nil
+}
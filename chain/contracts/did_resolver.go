@@ -0,0 +1,127 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// ResolveDID 将设备DID解析为W3C DID文档
+func (c *IdentityContract) ResolveDID(ctx contractapi.TransactionContextInterface, did string) (*models.DIDResolutionResult, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+
+	// 早期注册的设备没有记录控制者，由设备自身控制
+	controller := deviceInfo.Controller
+	if controller == "" {
+		controller = did
+	}
+
+	document := &models.DIDDocument{
+		Context:            []string{models.DIDContext, models.JWS2020Context},
+		ID:                 did,
+		Controller:         controller,
+		VerificationMethod: []models.VerificationMethod{},
+		Authentication:     []string{},
+		AssertionMethod:    []string{},
+		Service:            []models.ServiceEndpoint{},
+	}
+
+	// 设备公钥作为认证和声明签名的验证方法
+	if deviceInfo.PublicKey != "" {
+		jwk, err := utils.PublicKeyToJWK(deviceInfo.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("设备公钥转换失败: %v", err)
+		}
+		keyID := did + models.DeviceKeyFragment
+		document.VerificationMethod = append(document.VerificationMethod, models.VerificationMethod{
+			ID:           keyID,
+			Type:         models.VerificationMethodType,
+			Controller:   did,
+			PublicKeyJwk: jwk,
+		})
+		document.Authentication = append(document.Authentication, keyID)
+		document.AssertionMethod = append(document.AssertionMethod, keyID)
+	}
+	document.Service = append(document.Service, deviceInfo.Services...)
+
+	return &models.DIDResolutionResult{
+		DIDDocument: document,
+		DIDDocumentMetadata: models.DIDDocumentMetadata{
+			Created: deviceInfo.CreatedAt.UTC().Format(time.RFC3339),
+			Updated: deviceInfo.LastUpdatedAt.UTC().Format(time.RFC3339),
+			// 已退役设备的DID视为已停用
+			Deactivated: deviceInfo.Status == models.StatusDecommissioned,
+		},
+		DIDResolutionMetadata: models.DIDResolutionMetadata{
+			ContentType: models.DIDLDContentType,
+		},
+	}, nil
+}
+
+// UpdateDeviceServices 更新设备声明的服务端点，会整体替换原有的服务端点
+func (c *IdentityContract) UpdateDeviceServices(ctx contractapi.TransactionContextInterface, did string, servicesJSON string) error {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return err
+	}
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return fmt.Errorf("设备DID %s 已退役", did)
+	}
+
+	var services []models.ServiceEndpoint
+	err = json.Unmarshal([]byte(servicesJSON), &services)
+	if err != nil {
+		return fmt.Errorf("服务端点JSON解析失败: %v", err)
+	}
+
+	// 服务ID统一为 <did>#<名称> 格式，且不能重复
+	seen := make(map[string]bool)
+	for i := range services {
+		service := &services[i]
+		if service.ID == "" || service.Type == "" || service.ServiceEndpoint == "" {
+			return fmt.Errorf("第 %d 个服务端点的ID、类型和地址不能为空", i+1)
+		}
+		if strings.HasPrefix(service.ID, "#") {
+			service.ID = did + service.ID
+		}
+		if !strings.HasPrefix(service.ID, did+"#") {
+			return fmt.Errorf("服务端点ID必须为 %s#<名称> 格式: %s", did, service.ID)
+		}
+		if seen[service.ID] {
+			return fmt.Errorf("服务端点ID重复: %s", service.ID)
+		}
+		seen[service.ID] = true
+		if _, err := url.ParseRequestURI(service.ServiceEndpoint); err != nil {
+			return fmt.Errorf("服务端点地址无效: %s", service.ServiceEndpoint)
+		}
+	}
+
+	// 使用交易时间戳更新最后更新时间
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	deviceInfo.Services = services
+	deviceInfo.LastUpdatedAt = time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	return putDeviceInfo(ctx, deviceInfo)
+}
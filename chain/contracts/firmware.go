@@ -0,0 +1,274 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// PublishFirmwareAllowlist 发布某一型号的固件白名单，会整体替换原有的白名单，并发送 FirmwareAllowlistUpdated 事件
+// 供应商只能发布自身型号的白名单，管理员可以代任意供应商发布
+func (c *IdentityContract) PublishFirmwareAllowlist(ctx contractapi.TransactionContextInterface, vendor string, model string, releasesJSON string) (*models.FirmwareAllowlist, error) {
+	// 检查调用者权限
+	caller, err := requireRole(ctx, models.RoleAdmin, models.RoleVendor)
+	if err != nil {
+		return nil, err
+	}
+
+	if vendor == "" || model == "" {
+		return nil, fmt.Errorf("供应商和型号不能为空")
+	}
+	if caller.Role == models.RoleVendor && caller.Vendor != vendor {
+		return nil, fmt.Errorf("权限不足: 供应商 %s 不能发布 %s 的固件白名单", caller.Vendor, vendor)
+	}
+
+	var releases []models.FirmwareRelease
+	err = json.Unmarshal([]byte(releasesJSON), &releases)
+	if err != nil {
+		return nil, fmt.Errorf("固件白名单JSON解析失败: %v", err)
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("固件白名单不能为空")
+	}
+
+	// 同一版本只能对应一个固件哈希
+	seen := make(map[string]bool)
+	for i := range releases {
+		release := &releases[i]
+		if release.Version == "" {
+			return nil, fmt.Errorf("第 %d 个固件版本号不能为空", i+1)
+		}
+		release.Hash = strings.ToLower(release.Hash)
+		if !validateFirmwareHash(release.Hash) {
+			return nil, fmt.Errorf("固件版本 %s 的哈希格式无效: %s", release.Version, release.Hash)
+		}
+		if seen[release.Version] {
+			return nil, fmt.Errorf("固件版本重复: %s", release.Version)
+		}
+		seen[release.Version] = true
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	allowlist := &models.FirmwareAllowlist{
+		DocType:     models.FirmwareAllowlistObjectType,
+		Vendor:      vendor,
+		Model:       model,
+		Releases:    releases,
+		PublishedBy: caller.MSPID + "/" + caller.CommonName,
+		UpdatedAt:   time.Unix(timestamp.Seconds, int64(timestamp.Nanos)),
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+
+	allowlistKey, err := firmwareAllowlistKey(ctx, vendor, model)
+	if err != nil {
+		return nil, err
+	}
+	allowlistJSON, err := json.Marshal(allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("固件白名单序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(allowlistKey, allowlistJSON)
+	if err != nil {
+		return nil, fmt.Errorf("存储固件白名单时出错: %v", err)
+	}
+
+	// 发送固件白名单变更事件，事件数据即新的白名单
+	err = ctx.GetStub().SetEvent("FirmwareAllowlistUpdated", allowlistJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送固件白名单变更事件失败: %v", err)
+	}
+
+	return allowlist, nil
+}
+
+// GetFirmwareAllowlist 获取某一型号的固件白名单
+func (c *IdentityContract) GetFirmwareAllowlist(ctx contractapi.TransactionContextInterface, vendor string, model string) (*models.FirmwareAllowlist, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	allowlist, err := getFirmwareAllowlist(ctx, vendor, model)
+	if err != nil {
+		return nil, err
+	}
+	if allowlist == nil {
+		return nil, fmt.Errorf("型号 %s/%s 尚未发布固件白名单", vendor, model)
+	}
+
+	return allowlist, nil
+}
+
+// AttestFirmware 记录设备当前运行的固件版本和哈希，并与供应商发布的固件白名单核验
+// 白名单中有该版本但哈希不一致时，自动上报固件篡改风险行为；白名单中没有该版本时只记录，不计分
+func (c *IdentityContract) AttestFirmware(ctx contractapi.TransactionContextInterface, did string, version string, hash string) (*models.FirmwareAttestation, error) {
+	// 检查调用者权限，设备只能证明自身的固件
+	caller, err := requireRole(ctx, models.RoleDevice, models.RoleAdmin, models.RoleOracle)
+	if err != nil {
+		return nil, err
+	}
+	if caller.Role == models.RoleDevice && caller.DID != did {
+		return nil, fmt.Errorf("权限不足: 设备 %s 只能访问自身的记录", caller.DID)
+	}
+
+	if version == "" {
+		return nil, fmt.Errorf("固件版本不能为空")
+	}
+	hash = strings.ToLower(hash)
+	if !validateFirmwareHash(hash) {
+		return nil, fmt.Errorf("无效的固件哈希格式: %s", hash)
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备DID %s 已退役", did)
+	}
+
+	allowlist, err := getFirmwareAllowlist(ctx, deviceInfo.Vendor, deviceInfo.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用交易时间戳，确保各背书节点结果一致
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	deviceInfo.FirmwareVersion = version
+	deviceInfo.FirmwareHash = hash
+	deviceInfo.FirmwareStatus = verifyFirmware(allowlist, version, hash)
+	deviceInfo.AttestedAt = txTime
+	deviceInfo.LastUpdatedAt = txTime
+
+	if deviceInfo.FirmwareStatus == models.FirmwareMismatch {
+		// 官方固件被植入后门时哈希与白名单不一致，按固件篡改行为计分，证据哈希即固件哈希
+		rule, err := getActiveRiskRule(ctx, models.FirmwareTamperBehavior)
+		if err != nil {
+			return nil, err
+		}
+		err = applyRiskBehavior(ctx, deviceInfo, rule, hash, models.FirmwareAttestationSource, caller.MSPID+"/"+caller.CommonName, txTime)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+
+	return &models.FirmwareAttestation{
+		DID:             did,
+		FirmwareVersion: version,
+		FirmwareHash:    hash,
+		Status:          deviceInfo.FirmwareStatus,
+		RiskScore:       deviceInfo.RiskScore,
+		Timestamp:       txTime,
+		TxID:            ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// AttestConfiguration 记录设备当前配置的哈希，配置变更可通过设备历史追溯
+func (c *IdentityContract) AttestConfiguration(ctx contractapi.TransactionContextInterface, did string, configHash string) (*models.DeviceInfo, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	configHash = strings.ToLower(configHash)
+	if !validateFirmwareHash(configHash) {
+		return nil, fmt.Errorf("无效的配置哈希格式: %s", configHash)
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备DID %s 已退役", did)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	deviceInfo.ConfigHash = configHash
+	deviceInfo.AttestedAt = txTime
+	deviceInfo.LastUpdatedAt = txTime
+
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+
+	return deviceInfo, nil
+}
+
+// verifyFirmware 按版本查找白名单中的固件并核验哈希，白名单为nil表示型号尚未发布白名单
+// 白名单中没有该版本时无法判断是篡改还是供应商尚未发布的新版本，返回 unknown_version
+func verifyFirmware(allowlist *models.FirmwareAllowlist, version, hash string) string {
+	if allowlist == nil {
+		return models.FirmwareUnlisted
+	}
+	for _, release := range allowlist.Releases {
+		if release.Version != version {
+			continue
+		}
+		if release.Hash == hash {
+			return models.FirmwareVerified
+		}
+		return models.FirmwareMismatch
+	}
+	return models.FirmwareUnknownVersion
+}
+
+// validateFirmwareHash 验证固件或配置哈希格式（SHA256十六进制），不能为空
+func validateFirmwareHash(hash string) bool {
+	return hash != "" && utils.ValidateEvidenceHash(hash)
+}
+
+// getFirmwareAllowlist 读取某一型号的固件白名单，不存在时返回nil
+func getFirmwareAllowlist(ctx contractapi.TransactionContextInterface, vendor, model string) (*models.FirmwareAllowlist, error) {
+	allowlistKey, err := firmwareAllowlistKey(ctx, vendor, model)
+	if err != nil {
+		return nil, err
+	}
+
+	allowlistJSON, err := ctx.GetStub().GetState(allowlistKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取固件白名单时出错: %v", err)
+	}
+	if allowlistJSON == nil {
+		return nil, nil
+	}
+
+	var allowlist models.FirmwareAllowlist
+	err = json.Unmarshal(allowlistJSON, &allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("固件白名单反序列化失败: %v", err)
+	}
+
+	return &allowlist, nil
+}
+
+// firmwareAllowlistKey 生成固件白名单的复合键
+func firmwareAllowlistKey(ctx contractapi.TransactionContextInterface, vendor, model string) (string, error) {
+	if vendor == "" || model == "" {
+		return "", fmt.Errorf("供应商和型号不能为空")
+	}
+	allowlistKey, err := ctx.GetStub().CreateCompositeKey(models.FirmwareAllowlistObjectType, []string{vendor, model})
+	if err != nil {
+		return "", fmt.Errorf("创建固件白名单复合键失败: %v", err)
+	}
+	return allowlistKey, nil
+}
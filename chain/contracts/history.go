@@ -0,0 +1,141 @@
+package contracts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// GetDeviceHistory 获取设备信息的全部历史版本，按提交顺序从旧到新排列，并计算每个版本相对上一版本的字段变化
+// 需要节点开启历史数据库（core.ledger.history.enableHistoryDatabase）
+func (c *IdentityContract) GetDeviceHistory(ctx contractapi.TransactionContextInterface, did string) ([]*models.DeviceHistoryEntry, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	// 验证DID格式
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(did)
+	if err != nil {
+		return nil, fmt.Errorf("查询设备历史时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	type version struct {
+		entry *models.DeviceHistoryEntry
+		value []byte
+	}
+	versions := []version{}
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个历史版本时出错: %v", err)
+		}
+
+		entry := &models.DeviceHistoryEntry{
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+		}
+		if modification.Timestamp != nil {
+			entry.Timestamp = time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos)).UTC()
+		}
+		if !modification.IsDelete {
+			var deviceInfo models.DeviceInfo
+			err = json.Unmarshal(modification.Value, &deviceInfo)
+			if err != nil {
+				return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
+			}
+			entry.Device = &deviceInfo
+		}
+		versions = append(versions, version{entry: entry, value: modification.Value})
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("设备DID %s 不存在", did)
+	}
+
+	// 历史数据库按区块和交易的提交顺序从新到旧返回，反转为从旧到新；
+	// 交易时间戳由提交交易的客户端提出，存在时钟偏差且可能相同，不能用于排序
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+
+	history := make([]*models.DeviceHistoryEntry, 0, len(versions))
+	var previous []byte
+	for _, v := range versions {
+		if !v.entry.IsDelete && previous != nil {
+			changes, err := diffDeviceFields(previous, v.value)
+			if err != nil {
+				return nil, err
+			}
+			v.entry.Changes = changes
+		}
+		if v.entry.IsDelete {
+			previous = nil
+		} else {
+			previous = v.value
+		}
+		history = append(history, v.entry)
+	}
+
+	return history, nil
+}
+
+// diffDeviceFields 逐字段比较两个版本的设备信息JSON，按字段名排序返回发生变化的字段
+func diffDeviceFields(oldJSON, newJSON []byte) ([]models.FieldChange, error) {
+	var oldFields, newFields map[string]json.RawMessage
+	if err := json.Unmarshal(oldJSON, &oldFields); err != nil {
+		return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
+	}
+	if err := json.Unmarshal(newJSON, &newFields); err != nil {
+		return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
+	}
+
+	names := make([]string, 0, len(newFields))
+	for name := range newFields {
+		names = append(names, name)
+	}
+	for name := range oldFields {
+		if _, ok := newFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.FieldChange{}
+	for _, name := range names {
+		oldValue, newValue := compactJSON(oldFields[name]), compactJSON(newFields[name])
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, models.FieldChange{
+			Field:    name,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+
+	return changes, nil
+}
+
+// compactJSON 去除JSON值中的空白，便于比较和展示
+func compactJSON(value json.RawMessage) string {
+	if len(value) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, value); err != nil {
+		return string(value)
+	}
+	return buf.String()
+}
@@ -0,0 +1,63 @@
+package contracts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+func TestDiffDeviceFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldJSON string
+		newJSON string
+		want    []models.FieldChange
+		wantErr bool
+	}{
+		{
+			name:    "没有变化",
+			oldJSON: `{"did":"d1","riskScore":10}`,
+			newJSON: `{"riskScore": 10, "did": "d1"}`,
+			want:    []models.FieldChange{},
+		},
+		{
+			name:    "按字段名排序返回变化",
+			oldJSON: `{"status":"active","riskScore":10,"did":"d1"}`,
+			newJSON: `{"status":"risky","riskScore":720.5,"did":"d1"}`,
+			want: []models.FieldChange{
+				{Field: "riskScore", OldValue: "10", NewValue: "720.5"},
+				{Field: "status", OldValue: `"active"`, NewValue: `"risky"`},
+			},
+		},
+		{
+			name:    "新增和删除的字段",
+			oldJSON: `{"did":"d1","firmwareHash":"abc"}`,
+			newJSON: `{"did":"d1","attackProfile":["Recon.PortScan"]}`,
+			want: []models.FieldChange{
+				{Field: "attackProfile", OldValue: "", NewValue: `["Recon.PortScan"]`},
+				{Field: "firmwareHash", OldValue: `"abc"`, NewValue: ""},
+			},
+		},
+		{
+			name:    "嵌套值忽略空白差异",
+			oldJSON: `{"services":[{"id":"s1", "type":"mqtt"}]}`,
+			newJSON: `{"services":[{"id":"s1","type":"mqtt"}]}`,
+			want:    []models.FieldChange{},
+		},
+		{name: "旧版本不是JSON对象", oldJSON: `[]`, newJSON: `{}`, wantErr: true},
+		{name: "新版本不是JSON", oldJSON: `{}`, newJSON: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffDeviceFields([]byte(tt.oldJSON), []byte(tt.newJSON))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("diffDeviceFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffDeviceFields() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package contracts
 import (
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -18,62 +17,55 @@ type IdentityContract struct {
 
 // InitLedger 初始化账本
 func (c *IdentityContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return err
+	}
+
 	fmt.Println("身份认证链码初始化")
 	return nil
 }
 
-// RegisterDevice 注册新设备
-func (c *IdentityContract) RegisterDevice(ctx contractapi.TransactionContextInterface, name, model, vendor, deviceID string) error {
-	// 根据设备信息生成DID
-	did := utils.GenerateDID(name, model, vendor, deviceID)
-	
-	// 检查设备是否已存在
-	exists, err := c.DeviceExists(ctx, did)
-	if err != nil {
-		return fmt.Errorf("检查设备是否存在时出错: %v", err)
+// RegisterDevice 注册新设备，同时登记设备公钥，返回设备DID
+// DID由设备公钥和注册随机数（注册交易ID）派生，不能根据公开的设备信息预先计算
+func (c *IdentityContract) RegisterDevice(ctx contractapi.TransactionContextInterface, name, model, vendor, deviceID, publicKeyPEM string) (string, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return "", err
 	}
-	if exists {
-		return fmt.Errorf("设备DID %s 已存在", did)
+
+	// 注册设备的组织作为设备DID的控制者
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("获取调用者MSP ID失败: %v", err)
 	}
 
 	// 使用交易时间戳确保确定性
 	timestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("获取交易时间戳失败: %v", err)
+		return "", fmt.Errorf("获取交易时间戳失败: %v", err)
 	}
 	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
-	
-	// 创建新设备，初始化风险相关参数
-	deviceInfo := models.DeviceInfo{
-		DID:           did,
-		Name:          name,
-		Model:         model,
-		Vendor:        vendor,
-		RiskScore:     0.0,               // 初始风险评分为0
-		AttackIndexI:  0.0,               // 初始攻击画像指数为0
-		AttackProfile: []string{},        // 初始攻击画像为空
-		LastEventTime: txTime,            // 初始事件时间为当前时间
-		Status:        models.StatusActive,
-		CreatedAt:     txTime,
-		LastUpdatedAt: txTime,
-	}
-
-	// 将设备信息转换为JSON并存储
-	deviceInfoJSON, err := json.Marshal(deviceInfo)
-	if err != nil {
-		return fmt.Errorf("设备信息序列化失败: %v", err)
-	}
 
-	// 将设备信息写入账本
-	err = ctx.GetStub().PutState(did, deviceInfoJSON)
+	registration := &models.DeviceRegistration{
+		Name:      name,
+		Model:     model,
+		Vendor:    vendor,
+		DeviceID:  deviceID,
+		PublicKey: publicKeyPEM,
+	}
+	if _, err := validateDeviceRegistration(ctx, registration); err != nil {
+		return "", err
+	}
+	deviceInfo, err := registerDevice(ctx, registration, ctx.GetStub().GetTxID(), models.OrganizationDID(mspID), txTime)
 	if err != nil {
-		return fmt.Errorf("存储设备信息时出错: %v", err)
+		return "", err
 	}
 
 	// 创建设备注册事件
 	registerEvent := models.DeviceEvent{
 		EventType: models.EventTypeRegister,
-		DID:       did,
+		DID:       deviceInfo.DID,
 		Name:      name,
 		Timestamp: txTime.Unix(),
 	}
@@ -81,20 +73,25 @@ func (c *IdentityContract) RegisterDevice(ctx contractapi.TransactionContextInte
 	// 序列化事件数据
 	eventJSON, err := json.Marshal(registerEvent)
 	if err != nil {
-		return fmt.Errorf("事件数据序列化失败: %v", err)
+		return "", fmt.Errorf("事件数据序列化失败: %v", err)
 	}
 
 	// 发送设备注册事件
 	err = ctx.GetStub().SetEvent("DeviceRegistered", eventJSON)
 	if err != nil {
-		return fmt.Errorf("发送设备注册事件失败: %v", err)
+		return "", fmt.Errorf("发送设备注册事件失败: %v", err)
 	}
 
-	return nil
+	return deviceInfo.DID, nil
 }
 
 // GetDevice 获取设备信息
 func (c *IdentityContract) GetDevice(ctx contractapi.TransactionContextInterface, did string) (string, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return "", err
+	}
+
 	// 验证DID格式
 	if !utils.ValidateDID(did) {
 		return "", fmt.Errorf("无效的DID格式: %s", did)
@@ -115,6 +112,11 @@ func (c *IdentityContract) GetDevice(ctx contractapi.TransactionContextInterface
 
 // DeviceExists 检查设备是否存在
 func (c *IdentityContract) DeviceExists(ctx contractapi.TransactionContextInterface, did string) (bool, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return false, err
+	}
+
 	// 从账本中读取设备信息
 	deviceInfoJSON, err := ctx.GetStub().GetState(did)
 	if err != nil {
@@ -125,15 +127,31 @@ func (c *IdentityContract) DeviceExists(ctx contractapi.TransactionContextInterf
 	return deviceInfoJSON != nil, nil
 }
 
-// GetDIDByInfo 根据设备信息生成DID
+// GetDIDByInfo 根据设备信息查找已注册设备的DID，设备未注册时返回错误
 func (c *IdentityContract) GetDIDByInfo(ctx contractapi.TransactionContextInterface, name, model, vendor, deviceID string) (string, error) {
-	// 根据设备信息生成DID
-	did := utils.GenerateDID(name, model, vendor, deviceID)
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return "", err
+	}
+
+	did, err := getRegisteredDID(ctx, name, model, vendor, deviceID)
+	if err != nil {
+		return "", err
+	}
+	if did == "" {
+		return "", fmt.Errorf("设备未注册: %s/%s/%s", vendor, model, deviceID)
+	}
+
 	return did, nil
 }
 
 // VerifyDeviceIdentity 验证设备身份
 func (c *IdentityContract) VerifyDeviceIdentity(ctx contractapi.TransactionContextInterface, did, name, model string) (bool, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return false, err
+	}
+
 	// 验证DID格式
 	if !utils.ValidateDID(did) {
 		return false, fmt.Errorf("无效的DID格式: %s", did)
@@ -168,214 +186,188 @@ func (c *IdentityContract) VerifyDeviceIdentity(ctx contractapi.TransactionConte
 	return true, nil
 }
 
-// UpdateDeviceRiskScore 更新设备风险评分
-func (c *IdentityContract) UpdateDeviceRiskScore(ctx contractapi.TransactionContextInterface, did string, riskScoreStr string, attackIndexStr string, attackProfileJSON string) error {
-	// 验证DID格式
-	if !utils.ValidateDID(did) {
-		return fmt.Errorf("无效的DID格式: %s", did)
+// GetAllDevices 获取所有设备
+//
+// Deprecated: 一次扫描全部设备，设备数量较多时会超出查询的时间和大小限制，请使用 GetAllDevicesWithPagination
+func (c *IdentityContract) GetAllDevices(ctx contractapi.TransactionContextInterface) (string, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return "", err
 	}
-	
-	// 解析风险评分和攻击画像指数
-	riskScore, err := strconv.ParseFloat(riskScoreStr, 64)
+
+	// 按设备键范围扫描所有设备
+	page, err := scanDevices(ctx, &deviceFilter{}, 0, "")
 	if err != nil {
-		return fmt.Errorf("无效的风险评分格式: %s", riskScoreStr)
+		return "", err
 	}
-	
-	attackIndex, err := strconv.ParseFloat(attackIndexStr, 64)
+	devices := page.Devices
+
+	// 将设备列表转换为JSON字符串
+	devicesJSON, err := json.Marshal(devices)
 	if err != nil {
-		return fmt.Errorf("无效的攻击画像指数格式: %s", attackIndexStr)
+		return "", fmt.Errorf("序列化设备列表失败: %v", err)
 	}
-	
-	// 解析攻击画像JSON
-	var attackProfile []string
-	err = json.Unmarshal([]byte(attackProfileJSON), &attackProfile)
-	if err != nil {
-		return fmt.Errorf("攻击画像JSON解析失败: %v", err)
+
+	return string(devicesJSON), nil
+}
+
+// getDeviceInfo 读取设备信息
+func getDeviceInfo(ctx contractapi.TransactionContextInterface, did string) (*models.DeviceInfo, error) {
+	// 验证DID格式
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
 	}
-	
-	// 获取设备信息
+
 	deviceInfoJSON, err := ctx.GetStub().GetState(did)
 	if err != nil {
-		return fmt.Errorf("读取设备信息时出错: %v", err)
+		return nil, fmt.Errorf("读取设备信息时出错: %v", err)
 	}
 	if deviceInfoJSON == nil {
-		return fmt.Errorf("设备DID %s 不存在", did)
+		return nil, fmt.Errorf("设备DID %s 不存在", did)
 	}
-	
-	// 反序列化设备信息
+
 	var deviceInfo models.DeviceInfo
 	err = json.Unmarshal(deviceInfoJSON, &deviceInfo)
 	if err != nil {
-		return fmt.Errorf("设备信息反序列化失败: %v", err)
+		return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
 	}
-	
-	// 更新风险评分和攻击画像
-	deviceInfo.RiskScore = riskScore
-	deviceInfo.AttackIndexI = attackIndex
-	deviceInfo.AttackProfile = attackProfile
-	
-	// 如果风险评分超过阈值，更新设备状态为风险状态
-	if riskScore > models.RiskScoreThreshold && deviceInfo.Status != models.StatusRisky {
-		deviceInfo.Status = models.StatusRisky
-	}
-	
-	// 使用交易时间戳更新最后更新时间和最后事件时间
-	timestamp, err := ctx.GetStub().GetTxTimestamp()
-	if err != nil {
-		return fmt.Errorf("获取交易时间戳失败: %v", err)
-	}
-	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
-	deviceInfo.LastUpdatedAt = txTime
-	deviceInfo.LastEventTime = txTime
-	
-	// 将更新后的设备信息保存到账本
-	deviceInfoJSON, err = json.Marshal(deviceInfo)
+
+	return &deviceInfo, nil
+}
+
+// putDeviceInfo 将设备信息写入账本，统一设置文档类型
+func putDeviceInfo(ctx contractapi.TransactionContextInterface, deviceInfo *models.DeviceInfo) error {
+	deviceInfo.DocType = models.DeviceDocType
+	deviceInfoJSON, err := json.Marshal(deviceInfo)
 	if err != nil {
 		return fmt.Errorf("设备信息序列化失败: %v", err)
 	}
-	
-	err = ctx.GetStub().PutState(did, deviceInfoJSON)
+
+	err = ctx.GetStub().PutState(deviceInfo.DID, deviceInfoJSON)
 	if err != nil {
 		return fmt.Errorf("更新设备信息时出错: %v", err)
 	}
-	
-	// 创建风险评分更新事件
-	riskScoreEvent := models.DeviceEvent{
-		EventType: models.EventTypeRiskUpdate,
-		DID:       did,
-		Name:      deviceInfo.Name,
-		Timestamp: txTime.Unix(),
-		RiskScore: riskScore,
+
+	return nil
+}
+
+// validateDeviceRegistration 检查待注册设备的信息和公钥，设备已注册时同时返回已有的DID
+func validateDeviceRegistration(ctx contractapi.TransactionContextInterface, registration *models.DeviceRegistration) (string, error) {
+	if registration.Name == "" || registration.Model == "" || registration.Vendor == "" || registration.DeviceID == "" {
+		return "", fmt.Errorf("设备名称、型号、供应商和设备ID不能为空")
 	}
 
-	// 序列化事件数据
-	eventJSON, err := json.Marshal(riskScoreEvent)
-	if err != nil {
-		return fmt.Errorf("事件数据序列化失败: %v", err)
+	// 验证设备公钥
+	if _, err := utils.ParseECDSAPublicKey(registration.PublicKey); err != nil {
+		return "", fmt.Errorf("无效的设备公钥: %v", err)
 	}
 
-	// 发送风险评分更新事件
-	err = ctx.GetStub().SetEvent("RiskScoreUpdated", eventJSON)
+	// 同一设备（供应商、型号和设备ID相同）只能注册一次，包括以第一版DID注册的设备
+	registeredDID, err := getRegisteredDID(ctx, registration.Name, registration.Model, registration.Vendor, registration.DeviceID)
 	if err != nil {
-		return fmt.Errorf("发送风险评分更新事件失败: %v", err)
+		return "", err
 	}
-	
-	return nil
+	if registeredDID != "" {
+		return registeredDID, fmt.Errorf("设备已注册，DID: %s", registeredDID)
+	}
+
+	return "", nil
 }
 
-// ResetDeviceRiskScore 重置设备风险评分
-func (c *IdentityContract) ResetDeviceRiskScore(ctx contractapi.TransactionContextInterface, did string) error {
-	// 验证DID格式
-	if !utils.ValidateDID(did) {
-		return fmt.Errorf("无效的DID格式: %s", did)
+// registerDevice 为已通过检查的设备生成DID，写入设备信息、设备注册索引和供应商-型号索引
+// 同一交易中注册多台设备时，每台设备的注册随机数必须不同
+func registerDevice(ctx contractapi.TransactionContextInterface, registration *models.DeviceRegistration, nonce string, controller string, txTime time.Time) (*models.DeviceInfo, error) {
+	// 根据设备公钥和注册随机数生成DID
+	did, err := utils.GenerateDeviceDID(registration.PublicKey, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("生成设备DID失败: %v", err)
 	}
-	
-	// 获取设备信息
+
+	// 检查设备是否已存在
 	deviceInfoJSON, err := ctx.GetStub().GetState(did)
 	if err != nil {
-		return fmt.Errorf("读取设备信息时出错: %v", err)
+		return nil, fmt.Errorf("读取设备信息时出错: %v", err)
 	}
-	if deviceInfoJSON == nil {
-		return fmt.Errorf("设备DID %s 不存在", did)
+	if deviceInfoJSON != nil {
+		return nil, fmt.Errorf("设备DID %s 已存在", did)
 	}
-	
-	// 反序列化设备信息
-	var deviceInfo models.DeviceInfo
-	err = json.Unmarshal(deviceInfoJSON, &deviceInfo)
-	if err != nil {
-		return fmt.Errorf("设备信息反序列化失败: %v", err)
+
+	// 创建新设备，初始化风险相关参数
+	deviceInfo := &models.DeviceInfo{
+		DID:               did,
+		Name:              registration.Name,
+		Model:             registration.Model,
+		Vendor:            registration.Vendor,
+		DeviceID:          registration.DeviceID,
+		RegistrationNonce: nonce,
+		PublicKey:         registration.PublicKey,
+		Controller:        controller,
+		Services:          []models.ServiceEndpoint{},
+		RiskScore:         0.0,        // 初始风险评分为0
+		AttackIndexI:      0.0,        // 初始攻击画像指数为0
+		AttackProfile:     []string{}, // 初始攻击画像为空
+		LastEventTime:     txTime,     // 初始事件时间为当前时间
+		Status:            models.StatusActive,
+		ConnectionStatus:  models.StatusOffline,
+		CreatedAt:         txTime,
+		LastUpdatedAt:     txTime,
 	}
-	
-	// 重置风险评分和攻击画像
-	deviceInfo.RiskScore = 0.0
-	deviceInfo.AttackIndexI = 0.0
-	deviceInfo.AttackProfile = []string{}
-	
-	// 如果设备状态为风险状态，恢复为活跃状态
-	if deviceInfo.Status == models.StatusRisky {
-		deviceInfo.Status = models.StatusActive
+
+	// 将设备信息写入账本
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
 	}
-	
-	// 使用交易时间戳更新最后更新时间
-	timestamp, err := ctx.GetStub().GetTxTimestamp()
-	if err != nil {
-		return fmt.Errorf("获取交易时间戳失败: %v", err)
+
+	// 建立设备注册索引，用于根据设备信息查找DID
+	if err := putRegistrationIndex(ctx, deviceInfo); err != nil {
+		return nil, err
 	}
-	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
-	deviceInfo.LastUpdatedAt = txTime
-	
-	// 将更新后的设备信息保存到账本
-	deviceInfoJSON, err = json.Marshal(deviceInfo)
+
+	// 建立供应商-型号索引，用于按供应商和型号查询设备
+	if err := putVendorModelIndex(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+
+	return deviceInfo, nil
+}
+
+// getRegisteredDID 根据设备信息查找已注册设备的DID，未注册时返回空字符串
+// 先查设备注册索引，再按第一版DID的生成规则查找索引建立前注册的设备
+func getRegisteredDID(ctx contractapi.TransactionContextInterface, name, model, vendor, deviceID string) (string, error) {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(models.DeviceRegistrationIndex, []string{vendor, model, deviceID})
 	if err != nil {
-		return fmt.Errorf("设备信息序列化失败: %v", err)
+		return "", fmt.Errorf("创建设备注册索引复合键失败: %v", err)
 	}
-	
-	err = ctx.GetStub().PutState(did, deviceInfoJSON)
+	didBytes, err := ctx.GetStub().GetState(indexKey)
 	if err != nil {
-		return fmt.Errorf("更新设备信息时出错: %v", err)
+		return "", fmt.Errorf("读取设备注册索引时出错: %v", err)
 	}
-	
-	// 创建风险评分重置事件
-	resetEvent := models.DeviceEvent{
-		EventType: models.EventTypeRiskReset,
-		DID:       did,
-		Name:      deviceInfo.Name,
-		Timestamp: txTime.Unix(),
-		RiskScore: 0.0,
+	if didBytes != nil {
+		return string(didBytes), nil
 	}
 
-	// 序列化事件数据
-	eventJSON, err := json.Marshal(resetEvent)
+	legacyDID := utils.GenerateDID(name, model, vendor, deviceID)
+	deviceInfoJSON, err := ctx.GetStub().GetState(legacyDID)
 	if err != nil {
-		return fmt.Errorf("事件数据序列化失败: %v", err)
+		return "", fmt.Errorf("读取设备信息时出错: %v", err)
 	}
-
-	// 发送风险评分重置事件
-	err = ctx.GetStub().SetEvent("RiskScoreReset", eventJSON)
-	if err != nil {
-		return fmt.Errorf("发送风险评分重置事件失败: %v", err)
+	if deviceInfoJSON == nil {
+		return "", nil
 	}
-	
-	return nil
+
+	return legacyDID, nil
 }
 
-// GetAllDevices 获取所有设备
-func (c *IdentityContract) GetAllDevices(ctx contractapi.TransactionContextInterface) (string, error) {
-	// 获取所有设备的迭代器
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+// putRegistrationIndex 写入设备注册索引，值为设备DID
+func putRegistrationIndex(ctx contractapi.TransactionContextInterface, deviceInfo *models.DeviceInfo) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(models.DeviceRegistrationIndex, []string{deviceInfo.Vendor, deviceInfo.Model, deviceInfo.DeviceID})
 	if err != nil {
-		return "", fmt.Errorf("获取状态范围时出错: %v", err)
-	}
-	defer resultsIterator.Close()
-
-	// 存储所有设备的切片
-	var devices []*models.DeviceInfo
-	
-	// 遍历所有状态
-	for resultsIterator.HasNext() {
-		// 获取下一个键值对
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return "", fmt.Errorf("获取下一个状态时出错: %v", err)
-		}
-
-		// 反序列化设备信息
-		var deviceInfo models.DeviceInfo
-		err = json.Unmarshal(queryResponse.Value, &deviceInfo)
-		if err != nil {
-			// 跳过非设备信息的状态
-			continue
-		}
-
-		// 将设备信息添加到切片中
-		devices = append(devices, &deviceInfo)
+		return fmt.Errorf("创建设备注册索引复合键失败: %v", err)
 	}
-
-	// 将设备列表转换为JSON字符串
-	devicesJSON, err := json.Marshal(devices)
+	err = ctx.GetStub().PutState(indexKey, []byte(deviceInfo.DID))
 	if err != nil {
-		return "", fmt.Errorf("序列化设备列表失败: %v", err)
+		return fmt.Errorf("存储设备注册索引时出错: %v", err)
 	}
 
-	return string(devicesJSON), nil
-}
\ No newline at end of file
+	return nil
+}
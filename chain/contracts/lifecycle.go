@@ -0,0 +1,227 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// SuspendDevice 暂停设备，暂停后设备不能认证、连接或申请凭证
+func (c *IdentityContract) SuspendDevice(ctx contractapi.TransactionContextInterface, did string, reason string) (*models.DeviceInfo, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := transitionDevice(ctx, deviceInfo, models.StatusInactive, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+	if err := emitLifecycleEvent(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return deviceInfo, nil
+}
+
+// ReactivateDevice 恢复已暂停的设备，恢复后的状态由当前风险评分所在的风险等级决定
+func (c *IdentityContract) ReactivateDevice(ctx contractapi.TransactionContextInterface, did string, reason string) (*models.DeviceInfo, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status != models.StatusInactive {
+		return nil, fmt.Errorf("设备状态为 %s, 只有已暂停的设备可以恢复", deviceInfo.Status)
+	}
+
+	tier, err := riskTierForScore(ctx, deviceInfo.RiskScore)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := transitionDevice(ctx, deviceInfo, tier.DeviceStatus, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+	if err := emitLifecycleEvent(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return deviceInfo, nil
+}
+
+// DecommissionDevice 退役设备，退役为终止状态，设备当前有效的凭证同时吊销
+func (c *IdentityContract) DecommissionDevice(ctx contractapi.TransactionContextInterface, did string, reason string) (*models.DeviceInfo, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := decommissionDevice(ctx, deviceInfo, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := emitLifecycleEvent(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return deviceInfo, nil
+}
+
+// ReplaceDevice 用新设备替换旧设备，旧设备退役并与新设备互相关联
+func (c *IdentityContract) ReplaceDevice(ctx contractapi.TransactionContextInterface, oldDID string, newDID string, reason string) (*models.DeviceInfo, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if oldDID == newDID {
+		return nil, fmt.Errorf("新旧设备DID不能相同")
+	}
+
+	oldDevice, err := getDeviceInfo(ctx, oldDID)
+	if err != nil {
+		return nil, err
+	}
+	newDevice, err := getDeviceInfo(ctx, newDID)
+	if err != nil {
+		return nil, err
+	}
+	if newDevice.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("新设备 %s 已退役", newDID)
+	}
+	if newDevice.Replaces != "" {
+		return nil, fmt.Errorf("新设备 %s 已替换过设备 %s", newDID, newDevice.Replaces)
+	}
+
+	oldDevice.ReplacedBy = newDID
+	event, err := decommissionDevice(ctx, oldDevice, reason)
+	if err != nil {
+		return nil, err
+	}
+	event.ReplacedBy = newDID
+
+	newDevice.Replaces = oldDID
+	newDevice.LastUpdatedAt = oldDevice.LastUpdatedAt
+	if err := putDeviceInfo(ctx, newDevice); err != nil {
+		return nil, err
+	}
+
+	if err := emitLifecycleEvent(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return newDevice, nil
+}
+
+// decommissionDevice 将设备转换为退役状态并吊销其有效凭证
+func decommissionDevice(ctx contractapi.TransactionContextInterface, deviceInfo *models.DeviceInfo, reason string) (*models.LifecycleEvent, error) {
+	event, err := transitionDevice(ctx, deviceInfo, models.StatusDecommissioned, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+
+	records, err := getCredentialRecords(ctx, deviceInfo.DID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Status != models.CredentialStatusActive {
+			continue
+		}
+		record.Status = models.CredentialStatusRevoked
+		record.RevokedAt = deviceInfo.StatusChangedAt.UTC()
+		record.RevocationReason = "设备已退役"
+		if err := putCredentialRecord(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
+
+// transitionDevice 按生命周期状态机修改设备状态，记录原因、执行者和时间，返回待发送的事件
+func transitionDevice(ctx contractapi.TransactionContextInterface, deviceInfo *models.DeviceInfo, toStatus string, reason string) (*models.LifecycleEvent, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("状态变更原因不能为空")
+	}
+	if !models.CanTransition(deviceInfo.Status, toStatus) {
+		return nil, fmt.Errorf("设备状态不能从 %s 变更为 %s", deviceInfo.Status, toStatus)
+	}
+
+	actor, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者身份失败: %v", err)
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	event := &models.LifecycleEvent{
+		DID:        deviceInfo.DID,
+		FromStatus: deviceInfo.Status,
+		ToStatus:   toStatus,
+		Reason:     reason,
+		Actor:      actor,
+		Timestamp:  txTime.Unix(),
+	}
+
+	// 暂停或退役的设备立即断开连接
+	if toStatus == models.StatusInactive || toStatus == models.StatusDecommissioned {
+		if err := closeDeviceSession(ctx, deviceInfo, "设备状态变更为 "+toStatus, txTime); err != nil {
+			return nil, err
+		}
+	}
+
+	deviceInfo.Status = toStatus
+	deviceInfo.StatusReason = reason
+	deviceInfo.StatusChangedBy = actor
+	deviceInfo.StatusChangedAt = txTime
+	deviceInfo.LastUpdatedAt = txTime
+
+	return event, nil
+}
+
+// emitLifecycleEvent 发送设备生命周期变更事件
+func emitLifecycleEvent(ctx contractapi.TransactionContextInterface, event *models.LifecycleEvent) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("事件数据序列化失败: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent("DeviceLifecycleChanged", eventJSON)
+	if err != nil {
+		return fmt.Errorf("发送设备生命周期变更事件失败: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// 设备信息以DID为键存储，按DID前缀限定范围扫描，避免遍历其他类型的记录
+var (
+	deviceKeyStart = models.DeviceDIDPrefix
+	deviceKeyEnd   = models.DeviceDIDPrefix + string(utf8.MaxRune)
+)
+
+// errRichQueryUnsupported 状态数据库（LevelDB）不支持富查询
+var errRichQueryUnsupported = errors.New("状态数据库不支持富查询")
+
+// deviceFilter 设备查询条件，空字段表示不限
+type deviceFilter struct {
+	Status   string
+	Vendor   string
+	Model    string
+	Online   bool
+	MinScore *float64
+	MaxScore *float64
+}
+
+// selector 生成CouchDB富查询的选择器
+func (f *deviceFilter) selector() map[string]interface{} {
+	selector := map[string]interface{}{"docType": models.DeviceDocType}
+	if f.Status != "" {
+		selector["status"] = f.Status
+	}
+	if f.Vendor != "" {
+		selector["vendor"] = f.Vendor
+	}
+	if f.Model != "" {
+		selector["model"] = f.Model
+	}
+	if f.Online {
+		selector["connectionStatus"] = models.StatusOnline
+	}
+	if f.MinScore != nil || f.MaxScore != nil {
+		scoreRange := map[string]float64{}
+		if f.MinScore != nil {
+			scoreRange["$gte"] = *f.MinScore
+		}
+		if f.MaxScore != nil {
+			scoreRange["$lte"] = *f.MaxScore
+		}
+		selector["riskScore"] = scoreRange
+	}
+	return selector
+}
+
+// match 检查设备是否满足查询条件，用于不支持富查询时的范围扫描
+func (f *deviceFilter) match(deviceInfo *models.DeviceInfo) bool {
+	if f.Status != "" && deviceInfo.Status != f.Status {
+		return false
+	}
+	if f.Vendor != "" && deviceInfo.Vendor != f.Vendor {
+		return false
+	}
+	if f.Model != "" && deviceInfo.Model != f.Model {
+		return false
+	}
+	if f.Online && deviceInfo.ConnectionStatus != models.StatusOnline {
+		return false
+	}
+	if f.MinScore != nil && deviceInfo.RiskScore < *f.MinScore {
+		return false
+	}
+	if f.MaxScore != nil && deviceInfo.RiskScore > *f.MaxScore {
+		return false
+	}
+	return true
+}
+
+// QueryDevices 按设备状态、供应商和型号分页查询设备，条件为空表示不限
+func (c *IdentityContract) QueryDevices(ctx contractapi.TransactionContextInterface, status, vendor, model, pageSizeStr, bookmark string) (*models.DevicePage, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	pageSize, err := parsePageSize(pageSizeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryDevices(ctx, &deviceFilter{Status: status, Vendor: vendor, Model: model}, pageSize, bookmark)
+}
+
+// GetAllDevicesWithPagination 分页获取所有设备
+func (c *IdentityContract) GetAllDevicesWithPagination(ctx contractapi.TransactionContextInterface, pageSizeStr, bookmark string) (*models.DevicePage, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	pageSize, err := parsePageSize(pageSizeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryDevices(ctx, &deviceFilter{}, pageSize, bookmark)
+}
+
+// GetHighRiskDevicesWithPagination 分页获取处于最高风险等级的设备
+func (c *RiskContract) GetHighRiskDevicesWithPagination(ctx contractapi.TransactionContextInterface, pageSizeStr, bookmark string) (*models.DevicePage, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	pageSize, err := parsePageSize(pageSizeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// 读取风险等级策略
+	policy, err := loadRiskTierPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	highestTier := policy.HighestTier()
+
+	return queryDevices(ctx, &deviceFilter{MinScore: &highestTier.MinScore}, pageSize, bookmark)
+}
+
+// GetDevicesByRiskScoreRangeWithPagination 分页获取特定风险评分范围内的设备
+func (c *RiskContract) GetDevicesByRiskScoreRangeWithPagination(ctx contractapi.TransactionContextInterface, minScoreStr, maxScoreStr, pageSizeStr, bookmark string) (*models.DevicePage, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	minScore, err := strconv.ParseFloat(minScoreStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("最小风险评分格式无效: %v", err)
+	}
+	maxScore, err := strconv.ParseFloat(maxScoreStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("最大风险评分格式无效: %v", err)
+	}
+	if minScore < 0 || minScore > maxScore {
+		return nil, fmt.Errorf("风险评分范围无效")
+	}
+
+	pageSize, err := parsePageSize(pageSizeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryDevices(ctx, &deviceFilter{MinScore: &minScore, MaxScore: &maxScore}, pageSize, bookmark)
+}
+
+// queryDevices 分页查询满足条件的设备
+// 优先使用CouchDB富查询，状态数据库为LevelDB时不支持富查询，改为按设备键范围扫描并逐条过滤；富查询的其他错误直接返回
+func queryDevices(ctx contractapi.TransactionContextInterface, filter *deviceFilter, pageSize int, bookmark string) (*models.DevicePage, error) {
+	page, err := richQueryDevices(ctx, filter, pageSize, bookmark)
+	if err != errRichQueryUnsupported {
+		return page, err
+	}
+
+	return scanDevices(ctx, filter, pageSize, bookmark)
+}
+
+// richQueryDevices 使用CouchDB富查询分页查询设备，查询字段已在 META-INF/statedb/couchdb/indexes 中建立索引
+func richQueryDevices(ctx contractapi.TransactionContextInterface, filter *deviceFilter, pageSize int, bookmark string) (*models.DevicePage, error) {
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": filter.selector()})
+	if err != nil {
+		return nil, fmt.Errorf("查询条件序列化失败: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), int32(pageSize), bookmark)
+	if err != nil {
+		// LevelDB 返回 "ExecuteQueryWithPagination not supported for leveldb"
+		if strings.Contains(err.Error(), "not supported for leveldb") {
+			return nil, errRichQueryUnsupported
+		}
+		return nil, fmt.Errorf("执行富查询时出错: %v", err)
+	}
+	if resultsIterator == nil {
+		return nil, errRichQueryUnsupported
+	}
+	defer resultsIterator.Close()
+
+	page := &models.DevicePage{Devices: []*models.DeviceInfo{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var deviceInfo models.DeviceInfo
+		err = json.Unmarshal(queryResponse.Value, &deviceInfo)
+		if err != nil {
+			return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
+		}
+		page.Devices = append(page.Devices, &deviceInfo)
+	}
+
+	// 本页未取满说明已没有更多记录
+	if metadata != nil && int(metadata.FetchedRecordsCount) >= pageSize {
+		page.Bookmark = metadata.Bookmark
+	}
+
+	return page, nil
+}
+
+// scanDevices 按设备键范围扫描满足条件的设备，书签为上一页最后一个设备的DID
+// pageSize 为0时不分页，返回全部满足条件的设备
+func scanDevices(ctx contractapi.TransactionContextInterface, filter *deviceFilter, pageSize int, bookmark string) (*models.DevicePage, error) {
+	startKey := deviceKeyStart
+	if bookmark != "" {
+		if !strings.HasPrefix(bookmark, models.DeviceDIDPrefix) {
+			return nil, fmt.Errorf("无效的书签: %s", bookmark)
+		}
+		startKey = bookmark
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, deviceKeyEnd)
+	if err != nil {
+		return nil, fmt.Errorf("获取状态范围时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &models.DevicePage{Devices: []*models.DeviceInfo{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+		if queryResponse.Key == bookmark {
+			continue
+		}
+
+		var deviceInfo models.DeviceInfo
+		err = json.Unmarshal(queryResponse.Value, &deviceInfo)
+		if err != nil {
+			return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
+		}
+		// 升级前写入的设备信息没有文档类型
+		if deviceInfo.DocType != "" && deviceInfo.DocType != models.DeviceDocType {
+			continue
+		}
+		if !filter.match(&deviceInfo) {
+			continue
+		}
+
+		if pageSize > 0 && len(page.Devices) == pageSize {
+			page.Bookmark = page.Devices[len(page.Devices)-1].DID
+			break
+		}
+		page.Devices = append(page.Devices, &deviceInfo)
+	}
+
+	return page, nil
+}
+
+// BackfillDeviceDocType 为升级前写入、之后未再更新的设备信息补写文档类型，返回补写的设备数量
+// 状态数据库为CouchDB时，没有文档类型的设备不会出现在富查询结果中
+func (c *IdentityContract) BackfillDeviceDocType(ctx contractapi.TransactionContextInterface) (int, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	page, err := scanDevices(ctx, &deviceFilter{}, 0, "")
+	if err != nil {
+		return 0, err
+	}
+
+	// 只补写文档类型，不修改最后更新时间，避免使其他客户端的前置条件失效
+	count := 0
+	for _, deviceInfo := range page.Devices {
+		if deviceInfo.DocType != "" {
+			continue
+		}
+		if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+			return 0, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// parsePageSize 解析分页大小，为空或0时使用默认值
+func parsePageSize(value string) (int, error) {
+	if value == "" {
+		return models.DefaultPageSize, nil
+	}
+
+	pageSize, err := strconv.Atoi(value)
+	if err != nil || pageSize < 0 {
+		return 0, fmt.Errorf("分页大小格式无效: %s", value)
+	}
+	if pageSize == 0 {
+		return models.DefaultPageSize, nil
+	}
+	if pageSize > models.MaxPageSize {
+		return 0, fmt.Errorf("分页大小不能超过 %d", models.MaxPageSize)
+	}
+
+	return pageSize, nil
+}
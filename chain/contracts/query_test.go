@@ -0,0 +1,154 @@
+package contracts
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+func TestScanDevicesPaging(t *testing.T) {
+	ctx, stub := newAdminContext(t)
+	stub.startTx("tx-devices", eventTime0)
+
+	// 设备按DID排序后交替为 active 和 risky，第一个设备为升级前写入、没有文档类型的记录
+	var dids []string
+	for i := 0; i < 6; i++ {
+		dids = append(dids, utils.GenerateDID("camera", "C1", "acme", fmt.Sprintf("SN%03d", i)))
+	}
+	sort.Strings(dids)
+	statuses := map[string]string{}
+	for i, did := range dids {
+		status := models.StatusActive
+		if i%2 == 1 {
+			status = models.StatusRisky
+		}
+		statuses[did] = status
+		if i == 0 {
+			legacy := fmt.Sprintf(`{"did":%q,"status":%q}`, did, status)
+			if err := stub.PutState(did, []byte(legacy)); err != nil {
+				t.Fatalf("PutState() error = %v", err)
+			}
+			continue
+		}
+		if err := putDeviceInfo(ctx, &models.DeviceInfo{DID: did, Status: status}); err != nil {
+			t.Fatalf("putDeviceInfo() error = %v", err)
+		}
+	}
+	// 设备键范围内其他文档类型的记录不属于设备
+	other := models.DeviceDIDPrefix + "zzz-other"
+	if err := stub.PutState(other, []byte(`{"docType":"other","did":"x"}`)); err != nil {
+		t.Fatalf("PutState() error = %v", err)
+	}
+
+	withStatus := func(status string) []string {
+		var want []string
+		for _, did := range dids {
+			if status == "" || statuses[did] == status {
+				want = append(want, did)
+			}
+		}
+		return want
+	}
+
+	tests := []struct {
+		name     string
+		filter   *deviceFilter
+		pageSize int
+		want     []string
+		pages    int
+	}{
+		{name: "不分页", filter: &deviceFilter{}, pageSize: 0, want: withStatus(""), pages: 1},
+		{name: "每页2个", filter: &deviceFilter{}, pageSize: 2, want: withStatus(""), pages: 3},
+		{name: "每页4个", filter: &deviceFilter{}, pageSize: 4, want: withStatus(""), pages: 2},
+		{name: "按状态过滤", filter: &deviceFilter{Status: models.StatusRisky}, pageSize: 2, want: withStatus(models.StatusRisky), pages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			bookmark := ""
+			pages := 0
+			for {
+				page, err := scanDevices(ctx, tt.filter, tt.pageSize, bookmark)
+				if err != nil {
+					t.Fatalf("scanDevices() error = %v", err)
+				}
+				pages++
+				if tt.pageSize > 0 && len(page.Devices) > tt.pageSize {
+					t.Fatalf("第 %d 页有 %d 个设备, 超过 %d", pages, len(page.Devices), tt.pageSize)
+				}
+				for _, device := range page.Devices {
+					got = append(got, device.DID)
+				}
+				if page.Bookmark == "" {
+					break
+				}
+				if page.Bookmark != page.Devices[len(page.Devices)-1].DID {
+					t.Fatalf("书签 = %s, 应为本页最后一个设备的DID", page.Bookmark)
+				}
+				bookmark = page.Bookmark
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("设备 = %v, want %v", got, tt.want)
+			}
+			if pages != tt.pages {
+				t.Errorf("页数 = %d, want %d", pages, tt.pages)
+			}
+		})
+	}
+
+	if _, err := scanDevices(ctx, &deviceFilter{}, 2, "not-a-did"); err == nil {
+		t.Error("无效的书签应返回错误")
+	}
+}
+
+func TestGetOnlineDevices(t *testing.T) {
+	ctx, stub := newAdminContext(t)
+	contract := &IdentityContract{}
+	stub.startTx("tx-devices", eventTime0)
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		did := utils.GenerateDID("camera", "C1", "acme", fmt.Sprintf("SN%03d", i))
+		connection := models.StatusOffline
+		if i != 2 {
+			connection = models.StatusOnline
+			want = append(want, did)
+		}
+		if err := putDeviceInfo(ctx, &models.DeviceInfo{DID: did, Status: models.StatusActive, ConnectionStatus: connection}); err != nil {
+			t.Fatalf("putDeviceInfo() error = %v", err)
+		}
+	}
+	sort.Strings(want)
+
+	var got []string
+	bookmark := ""
+	for pages := 1; ; pages++ {
+		page, err := contract.GetOnlineDevices(ctx, "3", bookmark)
+		if err != nil {
+			t.Fatalf("GetOnlineDevices() error = %v", err)
+		}
+		if len(page.Devices) > 3 {
+			t.Fatalf("第 %d 页有 %d 个设备, 超过 3", pages, len(page.Devices))
+		}
+		for _, device := range page.Devices {
+			got = append(got, device.DID)
+		}
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("在线设备 = %v, want %v", got, want)
+	}
+	if _, err := contract.GetOnlineDevices(ctx, "abc", ""); err == nil {
+		t.Error("无效的每页数量应返回错误")
+	}
+}
@@ -0,0 +1,155 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// RegisterDevices 在一笔交易中批量注册设备，返回每台设备的DID
+// 任意一台设备未通过校验时整批不注册，错误信息列出所有未通过校验的设备的序号（从1开始）、供应商、型号、设备ID和原因，
+// 与 ValidateDeviceBatch 返回结果中的 index 和 error 一致
+func (c *IdentityContract) RegisterDevices(ctx contractapi.TransactionContextInterface, devicesJSON string) ([]*models.DeviceRegistrationResult, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	registrations, err := parseDeviceBatch(devicesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := validateDeviceBatch(ctx, registrations)
+	if err != nil {
+		return nil, err
+	}
+	var failures []string
+	for _, result := range results {
+		if result.Error != "" {
+			failures = append(failures, fmt.Sprintf("第 %d 台 (%s/%s/%s): %s", result.Index, result.Vendor, result.Model, result.DeviceID, result.Error))
+		}
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("批量注册失败，整批未注册，%d 台设备未通过校验: %s；可调用 ValidateDeviceBatch 获取每台设备的校验结果", len(failures), strings.Join(failures, "；"))
+	}
+
+	// 注册设备的组织作为设备DID的控制者
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者MSP ID失败: %v", err)
+	}
+
+	// 使用交易时间戳确保确定性
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	// 同一交易中的设备以 <交易ID>-<序号> 作为注册随机数，保证公钥相同时DID也不同
+	registerEvents := make([]models.DeviceEvent, 0, len(registrations))
+	for i, registration := range registrations {
+		nonce := fmt.Sprintf("%s-%d", ctx.GetStub().GetTxID(), i+1)
+		deviceInfo, err := registerDevice(ctx, registration, nonce, models.OrganizationDID(mspID), txTime)
+		if err != nil {
+			return nil, fmt.Errorf("注册第 %d 台设备 (%s/%s/%s) 失败，整批未注册: %v", i+1, registration.Vendor, registration.Model, registration.DeviceID, err)
+		}
+		results[i].DID = deviceInfo.DID
+
+		registerEvents = append(registerEvents, models.DeviceEvent{
+			EventType: models.EventTypeRegister,
+			DID:       deviceInfo.DID,
+			Name:      deviceInfo.Name,
+			Timestamp: txTime.Unix(),
+		})
+	}
+
+	// 每笔交易只能发送一个事件，批量注册的设备合并为一个事件
+	eventJSON, err := json.Marshal(registerEvents)
+	if err != nil {
+		return nil, fmt.Errorf("事件数据序列化失败: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("DevicesRegistered", eventJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送设备批量注册事件失败: %v", err)
+	}
+
+	return results, nil
+}
+
+// ValidateDeviceBatch 校验一批待注册的设备但不写入账本，返回每台设备的校验结果
+// 设备已注册时结果中带有已有的DID，供导入前预览
+func (c *IdentityContract) ValidateDeviceBatch(ctx contractapi.TransactionContextInterface, devicesJSON string) ([]*models.DeviceRegistrationResult, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	registrations, err := parseDeviceBatch(devicesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return validateDeviceBatch(ctx, registrations)
+}
+
+// parseDeviceBatch 解析批量注册的设备列表，检查批次大小
+func parseDeviceBatch(devicesJSON string) ([]*models.DeviceRegistration, error) {
+	var registrations []*models.DeviceRegistration
+	err := json.Unmarshal([]byte(devicesJSON), &registrations)
+	if err != nil {
+		return nil, fmt.Errorf("设备列表JSON解析失败: %v", err)
+	}
+	if len(registrations) == 0 {
+		return nil, fmt.Errorf("设备列表不能为空")
+	}
+	if len(registrations) > models.MaxRegistrationBatchSize {
+		return nil, fmt.Errorf("一次最多注册 %d 台设备", models.MaxRegistrationBatchSize)
+	}
+	for i, registration := range registrations {
+		if registration == nil {
+			return nil, fmt.Errorf("第 %d 台设备信息为空", i+1)
+		}
+	}
+
+	return registrations, nil
+}
+
+// validateDeviceBatch 逐台校验设备，并检查批次内供应商、型号和设备ID是否重复
+// 同一交易中的写入对后续读取不可见，批次内的重复只能在这里检查
+func validateDeviceBatch(ctx contractapi.TransactionContextInterface, registrations []*models.DeviceRegistration) ([]*models.DeviceRegistrationResult, error) {
+	results := make([]*models.DeviceRegistrationResult, 0, len(registrations))
+	seen := make(map[string]int)
+	for i, registration := range registrations {
+		result := &models.DeviceRegistrationResult{
+			Index:    i + 1,
+			Vendor:   registration.Vendor,
+			Model:    registration.Model,
+			DeviceID: registration.DeviceID,
+		}
+		results = append(results, result)
+
+		deviceKey, err := ctx.GetStub().CreateCompositeKey(models.DeviceRegistrationIndex, []string{registration.Vendor, registration.Model, registration.DeviceID})
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 台设备创建注册索引复合键失败: %v", result.Index, err)
+		}
+		if first, ok := seen[deviceKey]; ok {
+			result.Error = fmt.Sprintf("与第 %d 台设备重复", first)
+			continue
+		}
+		seen[deviceKey] = result.Index
+
+		existingDID, err := validateDeviceRegistration(ctx, registration)
+		if err != nil {
+			result.ExistingDID = existingDID
+			result.Error = err.Error()
+		}
+	}
+
+	return results, nil
+}
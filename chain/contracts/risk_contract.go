@@ -3,7 +3,9 @@ package contracts
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/Tittifer/IEEE/chain/models"
@@ -15,94 +17,271 @@ type RiskContract struct {
 	contractapi.Contract
 }
 
-// InitRiskLedger 初始化风险管理账本
+// InitRiskLedger 初始化风险管理账本，写入默认风险规则
 func (c *RiskContract) InitRiskLedger(ctx contractapi.TransactionContextInterface) error {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return err
+	}
+
 	fmt.Println("风险管理链码初始化")
-	return nil
+	return seedDefaultRiskRules(ctx)
 }
 
-// UpdateRiskScore 更新设备风险评分
-func (c *RiskContract) UpdateRiskScore(ctx contractapi.TransactionContextInterface, did string, newScoreStr string, attackIndexStr string, attackProfileJSON string) error {
+// ReportRiskBehavior 上报设备风险行为，由链码根据风险规则计算并更新风险评分
+// 每次上报同时写入一条风险事件记录，honeypointID 为捕获该行为的蜜点
+// expectedLastUpdatedAt 为上报方读取到的设备最后更新时间（RFC3339），与账本不一致时拒绝上报，为空时不检查
+func (c *RiskContract) ReportRiskBehavior(ctx contractapi.TransactionContextInterface, did string, behaviorType string, evidenceHash string, honeypointID string, expectedLastUpdatedAt string) (*models.DeviceInfo, error) {
+	// 检查调用者权限
+	reporter, err := requireRole(ctx, models.RoleOracle)
+	if err != nil {
+		return nil, err
+	}
+
 	// 验证DID格式
 	if !utils.ValidateDID(did) {
-		return fmt.Errorf("无效的DID格式: %s", did)
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
 	}
 	
-	// 将字符串转换为浮点数
-	newScore, err := strconv.ParseFloat(newScoreStr, 64)
+	// 验证蜜点ID
+	if honeypointID == "" {
+		return nil, fmt.Errorf("蜜点ID不能为空")
+	}
+	
+	// 验证证据哈希格式
+	if !utils.ValidateEvidenceHash(evidenceHash) {
+		return nil, fmt.Errorf("无效的证据哈希格式: %s", evidenceHash)
+	}
+	
+	// 从链上规则库查找生效的风险规则
+	rule, err := getActiveRiskRule(ctx, behaviorType)
 	if err != nil {
-		return fmt.Errorf("风险评分格式无效: %v", err)
+		return nil, err
 	}
 	
-	// 验证风险评分范围
-	if newScore < 0 {
-		return fmt.Errorf("风险评分必须大于等于0")
+	// 从账本中读取设备信息
+	deviceInfoJSON, err := ctx.GetStub().GetState(did)
+	if err != nil {
+		return nil, fmt.Errorf("读取设备信息时出错: %v", err)
+	}
+	if deviceInfoJSON == nil {
+		return nil, fmt.Errorf("设备DID %s 不存在", did)
 	}
 	
-	attackIndex, err := strconv.ParseFloat(attackIndexStr, 64)
+	// 反序列化设备信息
+	var deviceInfo models.DeviceInfo
+	err = json.Unmarshal(deviceInfoJSON, &deviceInfo)
 	if err != nil {
-		return fmt.Errorf("攻击画像指数格式无效: %v", err)
+		return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
 	}
 	
-	// 验证攻击画像指数范围
-	if attackIndex < 0 {
-		return fmt.Errorf("攻击画像指数必须大于等于0")
+	// 已退役的设备不再接受风险上报
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备DID %s 已退役", did)
 	}
 	
-	// 解析攻击画像JSON
-	var attackProfile []string
-	err = json.Unmarshal([]byte(attackProfileJSON), &attackProfile)
+	// 检查设备自上报方读取后是否被修改
+	if err := checkLastUpdatedAt(&deviceInfo, expectedLastUpdatedAt); err != nil {
+		return nil, err
+	}
+	
+	// 使用交易时间戳计算，确保各背书节点结果一致
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	
+	err = applyRiskBehavior(ctx, &deviceInfo, rule, evidenceHash, honeypointID, reporter.MSPID+"/"+reporter.CommonName, txTime)
 	if err != nil {
-		return fmt.Errorf("攻击画像JSON解析失败: %v", err)
+		return nil, err
+	}
+	
+	return &deviceInfo, nil
+}
+
+// applyRiskBehavior 按风险规则更新设备风险评分并写入账本，同时追加风险事件记录并发送风险评分更新事件
+// 由风险行为上报和固件核验共用，honeypointID 为行为来源，reporter 为上报者身份
+func applyRiskBehavior(ctx contractapi.TransactionContextInterface, deviceInfo *models.DeviceInfo, rule *models.RiskRule, evidenceHash, honeypointID, reporter string, txTime time.Time) error {
+	// 按当前风险评分配置计算新的风险评分和攻击画像
+	profile, err := loadScoringProfile(ctx)
+	if err != nil {
+		return err
+	}
+	scoreBefore, attackIndexBefore := deviceInfo.RiskScore, deviceInfo.AttackIndexI
+	state := profile.Params.Apply(deviceInfo.RiskState(), rule.ScoringRule(), txTime)
+	newScore, newAttackIndex := state.RiskScore, state.AttackIndex
+	
+	// 更新风险评分和攻击画像，激活威胁状态
+	deviceInfo.SetRiskState(state)
+	deviceInfo.ScoringProfileVersion = profile.Version
+	deviceInfo.LastUpdatedAt = txTime
+	
+	// 根据风险等级策略更新设备状态
+	policy, err := loadRiskTierPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	tier := policy.TierForScore(newScore)
+	if tier == nil {
+		return fmt.Errorf("风险评分 %.2f 不属于任何风险等级", newScore)
+	}
+	models.ApplyTierStatus(deviceInfo, tier)
+	
+	// 风险评分进入禁止连接的等级时，在同一交易中关闭设备当前的连接会话
+	sessionID := deviceInfo.SessionID
+	sessionClosed := false
+	if !tier.AllowConnect && deviceInfo.ConnectionStatus == models.StatusOnline {
+		reason := fmt.Sprintf("风险评分 %.2f 达到 %s 等级, 禁止连接", newScore, tier.Label)
+		if err := closeDeviceSession(ctx, deviceInfo, reason, txTime); err != nil {
+			return err
+		}
+		sessionClosed = true
+	}
+	
+	// 将更新后的设备信息写入账本
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return err
+	}
+	
+	// 追加风险事件记录，保留完整的攻击时间线
+	riskEvent := &models.RiskEvent{
+		DID:               deviceInfo.DID,
+		TxID:              ctx.GetStub().GetTxID(),
+		Timestamp:         txTime,
+		BehaviorType:      rule.BehaviorType,
+		Category:          rule.Category,
+		BaseScore:         rule.Score,
+		Weight:            rule.Weight,
+		RuleVersion:       rule.Version,
+		ScoringProfileVersion: profile.Version,
+		TierPolicyVersion: policy.Version,
+		ScoreBefore:       scoreBefore,
+		ScoreAfter:        newScore,
+		AttackIndexBefore: attackIndexBefore,
+		AttackIndexAfter:  newAttackIndex,
+		Tier:              tier.Name,
+		HoneypointID:      honeypointID,
+		Reporter:          reporter,
+		EvidenceHash:      evidenceHash,
+	}
+	if err := putRiskEvent(ctx, riskEvent); err != nil {
+		return err
+	}
+	
+	// 创建风险评分更新事件
+	riskScoreEvent := models.DeviceEvent{
+		EventType:     models.EventTypeRiskUpdate,
+		DID:           deviceInfo.DID,
+		Name:          deviceInfo.Name,
+		Timestamp:     txTime.Unix(),
+		RiskScore:     newScore,
+		Category:      rule.Category,
+		BehaviorType:  rule.BehaviorType,
+		EvidenceHash:  evidenceHash,
+		SessionID:     sessionID,
+		SessionClosed: sessionClosed,
+	}
+
+	// 序列化事件数据
+	eventJSON, err := json.Marshal(riskScoreEvent)
+	if err != nil {
+		return fmt.Errorf("事件数据序列化失败: %v", err)
+	}
+
+	// 发送风险评分更新事件
+	err = ctx.GetStub().SetEvent("RiskScoreUpdated", eventJSON)
+	if err != nil {
+		return fmt.Errorf("发送风险评分更新事件失败: %v", err)
+	}
+	
+	return nil
+}
+
+// DecayAttackIndex 对设备攻击画像指数执行慢速衰减（后台状态维护）
+// Δt 从上次事件时间和上次衰减时间中较晚的一个算起，重复调用不会叠加衰减
+// expectedLastUpdatedAt 与 ReportRiskBehavior 相同，为空时不检查
+func (c *RiskContract) DecayAttackIndex(ctx contractapi.TransactionContextInterface, did string, expectedLastUpdatedAt string) (*models.DeviceInfo, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	// 验证DID格式
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
 	}
 	
 	// 从账本中读取设备信息
 	deviceInfoJSON, err := ctx.GetStub().GetState(did)
 	if err != nil {
-		return fmt.Errorf("读取设备信息时出错: %v", err)
+		return nil, fmt.Errorf("读取设备信息时出错: %v", err)
 	}
 	if deviceInfoJSON == nil {
-		return fmt.Errorf("设备DID %s 不存在", did)
+		return nil, fmt.Errorf("设备DID %s 不存在", did)
 	}
 	
 	// 反序列化设备信息
 	var deviceInfo models.DeviceInfo
 	err = json.Unmarshal(deviceInfoJSON, &deviceInfo)
 	if err != nil {
-		return fmt.Errorf("设备信息反序列化失败: %v", err)
+		return nil, fmt.Errorf("设备信息反序列化失败: %v", err)
 	}
 	
-	// 更新风险评分和攻击画像
-	deviceInfo.RiskScore = newScore
-	deviceInfo.AttackIndexI = attackIndex
-	deviceInfo.AttackProfile = attackProfile
+	// 检查设备自调用方读取后是否被修改
+	if err := checkLastUpdatedAt(&deviceInfo, expectedLastUpdatedAt); err != nil {
+		return nil, err
+	}
 	
-	// 根据风险评分更新设备状态
-	if newScore >= 700 {
-		// 高危：硬性阻断
-		deviceInfo.Status = models.StatusRisky
-	} else if deviceInfo.Status == models.StatusRisky && newScore < models.RiskScoreThreshold {
-		// 如果设备之前是风险状态，但现在风险评分降低，恢复为活跃状态
-		deviceInfo.Status = models.StatusActive
+	// 使用交易时间戳计算衰减
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
 	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
 	
-	// 将设备信息转换为JSON并存储
-	updatedDeviceInfoJSON, err := json.Marshal(deviceInfo)
+	// 按当前风险评分配置衰减，衰减不属于风险事件，不更新上次事件时间
+	profile, err := loadScoringProfile(ctx)
 	if err != nil {
-		return fmt.Errorf("设备信息序列化失败: %v", err)
+		return nil, err
 	}
+	deviceInfo.SetRiskState(profile.Params.Decay(deviceInfo.RiskState(), txTime))
+	deviceInfo.ScoringProfileVersion = profile.Version
+	deviceInfo.LastUpdatedAt = txTime
 	
 	// 将更新后的设备信息写入账本
-	err = ctx.GetStub().PutState(did, updatedDeviceInfoJSON)
-	if err != nil {
-		return fmt.Errorf("存储设备信息时出错: %v", err)
+	if err := putDeviceInfo(ctx, &deviceInfo); err != nil {
+		return nil, err
 	}
 	
+	return &deviceInfo, nil
+}
+
+// checkLastUpdatedAt 检查设备的最后更新时间是否与调用方读取到的一致，expected 为空时不检查
+// 不一致时返回以 models.PreconditionFailed 开头的错误，调用方应重新读取设备后重试
+func checkLastUpdatedAt(deviceInfo *models.DeviceInfo, expected string) error {
+	if expected == "" {
+		return nil
+	}
+
+	expectedTime, err := time.Parse(time.RFC3339Nano, expected)
+	if err != nil {
+		return fmt.Errorf("期望的最后更新时间格式无效: %v", err)
+	}
+	if !deviceInfo.LastUpdatedAt.Equal(expectedTime) {
+		return fmt.Errorf("%s: 设备 %s 的最后更新时间为 %s, 期望 %s", models.PreconditionFailed, deviceInfo.DID, deviceInfo.LastUpdatedAt.Format(time.RFC3339Nano), expected)
+	}
+
 	return nil
 }
 
 // GetRiskScore 获取设备风险评分
 func (c *RiskContract) GetRiskScore(ctx contractapi.TransactionContextInterface, did string) (float64, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return 0.0, err
+	}
+
 	// 验证DID格式
 	if !utils.ValidateDID(did) {
 		return 0.0, fmt.Errorf("无效的DID格式: %s", did)
@@ -129,6 +308,11 @@ func (c *RiskContract) GetRiskScore(ctx contractapi.TransactionContextInterface,
 
 // GetAttackProfile 获取设备攻击画像
 func (c *RiskContract) GetAttackProfile(ctx contractapi.TransactionContextInterface, did string) (string, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return "", err
+	}
+
 	// 验证DID格式
 	if !utils.ValidateDID(did) {
 		return "", fmt.Errorf("无效的DID格式: %s", did)
@@ -161,67 +345,60 @@ func (c *RiskContract) GetAttackProfile(ctx contractapi.TransactionContextInterf
 
 // CheckDeviceConnectionEligibility 检查设备是否有资格连接
 func (c *RiskContract) CheckDeviceConnectionEligibility(ctx contractapi.TransactionContextInterface, did string) (string, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return "", err
+	}
+
 	// 获取设备风险评分
 	riskScore, err := c.GetRiskScore(ctx, did)
 	if err != nil {
 		return "", err
 	}
 	
-	// 根据风险评分返回响应策略
-	if riskScore >= 700 {
-		// 高危（700-1000）：硬性阻断
-		return "高危风险，禁止设备连接", nil
-	} else if riskScore >= 200 && riskScore < 700 {
-		// 警戒（200-699）：主动欺骗与隔离引导
-		return "警戒风险，将设备流量重定向至隔离的蜜网环境", nil
-	} else if riskScore >= 1 && riskScore < 200 {
-		// 关注（1-199）：增强监控，主动引诱
-		return "关注风险，增强监控，主动引诱", nil
-	} else {
-		// 常规（0）：标准化信任与监控
-		return "常规风险，设备可以正常连接", nil
+	// 根据风险等级策略返回连接资格检查结果
+	tier, err := riskTierForScore(ctx, riskScore)
+	if err != nil {
+		return "", err
 	}
+	
+	return tier.ConnectionNotice, nil
 }
 
-// GetHighRiskDevices 获取高风险设备
+// GetHighRiskDevices 获取处于最高风险等级的设备
+//
+// Deprecated: 一次扫描全部设备，设备数量较多时会超出查询的时间和大小限制，请使用 GetHighRiskDevicesWithPagination
 func (c *RiskContract) GetHighRiskDevices(ctx contractapi.TransactionContextInterface) ([]*models.DeviceInfo, error) {
-	// 获取所有设备的迭代器
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	// 读取风险等级策略
+	policy, err := loadRiskTierPolicy(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取状态范围时出错: %v", err)
+		return nil, err
 	}
-	defer resultsIterator.Close()
-	
-	// 存储高风险设备的切片
-	var highRiskDevices []*models.DeviceInfo
+	highestTier := policy.HighestTier()
 	
-	// 遍历所有状态
-	for resultsIterator.HasNext() {
-		// 获取下一个键值对
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
-		}
-		
-		// 反序列化设备信息
-		var deviceInfo models.DeviceInfo
-		err = json.Unmarshal(queryResponse.Value, &deviceInfo)
-		if err != nil {
-			// 跳过非设备信息的状态
-			continue
-		}
-		
-		// 检查设备是否为高风险设备
-		if deviceInfo.RiskScore >= 700 {
-			highRiskDevices = append(highRiskDevices, &deviceInfo)
-		}
+	// 查找风险评分不低于最高风险等级下限的设备
+	page, err := scanDevices(ctx, &deviceFilter{MinScore: &highestTier.MinScore}, 0, "")
+	if err != nil {
+		return nil, err
 	}
 	
-	return highRiskDevices, nil
+	return page.Devices, nil
 }
 
 // GetDevicesByRiskScoreRange 获取特定风险评分范围内的设备
+//
+// Deprecated: 一次扫描全部设备，设备数量较多时会超出查询的时间和大小限制，请使用 GetDevicesByRiskScoreRangeWithPagination
 func (c *RiskContract) GetDevicesByRiskScoreRange(ctx contractapi.TransactionContextInterface, minScoreStr, maxScoreStr string) ([]*models.DeviceInfo, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
 	// 将字符串转换为浮点数
 	minScore, err := strconv.ParseFloat(minScoreStr, 64)
 	if err != nil {
@@ -238,101 +415,48 @@ func (c *RiskContract) GetDevicesByRiskScoreRange(ctx contractapi.TransactionCon
 		return nil, fmt.Errorf("风险评分范围无效")
 	}
 	
-	// 获取所有设备的迭代器
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	// 查找风险评分在指定范围内的设备
+	page, err := scanDevices(ctx, &deviceFilter{MinScore: &minScore, MaxScore: &maxScore}, 0, "")
 	if err != nil {
-		return nil, fmt.Errorf("获取状态范围时出错: %v", err)
-	}
-	defer resultsIterator.Close()
-	
-	// 存储符合条件的设备的切片
-	var devices []*models.DeviceInfo
-	
-	// 遍历所有状态
-	for resultsIterator.HasNext() {
-		// 获取下一个键值对
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
-		}
-		
-		// 反序列化设备信息
-		var deviceInfo models.DeviceInfo
-		err = json.Unmarshal(queryResponse.Value, &deviceInfo)
-		if err != nil {
-			// 跳过非设备信息的状态
-			continue
-		}
-		
-		// 检查设备是否在指定风险评分范围内
-		if deviceInfo.RiskScore >= minScore && deviceInfo.RiskScore <= maxScore {
-			devices = append(devices, &deviceInfo)
-		}
+		return nil, err
 	}
 	
-	return devices, nil
+	return page.Devices, nil
 }
 
 // GetDeviceRiskResponse 获取设备风险响应策略
-func (c *RiskContract) GetDeviceRiskResponse(ctx contractapi.TransactionContextInterface, did string) (string, error) {
+func (c *RiskContract) GetDeviceRiskResponse(ctx contractapi.TransactionContextInterface, did string) (*models.RiskResponse, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
 	// 获取设备风险评分
 	riskScore, err := c.GetRiskScore(ctx, did)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	
+	// 根据风险等级策略返回响应策略
+	policy, err := loadRiskTierPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tier := policy.TierForScore(riskScore)
+	if tier == nil {
+		return nil, fmt.Errorf("风险评分 %.2f 不属于任何风险等级", riskScore)
 	}
 	
-	// 根据风险评分返回响应策略
-	var response string
-	if riskScore >= 700 {
-		// 高危（700-1000）：硬性阻断
-		response = `{
-			"riskLevel": "高危",
-			"riskScore": ` + fmt.Sprintf("%.2f", riskScore) + `,
-			"strategy": "硬性阻断",
-			"measures": [
-				"立即强制中断该设备所有已建立的网络连接",
-				"临时锁定设备账户",
-				"对其交互过的蜜点进行快照存证",
-				"进入人工介入和深度溯源"
-			]
-		}`
-	} else if riskScore >= 200 && riskScore < 700 {
-		// 警戒（200-699）：主动欺骗与隔离引导
-		response = `{
-			"riskLevel": "警戒",
-			"riskScore": ` + fmt.Sprintf("%.2f", riskScore) + `,
-			"strategy": "主动欺骗与隔离引导",
-			"measures": [
-				"将该设备的所有内部域名解析请求指向对应的伪造服务",
-				"在网络层将其流量重定向至一个隔离的蜜网环境中",
-				"在其所处的蜜网环境中，动态植入伪造的凭证文件、数据库连接字符串、API密钥等蜜点"
-			]
-		}`
-	} else if riskScore >= 1 && riskScore < 200 {
-		// 关注（1-199）：增强监控，主动引诱
-		response = `{
-			"riskLevel": "关注",
-			"riskScore": ` + fmt.Sprintf("%.2f", riskScore) + `,
-			"strategy": "增强监控，主动引诱，无感知的情报收集",
-			"measures": [
-				"针对该设备的源IP，自动启用全数据包捕获",
-				"详细记录其在蜜点中的所有操作行为",
-				"实施轻微的服务质量策略，对其扫描或连接行为进行速率限制",
-				"在其当前互动的蜜点环境中，主动暴露更具吸引力的诱饵"
-			]
-		}`
-	} else {
-		// 常规（0）：标准化信任与监控
-		response = `{
-			"riskLevel": "常规",
-			"riskScore": ` + fmt.Sprintf("%.2f", riskScore) + `,
-			"strategy": "标准化信任与监控",
-			"measures": [
-				"维持默认的日志记录",
-				"仅在会话建立或关键操作时通过智能合约验证其DID身份的有效性"
-			]
-		}`
+	response := &models.RiskResponse{
+		DID:           did,
+		RiskScore:     math.Round(riskScore*100) / 100,
+		Tier:          tier.Name,
+		RiskLevel:     tier.Label,
+		AllowConnect:  tier.AllowConnect,
+		Strategy:      tier.Strategy,
+		Measures:      models.ResolveMeasures(tier.Measures),
+		PolicyVersion: policy.Version,
 	}
 	
 	return response, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,102 @@
+package contracts
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+func TestCheckLastUpdatedAt(t *testing.T) {
+	lastUpdatedAt := time.Date(2026, 3, 1, 8, 30, 0, 123456789, time.UTC)
+	device := &models.DeviceInfo{DID: utils.GenerateDID("camera", "C1", "acme", "SN001"), LastUpdatedAt: lastUpdatedAt}
+
+	tests := []struct {
+		name             string
+		expected         string
+		wantErr          bool
+		wantPrecondition bool
+	}{
+		{name: "不检查", expected: ""},
+		{name: "一致", expected: lastUpdatedAt.Format(time.RFC3339Nano)},
+		{name: "其他时区表示的同一时间", expected: lastUpdatedAt.In(time.FixedZone("CST", 8*3600)).Format(time.RFC3339Nano)},
+		{name: "不一致", expected: lastUpdatedAt.Add(-time.Second).Format(time.RFC3339Nano), wantErr: true, wantPrecondition: true},
+		{name: "精度丢失", expected: lastUpdatedAt.Format(time.RFC3339), wantErr: true, wantPrecondition: true},
+		{name: "格式无效", expected: "2026-03-01 08:30:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLastUpdatedAt(device, tt.expected)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkLastUpdatedAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && strings.HasPrefix(err.Error(), models.PreconditionFailed) != tt.wantPrecondition {
+				t.Errorf("checkLastUpdatedAt() error = %v, 前置条件错误 %v", err, tt.wantPrecondition)
+			}
+		})
+	}
+}
+
+func TestDecayAttackIndexPrecondition(t *testing.T) {
+	did := utils.GenerateDID("camera", "C1", "acme", "SN001")
+	oracle := newTestIdentity("Org1MSP", "User1@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleOracle})
+	lastUpdatedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	txTime := lastUpdatedAt.Add(10 * 24 * time.Hour)
+
+	tests := []struct {
+		name             string
+		expected         string
+		wantPrecondition bool
+	}{
+		{name: "不检查前置条件", expected: ""},
+		{name: "前置条件一致", expected: lastUpdatedAt.Format(time.RFC3339Nano)},
+		{name: "设备已被其他交易修改", expected: lastUpdatedAt.Add(-time.Minute).Format(time.RFC3339Nano), wantPrecondition: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, stub := newTestContext(t, oracle)
+			stub.startTx("tx-setup", lastUpdatedAt)
+			device := &models.DeviceInfo{DID: did, Status: models.StatusActive, AttackIndexI: 1, LastEventTime: lastUpdatedAt, LastUpdatedAt: lastUpdatedAt}
+			if err := putDeviceInfo(ctx, device); err != nil {
+				t.Fatalf("putDeviceInfo() error = %v", err)
+			}
+
+			stub.startTx("tx-decay", txTime)
+			got, err := (&RiskContract{}).DecayAttackIndex(ctx, did, tt.expected)
+			if tt.wantPrecondition {
+				if err == nil || !strings.HasPrefix(err.Error(), models.PreconditionFailed) {
+					t.Fatalf("DecayAttackIndex() error = %v, want %s", err, models.PreconditionFailed)
+				}
+			} else if err != nil {
+				t.Fatalf("DecayAttackIndex() error = %v", err)
+			}
+
+			stored, err := stub.GetState(did)
+			if err != nil {
+				t.Fatalf("GetState() error = %v", err)
+			}
+			var saved models.DeviceInfo
+			if err := json.Unmarshal(stored, &saved); err != nil {
+				t.Fatalf("设备信息反序列化失败: %v", err)
+			}
+
+			if tt.wantPrecondition {
+				if !saved.LastUpdatedAt.Equal(lastUpdatedAt) || saved.AttackIndexI != 1 {
+					t.Errorf("前置条件不满足时设备被修改: %+v", saved)
+				}
+				return
+			}
+			if !got.LastUpdatedAt.Equal(txTime) || !saved.LastUpdatedAt.Equal(txTime) {
+				t.Errorf("LastUpdatedAt = %v, want %v", saved.LastUpdatedAt, txTime)
+			}
+			if saved.AttackIndexI >= 1 {
+				t.Errorf("AttackIndexI = %v, 应已衰减", saved.AttackIndexI)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// GetRiskEventHistory 按时间范围分页查询设备的风险事件记录
+// from 和 to 为 RFC3339 格式的时间，为空表示不限；pageSize 为空时使用默认值；bookmark 为上一页返回的书签
+func (c *RiskContract) GetRiskEventHistory(ctx contractapi.TransactionContextInterface, did string, from string, to string, pageSizeStr string, bookmark string) (*models.RiskEventPage, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	// 验证DID格式
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
+	}
+
+	fromKey, err := parseEventTime(from, "")
+	if err != nil {
+		return nil, fmt.Errorf("起始时间格式无效: %v", err)
+	}
+	toKey, err := parseEventTime(to, "~")
+	if err != nil {
+		return nil, fmt.Errorf("结束时间格式无效: %v", err)
+	}
+
+	pageSize, err := parsePageSize(pageSizeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	startKey, err := riskEventStartKey(ctx, did, fromKey, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	// 从起始时间或书签所在的复合键开始读取，每页只读取 pageSize+2 条记录：
+	// 书签对应的记录本身会被跳过，多读一条用于判断是否还有下一页
+	resultsIterator, _, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(models.RiskEventObjectType, []string{did}, int32(pageSize+2), startKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询风险事件时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	// 记录按交易时间升序排列，跳过书签对应的记录，超出结束时间后停止
+	page := &models.RiskEventPage{Events: []*models.RiskEvent{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("解析风险事件复合键失败: %v", err)
+		}
+		if len(attributes) != 3 {
+			continue
+		}
+		position := attributes[1] + "~" + attributes[2]
+		if bookmark != "" && position <= bookmark {
+			continue
+		}
+		if attributes[1] < fromKey {
+			continue
+		}
+		if attributes[1] > toKey {
+			break
+		}
+
+		if len(page.Events) == pageSize {
+			page.Bookmark = lastEventPosition(page.Events)
+			break
+		}
+
+		var event models.RiskEvent
+		err = json.Unmarshal(queryResponse.Value, &event)
+		if err != nil {
+			return nil, fmt.Errorf("风险事件反序列化失败: %v", err)
+		}
+		page.Events = append(page.Events, &event)
+	}
+
+	return page, nil
+}
+
+// riskEventStartKey 计算分页查询的起始复合键，取起始时间和书签中较晚的一个，两者都为空时从设备的第一条记录开始
+// 返回值作为 Fabric 分页查询的书签传入，节点从该键开始读取
+func riskEventStartKey(ctx contractapi.TransactionContextInterface, did, fromKey, bookmark string) (string, error) {
+	attributes := []string{did}
+	if fromKey != "" {
+		attributes = []string{did, fromKey}
+	}
+	if bookmark != "" {
+		parts := strings.SplitN(bookmark, "~", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", fmt.Errorf("无效的书签: %s", bookmark)
+		}
+		if parts[0] >= fromKey {
+			attributes = []string{did, parts[0], parts[1]}
+		}
+	}
+	if len(attributes) == 1 {
+		return "", nil
+	}
+
+	startKey, err := ctx.GetStub().CreateCompositeKey(models.RiskEventObjectType, attributes)
+	if err != nil {
+		return "", fmt.Errorf("创建风险事件复合键失败: %v", err)
+	}
+	return startKey, nil
+}
+
+// putRiskEvent 写入风险事件记录
+func putRiskEvent(ctx contractapi.TransactionContextInterface, event *models.RiskEvent) error {
+	event.DocType = models.RiskEventObjectType
+	eventKey, err := ctx.GetStub().CreateCompositeKey(models.RiskEventObjectType, []string{event.DID, eventTimeKey(event.Timestamp), event.TxID})
+	if err != nil {
+		return fmt.Errorf("创建风险事件复合键失败: %v", err)
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("风险事件序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(eventKey, eventJSON)
+	if err != nil {
+		return fmt.Errorf("存储风险事件时出错: %v", err)
+	}
+
+	return nil
+}
+
+// eventTimeKey 将交易时间转换为定长的纳秒时间戳字符串，按字典序排序即按时间排序
+func eventTimeKey(t time.Time) string {
+	return fmt.Sprintf("%020d", t.UnixNano())
+}
+
+// lastEventPosition 生成分页书签，即本页最后一条记录在复合键中的位置
+func lastEventPosition(events []*models.RiskEvent) string {
+	last := events[len(events)-1]
+	return eventTimeKey(last.Timestamp) + "~" + last.TxID
+}
+
+// parseEventTime 解析RFC3339格式的查询时间，为空时返回 unbounded
+func parseEventTime(value string, unbounded string) (string, error) {
+	if value == "" {
+		return unbounded, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", err
+	}
+	return eventTimeKey(t), nil
+}
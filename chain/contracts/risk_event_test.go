@@ -0,0 +1,141 @@
+package contracts
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+var eventTime0 = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// putTestRiskEvents 为设备写入 n 条风险事件，第 i 条的交易时间为 eventTime0 之后 i 分钟
+func putTestRiskEvents(t *testing.T, stub *pagingStub, ctx contractapi.TransactionContextInterface, did string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		txID := fmt.Sprintf("%s-tx-%d", did[len(did)-4:], i)
+		stub.MockTransactionStart(txID)
+		event := &models.RiskEvent{DID: did, TxID: txID, Timestamp: eventTime0.Add(time.Duration(i) * time.Minute)}
+		if err := putRiskEvent(ctx, event); err != nil {
+			t.Fatalf("putRiskEvent() error = %v", err)
+		}
+		stub.MockTransactionEnd(txID)
+	}
+}
+
+func eventTxIDs(events []*models.RiskEvent) []string {
+	txIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		txIDs = append(txIDs, event.TxID)
+	}
+	return txIDs
+}
+
+func TestGetRiskEventHistoryPaging(t *testing.T) {
+	did := utils.GenerateDID("camera", "C1", "acme", "SN001")
+	other := utils.GenerateDID("camera", "C1", "acme", "SN002")
+	minute := func(i int) string { return eventTime0.Add(time.Duration(i) * time.Minute).Format(time.RFC3339) }
+
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		pageSize  int
+		wantPages [][]int
+	}{
+		{name: "不限时间分页", pageSize: 3, wantPages: [][]int{{0, 1, 2}, {3, 4, 5}, {6}}},
+		{name: "记录数恰好为整页", from: minute(1), pageSize: 3, wantPages: [][]int{{1, 2, 3}, {4, 5, 6}}},
+		{name: "按起止时间过滤", from: minute(2), to: minute(4), pageSize: 2, wantPages: [][]int{{2, 3}, {4}}},
+		{name: "结束时间早于所有记录", to: eventTime0.Add(-time.Minute).Format(time.RFC3339), pageSize: 3, wantPages: [][]int{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, stub := newAdminContext(t)
+			putTestRiskEvents(t, stub, ctx, did, 7)
+			putTestRiskEvents(t, stub, ctx, other, 2)
+
+			contract := &RiskContract{}
+			bookmark := ""
+			for i, want := range tt.wantPages {
+				page, err := contract.GetRiskEventHistory(ctx, did, tt.from, tt.to, fmt.Sprint(tt.pageSize), bookmark)
+				if err != nil {
+					t.Fatalf("第 %d 页 GetRiskEventHistory() error = %v", i+1, err)
+				}
+				wantTxIDs := make([]string, 0, len(want))
+				for _, n := range want {
+					wantTxIDs = append(wantTxIDs, fmt.Sprintf("%s-tx-%d", did[len(did)-4:], n))
+				}
+				if got := eventTxIDs(page.Events); fmt.Sprint(got) != fmt.Sprint(wantTxIDs) {
+					t.Fatalf("第 %d 页 = %v, want %v", i+1, got, wantTxIDs)
+				}
+
+				last := i == len(tt.wantPages)-1
+				if last && page.Bookmark != "" {
+					t.Errorf("最后一页书签 = %q, want 空", page.Bookmark)
+				}
+				if !last && page.Bookmark == "" {
+					t.Fatalf("第 %d 页缺少书签", i+1)
+				}
+				bookmark = page.Bookmark
+			}
+
+			// 每页最多读取 pageSize+2 条记录，与之前的记录数无关
+			if len(stub.calls) != len(tt.wantPages) {
+				t.Fatalf("分页查询次数 = %d, want %d", len(stub.calls), len(tt.wantPages))
+			}
+			for i, call := range stub.calls {
+				if call.pageSize != int32(tt.pageSize+2) || call.read > tt.pageSize+2 {
+					t.Errorf("第 %d 次查询 pageSize = %d, 读取 %d 条", i+1, call.pageSize, call.read)
+				}
+			}
+		})
+	}
+}
+
+func TestRiskEventStartKey(t *testing.T) {
+	ctx, _ := newAdminContext(t)
+	did := utils.GenerateDID("camera", "C1", "acme", "SN001")
+	fromKey := eventTimeKey(eventTime0.Add(2 * time.Minute))
+	earlier := eventTimeKey(eventTime0.Add(time.Minute)) + "~tx-1"
+	later := eventTimeKey(eventTime0.Add(3*time.Minute)) + "~tx-3"
+
+	key := func(attributes ...string) string {
+		k, err := ctx.GetStub().CreateCompositeKey(models.RiskEventObjectType, append([]string{did}, attributes...))
+		if err != nil {
+			t.Fatalf("CreateCompositeKey() error = %v", err)
+		}
+		return k
+	}
+
+	tests := []struct {
+		name     string
+		fromKey  string
+		bookmark string
+		want     string
+		wantErr  bool
+	}{
+		{name: "无起始时间和书签", want: ""},
+		{name: "仅有起始时间", fromKey: fromKey, want: key(fromKey)},
+		{name: "仅有书签", bookmark: later, want: key(eventTimeKey(eventTime0.Add(3*time.Minute)), "tx-3")},
+		{name: "书签晚于起始时间", fromKey: fromKey, bookmark: later, want: key(eventTimeKey(eventTime0.Add(3*time.Minute)), "tx-3")},
+		{name: "书签早于起始时间", fromKey: fromKey, bookmark: earlier, want: key(fromKey)},
+		{name: "书签缺少交易ID", bookmark: eventTimeKey(eventTime0), wantErr: true},
+		{name: "书签时间为空", bookmark: "~tx-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := riskEventStartKey(ctx, did, tt.fromKey, tt.bookmark)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("riskEventStartKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("riskEventStartKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// GetRiskTierPolicy 获取当前生效的风险等级策略
+func (c *RiskContract) GetRiskTierPolicy(ctx contractapi.TransactionContextInterface) (*models.RiskTierPolicy, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	return loadRiskTierPolicy(ctx)
+}
+
+// GetRiskTierPolicyVersion 获取指定版本的风险等级策略，用于解释历史风险事件的等级划分
+func (c *RiskContract) GetRiskTierPolicyVersion(ctx contractapi.TransactionContextInterface, versionStr string) (*models.RiskTierPolicy, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version < 0 {
+		return nil, fmt.Errorf("无效的策略版本号: %s", versionStr)
+	}
+	if version == 0 {
+		policy := models.DefaultRiskTierPolicy
+		return &policy, nil
+	}
+
+	versionKey, err := ctx.GetStub().CreateCompositeKey(models.RiskTierPolicyVersionObjectType, []string{fmt.Sprintf("%08d", version)})
+	if err != nil {
+		return nil, fmt.Errorf("创建风险等级策略版本复合键失败: %v", err)
+	}
+	policyJSON, err := ctx.GetStub().GetState(versionKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取风险等级策略时出错: %v", err)
+	}
+	if policyJSON == nil {
+		return nil, fmt.Errorf("风险等级策略版本 %d 不存在", version)
+	}
+
+	var policy models.RiskTierPolicy
+	err = json.Unmarshal(policyJSON, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("风险等级策略反序列化失败: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// SetRiskTierPolicy 修改风险等级策略，修改后所有合约函数和客户端使用新策略
+func (c *RiskContract) SetRiskTierPolicy(ctx contractapi.TransactionContextInterface, policyJSON string) (*models.RiskTierPolicy, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	// 解析风险等级策略
+	var policy models.RiskTierPolicy
+	err := json.Unmarshal([]byte(policyJSON), &policy)
+	if err != nil {
+		return nil, fmt.Errorf("风险等级策略JSON解析失败: %v", err)
+	}
+
+	// 等级须覆盖到当前风险评分配置的最大风险分数
+	profile, err := loadScoringProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := putRiskTierPolicy(ctx, &policy, profile.Params.MaxScore); err != nil {
+		return nil, err
+	}
+
+	// 发送策略变更事件，通知各客户端刷新策略
+	updatedPolicyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("风险等级策略序列化失败: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("RiskTierPolicyUpdated", updatedPolicyJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送风险等级策略变更事件失败: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// putRiskTierPolicy 验证风险等级策略并写入新版本，同时按版本号保留一份，供解释历史风险事件使用
+func putRiskTierPolicy(ctx contractapi.TransactionContextInterface, policy *models.RiskTierPolicy, maxScore float64) error {
+	// 验证风险等级策略
+	err := policy.Validate(maxScore)
+	if err != nil {
+		return fmt.Errorf("风险等级策略无效: %v", err)
+	}
+
+	// 版本号在当前策略基础上递增
+	current, err := loadRiskTierPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	policy.Version = current.Version + 1
+
+	// 记录修改者和修改时间，供审计使用
+	updatedBy, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败: %v", err)
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	policy.UpdatedBy = updatedBy
+	policy.UpdatedAt = time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	policy.TxID = ctx.GetStub().GetTxID()
+	policy.DocType = models.RiskTierPolicyObjectType
+
+	policyKey, err := riskTierPolicyKey(ctx)
+	if err != nil {
+		return err
+	}
+	versionKey, err := ctx.GetStub().CreateCompositeKey(models.RiskTierPolicyVersionObjectType, []string{fmt.Sprintf("%08d", policy.Version)})
+	if err != nil {
+		return fmt.Errorf("创建风险等级策略版本复合键失败: %v", err)
+	}
+	updatedPolicyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("风险等级策略序列化失败: %v", err)
+	}
+	for _, key := range []string{policyKey, versionKey} {
+		err = ctx.GetStub().PutState(key, updatedPolicyJSON)
+		if err != nil {
+			return fmt.Errorf("存储风险等级策略时出错: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// loadRiskTierPolicy 读取链上风险等级策略，未设置时使用默认策略
+func loadRiskTierPolicy(ctx contractapi.TransactionContextInterface) (*models.RiskTierPolicy, error) {
+	policyKey, err := riskTierPolicyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policyJSON, err := ctx.GetStub().GetState(policyKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取风险等级策略时出错: %v", err)
+	}
+	if policyJSON == nil {
+		policy := models.DefaultRiskTierPolicy
+		return &policy, nil
+	}
+
+	var policy models.RiskTierPolicy
+	err = json.Unmarshal(policyJSON, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("风险等级策略反序列化失败: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// riskTierForScore 根据链上策略查找风险评分所属的风险等级
+func riskTierForScore(ctx contractapi.TransactionContextInterface, score float64) (*models.RiskTier, error) {
+	policy, err := loadRiskTierPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tier := policy.TierForScore(score)
+	if tier == nil {
+		return nil, fmt.Errorf("风险评分 %.2f 不属于任何风险等级", score)
+	}
+
+	return tier, nil
+}
+
+// riskTierPolicyKey 生成风险等级策略的复合键，复合键不会出现在设备的范围查询中
+func riskTierPolicyKey(ctx contractapi.TransactionContextInterface) (string, error) {
+	policyKey, err := ctx.GetStub().CreateCompositeKey(models.RiskTierPolicyObjectType, []string{"current"})
+	if err != nil {
+		return "", fmt.Errorf("创建风险等级策略复合键失败: %v", err)
+	}
+	return policyKey, nil
+}
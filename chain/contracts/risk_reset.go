@@ -0,0 +1,271 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+// ApproveRiskReset 批准重置设备风险评分，仅管理员可以调用
+// 批准记录在有效期内由另一名管理员通过 ResetDeviceRiskScore 执行，重复批准覆盖之前的记录
+func (c *IdentityContract) ApproveRiskReset(ctx contractapi.TransactionContextInterface, did string, reason string) (*models.RiskResetApproval, error) {
+	// 检查调用者权限，批准人的角色与其他管理员操作一样解析
+	approver, err := requireRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason == "" {
+		return nil, fmt.Errorf("重置原因不能为空")
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备 %s 已退役, 不能重置风险评分", did)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	approval := &models.RiskResetApproval{
+		DocType:    models.RiskResetApprovalObjectType,
+		DID:        did,
+		Reason:     reason,
+		ApprovedBy: approver.MSPID + "/" + approver.CommonName,
+		TxID:       ctx.GetStub().GetTxID(),
+		ApprovedAt: time.Unix(timestamp.Seconds, int64(timestamp.Nanos)),
+	}
+
+	approvalKey, err := riskResetApprovalKey(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	approvalJSON, err := json.Marshal(approval)
+	if err != nil {
+		return nil, fmt.Errorf("重置批准记录序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(approvalKey, approvalJSON)
+	if err != nil {
+		return nil, fmt.Errorf("存储重置批准记录时出错: %v", err)
+	}
+
+	// 发送重置批准事件，事件数据即批准记录
+	err = ctx.GetStub().SetEvent("RiskResetApproved", approvalJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送重置批准事件失败: %v", err)
+	}
+
+	return approval, nil
+}
+
+// ResetDeviceRiskScore 重置设备风险评分，仅管理员可以调用
+// 重置前须由另一名管理员通过 ApproveRiskReset 批准，reason 须与批准的原因一致，批准记录在重置后删除；
+// mode 为 full（默认）时清零风险评分和攻击画像，为 soft 时保留攻击画像指数和攻击画像作为长期信誉
+// 重置不修改上次事件时间，设备状态按重置后风险评分所在的风险等级恢复
+func (c *IdentityContract) ResetDeviceRiskScore(ctx contractapi.TransactionContextInterface, did string, reason string, mode string) (*models.RiskReset, error) {
+	// 检查调用者权限
+	caller, err := requireRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason == "" {
+		return nil, fmt.Errorf("重置原因不能为空")
+	}
+	if mode == "" {
+		mode = models.RiskResetModeFull
+	}
+	if mode != models.RiskResetModeFull && mode != models.RiskResetModeSoft {
+		return nil, fmt.Errorf("无效的重置方式: %s, 应为 %s 或 %s", mode, models.RiskResetModeFull, models.RiskResetModeSoft)
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status == models.StatusDecommissioned {
+		return nil, fmt.Errorf("设备 %s 已退役, 不能重置风险评分", did)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	approval, err := consumeResetApproval(ctx, caller, did, reason, txTime)
+	if err != nil {
+		return nil, err
+	}
+
+	attackProfile := deviceInfo.AttackProfile
+	if attackProfile == nil {
+		attackProfile = []string{}
+	}
+	reset := &models.RiskReset{
+		DID:               did,
+		Name:              deviceInfo.Name,
+		TxID:              ctx.GetStub().GetTxID(),
+		Timestamp:         txTime,
+		Mode:              mode,
+		Reason:            reason,
+		ApprovedBy:        approval.ApprovedBy,
+		ApprovalTxID:      approval.TxID,
+		ResetBy:           caller.MSPID + "/" + caller.CommonName,
+		ScoreBefore:       deviceInfo.RiskScore,
+		AttackIndexBefore: deviceInfo.AttackIndexI,
+		AttackProfile:     attackProfile,
+	}
+
+	// 重置风险评分，完全重置时同时清空攻击画像
+	deviceInfo.RiskScore = 0.0
+	if mode == models.RiskResetModeFull {
+		deviceInfo.AttackIndexI = 0.0
+		deviceInfo.AttackProfile = []string{}
+	}
+	reset.AttackIndexAfter = deviceInfo.AttackIndexI
+
+	// 根据风险等级策略恢复设备状态
+	policy, err := loadRiskTierPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tier := policy.TierForScore(deviceInfo.RiskScore)
+	if tier == nil {
+		return nil, fmt.Errorf("风险评分 %.2f 不属于任何风险等级", deviceInfo.RiskScore)
+	}
+	models.ApplyTierStatus(deviceInfo, tier)
+	reset.Status = deviceInfo.Status
+	reset.TierPolicyVersion = policy.Version
+
+	deviceInfo.LastUpdatedAt = txTime
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+	if err := putRiskReset(ctx, reset); err != nil {
+		return nil, err
+	}
+
+	// 发送风险评分重置事件，事件数据即重置记录
+	eventJSON, err := json.Marshal(reset)
+	if err != nil {
+		return nil, fmt.Errorf("事件数据序列化失败: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("RiskScoreReset", eventJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送风险评分重置事件失败: %v", err)
+	}
+
+	return reset, nil
+}
+
+// consumeResetApproval 读取并删除设备的重置批准记录
+// 批准须在有效期内、原因与重置原因一致，且批准人不能是执行重置的身份本身
+func consumeResetApproval(ctx contractapi.TransactionContextInterface, caller *models.CallerIdentity, did string, reason string, txTime time.Time) (*models.RiskResetApproval, error) {
+	approvalKey, err := riskResetApprovalKey(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	approvalJSON, err := ctx.GetStub().GetState(approvalKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取重置批准记录时出错: %v", err)
+	}
+	if approvalJSON == nil {
+		return nil, fmt.Errorf("设备 %s 的风险评分重置未经批准, 须先由另一名管理员调用 ApproveRiskReset", did)
+	}
+
+	var approval models.RiskResetApproval
+	err = json.Unmarshal(approvalJSON, &approval)
+	if err != nil {
+		return nil, fmt.Errorf("重置批准记录反序列化失败: %v", err)
+	}
+	if approval.ApprovedBy == caller.MSPID+"/"+caller.CommonName {
+		return nil, fmt.Errorf("批准人不能是执行重置的身份本身")
+	}
+	if txTime.Sub(approval.ApprovedAt) > models.RiskResetApprovalTTL {
+		return nil, fmt.Errorf("设备 %s 的重置批准已于 %s 过期, 须重新批准", did, approval.ApprovedAt.Add(models.RiskResetApprovalTTL).Format(time.RFC3339))
+	}
+	if approval.Reason != reason {
+		return nil, fmt.Errorf("重置原因与批准的原因不一致: 批准的原因为 %s", approval.Reason)
+	}
+
+	err = ctx.GetStub().DelState(approvalKey)
+	if err != nil {
+		return nil, fmt.Errorf("删除重置批准记录时出错: %v", err)
+	}
+
+	return &approval, nil
+}
+
+// riskResetApprovalKey 生成重置批准记录的复合键
+func riskResetApprovalKey(ctx contractapi.TransactionContextInterface, did string) (string, error) {
+	approvalKey, err := ctx.GetStub().CreateCompositeKey(models.RiskResetApprovalObjectType, []string{did})
+	if err != nil {
+		return "", fmt.Errorf("创建重置批准记录复合键失败: %v", err)
+	}
+	return approvalKey, nil
+}
+
+// GetRiskResetHistory 查询设备的风险评分重置记录，按时间升序
+func (c *IdentityContract) GetRiskResetHistory(ctx contractapi.TransactionContextInterface, did string) ([]*models.RiskReset, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	// 验证DID格式
+	if !utils.ValidateDID(did) {
+		return nil, fmt.Errorf("无效的DID格式: %s", did)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.RiskResetObjectType, []string{did})
+	if err != nil {
+		return nil, fmt.Errorf("查询风险评分重置记录时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	resets := []*models.RiskReset{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var reset models.RiskReset
+		err = json.Unmarshal(queryResponse.Value, &reset)
+		if err != nil {
+			return nil, fmt.Errorf("风险评分重置记录反序列化失败: %v", err)
+		}
+		resets = append(resets, &reset)
+	}
+
+	return resets, nil
+}
+
+// putRiskReset 写入风险评分重置记录
+func putRiskReset(ctx contractapi.TransactionContextInterface, reset *models.RiskReset) error {
+	reset.DocType = models.RiskResetObjectType
+	resetKey, err := ctx.GetStub().CreateCompositeKey(models.RiskResetObjectType, []string{reset.DID, eventTimeKey(reset.Timestamp), reset.TxID})
+	if err != nil {
+		return fmt.Errorf("创建风险评分重置记录复合键失败: %v", err)
+	}
+
+	resetJSON, err := json.Marshal(reset)
+	if err != nil {
+		return fmt.Errorf("风险评分重置记录序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(resetKey, resetJSON)
+	if err != nil {
+		return fmt.Errorf("存储风险评分重置记录时出错: %v", err)
+	}
+
+	return nil
+}
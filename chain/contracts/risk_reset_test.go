@@ -0,0 +1,88 @@
+package contracts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+func TestResetDeviceRiskScoreApproval(t *testing.T) {
+	did := utils.GenerateDID("camera", "C1", "acme", "SN001")
+	resetter := newTestIdentity("Org1MSP", "Admin@org1.chain.com", []string{models.AdminOU}, nil)
+	approver := newTestIdentity("Org1MSP", "approver@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleAdmin})
+	oracle := newTestIdentity("Org1MSP", "oracle@org1.chain.com", nil, map[string]string{models.RoleAttribute: models.RoleOracle})
+	approvedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		approver    *testIdentity
+		approveErr  string
+		resetAfter  time.Duration
+		resetReason string
+		resetErr    string
+	}{
+		{name: "另一名管理员批准后执行重置", approver: approver, resetAfter: time.Hour, resetReason: "蜜点误报"},
+		{name: "OU=admin 的管理员可以批准", approver: newTestIdentity("Org1MSP", "second@org1.chain.com", []string{models.AdminOU}, nil), resetAfter: time.Hour, resetReason: "蜜点误报"},
+		{name: "未经批准", resetAfter: time.Hour, resetReason: "蜜点误报", resetErr: "未经批准"},
+		{name: "批准人与执行者相同", approver: resetter, resetAfter: time.Hour, resetReason: "蜜点误报", resetErr: "批准人不能是执行重置的身份本身"},
+		{name: "原因与批准的原因不一致", approver: approver, resetAfter: time.Hour, resetReason: "其他原因", resetErr: "原因不一致"},
+		{name: "批准已过期", approver: approver, resetAfter: models.RiskResetApprovalTTL + time.Second, resetReason: "蜜点误报", resetErr: "过期"},
+		{name: "非管理员不能批准", approver: oracle, approveErr: "权限不足", resetAfter: time.Hour, resetReason: "蜜点误报", resetErr: "未经批准"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, stub := newTestContext(t, resetter)
+			stub.startTx("tx-register", approvedAt.Add(-time.Hour))
+			device := &models.DeviceInfo{DID: did, Name: "摄像头", Status: models.StatusRisky, RiskScore: 800, AttackIndexI: 0.7, AttackProfile: []string{"Recon.PortScan"}}
+			if err := putDeviceInfo(ctx, device); err != nil {
+				t.Fatalf("putDeviceInfo() error = %v", err)
+			}
+
+			contract := &IdentityContract{}
+			if tt.approver != nil {
+				ctx.SetClientIdentity(tt.approver)
+				stub.startTx("tx-approve", approvedAt)
+				_, err := contract.ApproveRiskReset(ctx, did, "蜜点误报")
+				if !errorContains(err, tt.approveErr) {
+					t.Fatalf("ApproveRiskReset() error = %v, want %q", err, tt.approveErr)
+				}
+			}
+
+			ctx.SetClientIdentity(resetter)
+			stub.startTx("tx-reset", approvedAt.Add(tt.resetAfter))
+			reset, err := contract.ResetDeviceRiskScore(ctx, did, tt.resetReason, models.RiskResetModeFull)
+			if !errorContains(err, tt.resetErr) {
+				t.Fatalf("ResetDeviceRiskScore() error = %v, want %q", err, tt.resetErr)
+			}
+			if err != nil {
+				return
+			}
+
+			wantApprovedBy := "Org1MSP/" + tt.approver.cert.Subject.CommonName
+			if reset.ApprovedBy != wantApprovedBy || reset.ApprovalTxID != "tx-approve" {
+				t.Errorf("ApprovedBy = %s (交易 %s), want %s (交易 tx-approve)", reset.ApprovedBy, reset.ApprovalTxID, wantApprovedBy)
+			}
+			if reset.ResetBy != "Org1MSP/Admin@org1.chain.com" {
+				t.Errorf("ResetBy = %s", reset.ResetBy)
+			}
+
+			// 批准只能使用一次
+			stub.startTx("tx-reset-again", approvedAt.Add(tt.resetAfter+time.Minute))
+			if _, err := contract.ResetDeviceRiskScore(ctx, did, tt.resetReason, models.RiskResetModeFull); !errorContains(err, "未经批准") {
+				t.Errorf("重复重置 error = %v, want 未经批准", err)
+			}
+		})
+	}
+}
+
+// errorContains 检查 err 的内容，want 为空时要求没有错误
+func errorContains(err error, want string) bool {
+	if want == "" {
+		return err == nil
+	}
+	return err != nil && strings.Contains(err.Error(), want)
+}
@@ -0,0 +1,306 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// PutRiskRule 新增或修改风险规则，每次写入生成新版本
+func (c *RiskContract) PutRiskRule(ctx contractapi.TransactionContextInterface, ruleJSON string) (*models.RiskRule, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	// 解析风险规则
+	var rule models.RiskRule
+	err := json.Unmarshal([]byte(ruleJSON), &rule)
+	if err != nil {
+		return nil, fmt.Errorf("风险规则JSON解析失败: %v", err)
+	}
+
+	// 验证风险规则
+	if rule.BehaviorType == "" || rule.Category == "" {
+		return nil, fmt.Errorf("风险规则的行为类型和行为类别不能为空")
+	}
+	profile, err := loadScoringProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Score < 0 || rule.Score > profile.Params.MaxScore {
+		return nil, fmt.Errorf("基础风险分数必须在 0 到 %.2f 之间", profile.Params.MaxScore)
+	}
+	if rule.Weight < 0 {
+		return nil, fmt.Errorf("行为权重必须大于等于0")
+	}
+
+	rule.Status = models.RuleStatusActive
+	return putRiskRuleWithEvent(ctx, &rule)
+}
+
+// GetRiskRule 获取风险规则的最新版本
+func (c *RiskContract) GetRiskRule(ctx contractapi.TransactionContextInterface, behaviorType string) (*models.RiskRule, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	rule, err := getLatestRiskRule(ctx, behaviorType)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("风险规则不存在: %s", behaviorType)
+	}
+
+	return rule, nil
+}
+
+// GetRiskRuleVersions 获取风险规则的全部历史版本，用于审计
+func (c *RiskContract) GetRiskRuleVersions(ctx contractapi.TransactionContextInterface, behaviorType string) ([]*models.RiskRule, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.RiskRuleObjectType, []string{behaviorType})
+	if err != nil {
+		return nil, fmt.Errorf("查询风险规则时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var versions []*models.RiskRule
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var rule models.RiskRule
+		err = json.Unmarshal(queryResponse.Value, &rule)
+		if err != nil {
+			return nil, fmt.Errorf("风险规则反序列化失败: %v", err)
+		}
+		versions = append(versions, &rule)
+	}
+
+	return versions, nil
+}
+
+// ListRiskRules 列出所有生效风险规则的最新版本
+func (c *RiskContract) ListRiskRules(ctx contractapi.TransactionContextInterface) ([]*models.RiskRule, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.RiskRuleObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("查询风险规则时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	// 复合键按行为类型和版本号排序，同一行为类型的最后一条即为最新版本
+	var latest []*models.RiskRule
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var rule models.RiskRule
+		err = json.Unmarshal(queryResponse.Value, &rule)
+		if err != nil {
+			return nil, fmt.Errorf("风险规则反序列化失败: %v", err)
+		}
+
+		if n := len(latest); n > 0 && latest[n-1].BehaviorType == rule.BehaviorType {
+			latest[n-1] = &rule
+		} else {
+			latest = append(latest, &rule)
+		}
+	}
+
+	// 过滤已废弃的规则
+	rules := make([]*models.RiskRule, 0, len(latest))
+	for _, rule := range latest {
+		if rule.Status == models.RuleStatusActive {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// DeprecateRiskRule 废弃风险规则，以新版本记录废弃状态
+func (c *RiskContract) DeprecateRiskRule(ctx contractapi.TransactionContextInterface, behaviorType string) (*models.RiskRule, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	rule, err := getLatestRiskRule(ctx, behaviorType)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("风险规则不存在: %s", behaviorType)
+	}
+	if rule.Status == models.RuleStatusDeprecated {
+		return nil, fmt.Errorf("风险规则 %s 已废弃", behaviorType)
+	}
+
+	rule.Status = models.RuleStatusDeprecated
+	return putRiskRuleWithEvent(ctx, rule)
+}
+
+// seedDefaultRiskRules 将默认风险规则写入链上规则库，已存在的规则保持不变
+func seedDefaultRiskRules(ctx contractapi.TransactionContextInterface) error {
+	var seeded []*models.RiskRule
+	for i := range models.DefaultRiskRules {
+		existing, err := getLatestRiskRule(ctx, models.DefaultRiskRules[i].BehaviorType)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		rule := models.DefaultRiskRules[i]
+		rule.Status = models.RuleStatusActive
+		if _, err := putRiskRuleVersion(ctx, &rule); err != nil {
+			return err
+		}
+		seeded = append(seeded, &rule)
+	}
+	if len(seeded) == 0 {
+		return nil
+	}
+
+	// 每笔交易只能发送一个事件，批量写入的规则合并为一个事件
+	seededJSON, err := json.Marshal(seeded)
+	if err != nil {
+		return fmt.Errorf("风险规则序列化失败: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("RiskRulesSeeded", seededJSON)
+	if err != nil {
+		return fmt.Errorf("发送风险规则初始化事件失败: %v", err)
+	}
+
+	return nil
+}
+
+// getActiveRiskRule 获取生效的风险规则，不存在或已废弃时返回错误
+func getActiveRiskRule(ctx contractapi.TransactionContextInterface, behaviorType string) (*models.RiskRule, error) {
+	rule, err := getLatestRiskRule(ctx, behaviorType)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("风险规则不存在: %s", behaviorType)
+	}
+	if rule.Status != models.RuleStatusActive {
+		return nil, fmt.Errorf("风险规则 %s 已废弃", behaviorType)
+	}
+
+	return rule, nil
+}
+
+// getLatestRiskRule 获取风险规则的最新版本，不存在时返回nil
+func getLatestRiskRule(ctx contractapi.TransactionContextInterface, behaviorType string) (*models.RiskRule, error) {
+	if behaviorType == "" {
+		return nil, fmt.Errorf("行为类型不能为空")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.RiskRuleObjectType, []string{behaviorType})
+	if err != nil {
+		return nil, fmt.Errorf("查询风险规则时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var latestJSON []byte
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+		latestJSON = queryResponse.Value
+	}
+	if latestJSON == nil {
+		return nil, nil
+	}
+
+	var rule models.RiskRule
+	err = json.Unmarshal(latestJSON, &rule)
+	if err != nil {
+		return nil, fmt.Errorf("风险规则反序列化失败: %v", err)
+	}
+
+	return &rule, nil
+}
+
+// putRiskRuleWithEvent 写入风险规则新版本并发送规则变更事件，通知各预言机刷新规则缓存
+func putRiskRuleWithEvent(ctx contractapi.TransactionContextInterface, rule *models.RiskRule) (*models.RiskRule, error) {
+	rule, err := putRiskRuleVersion(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleJSON, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("风险规则序列化失败: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("RiskRuleUpdated", ruleJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送风险规则变更事件失败: %v", err)
+	}
+
+	return rule, nil
+}
+
+// putRiskRuleVersion 以新版本号写入风险规则
+func putRiskRuleVersion(ctx contractapi.TransactionContextInterface, rule *models.RiskRule) (*models.RiskRule, error) {
+	latest, err := getLatestRiskRule(ctx, rule.BehaviorType)
+	if err != nil {
+		return nil, err
+	}
+	rule.Version = 1
+	if latest != nil {
+		rule.Version = latest.Version + 1
+	}
+
+	// 记录修改者和修改时间，供审计使用
+	updatedBy, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者身份失败: %v", err)
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	rule.UpdatedBy = updatedBy
+	rule.UpdatedAt = time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	rule.TxID = ctx.GetStub().GetTxID()
+	rule.DocType = models.RiskRuleObjectType
+
+	// 版本号补零，保证复合键按版本顺序排列
+	ruleKey, err := ctx.GetStub().CreateCompositeKey(models.RiskRuleObjectType, []string{rule.BehaviorType, fmt.Sprintf("%08d", rule.Version)})
+	if err != nil {
+		return nil, fmt.Errorf("创建风险规则复合键失败: %v", err)
+	}
+
+	ruleJSON, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("风险规则序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(ruleKey, ruleJSON)
+	if err != nil {
+		return nil, fmt.Errorf("存储风险规则时出错: %v", err)
+	}
+
+	return rule, nil
+}
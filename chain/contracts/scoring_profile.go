@@ -0,0 +1,183 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// GetScoringProfile 获取当前生效的风险评分配置
+func (c *RiskContract) GetScoringProfile(ctx contractapi.TransactionContextInterface) (*models.ScoringProfile, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	return loadScoringProfile(ctx)
+}
+
+// GetScoringProfileVersion 获取指定版本的风险评分配置，用于复算和解释历史风险事件
+func (c *RiskContract) GetScoringProfileVersion(ctx contractapi.TransactionContextInterface, versionStr string) (*models.ScoringProfile, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, anyRole...); err != nil {
+		return nil, err
+	}
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version < 0 {
+		return nil, fmt.Errorf("无效的评分配置版本号: %s", versionStr)
+	}
+	if version == 0 {
+		profile := models.DefaultScoringProfile
+		return &profile, nil
+	}
+
+	versionKey, err := ctx.GetStub().CreateCompositeKey(models.ScoringProfileVersionObjectType, []string{fmt.Sprintf("%08d", version)})
+	if err != nil {
+		return nil, fmt.Errorf("创建风险评分配置版本复合键失败: %v", err)
+	}
+	profileJSON, err := ctx.GetStub().GetState(versionKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取风险评分配置时出错: %v", err)
+	}
+	if profileJSON == nil {
+		return nil, fmt.Errorf("风险评分配置版本 %d 不存在", version)
+	}
+
+	var profile models.ScoringProfile
+	err = json.Unmarshal(profileJSON, &profile)
+	if err != nil {
+		return nil, fmt.Errorf("风险评分配置反序列化失败: %v", err)
+	}
+
+	return &profile, nil
+}
+
+// SetScoringProfile 修改风险评分配置，生成新版本，之后的风险评分计算使用新配置
+// tierPolicyJSON 为随配置一起生效的风险等级策略，为空时沿用当前策略；修改最大风险分数时须同时提供覆盖到新上限的策略
+func (c *RiskContract) SetScoringProfile(ctx contractapi.TransactionContextInterface, profileJSON string, tierPolicyJSON string) (*models.ScoringProfile, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	// 解析并验证风险评分配置
+	var profile models.ScoringProfile
+	err := json.Unmarshal([]byte(profileJSON), &profile)
+	if err != nil {
+		return nil, fmt.Errorf("风险评分配置JSON解析失败: %v", err)
+	}
+	err = profile.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("风险评分配置无效: %v", err)
+	}
+
+	// 风险等级策略须覆盖到新的最大风险分数
+	var policy *models.RiskTierPolicy
+	if tierPolicyJSON != "" {
+		policy = &models.RiskTierPolicy{}
+		err = json.Unmarshal([]byte(tierPolicyJSON), policy)
+		if err != nil {
+			return nil, fmt.Errorf("风险等级策略JSON解析失败: %v", err)
+		}
+		if err := putRiskTierPolicy(ctx, policy, profile.Params.MaxScore); err != nil {
+			return nil, err
+		}
+	} else {
+		policy, err = loadRiskTierPolicy(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.Validate(profile.Params.MaxScore); err != nil {
+			return nil, fmt.Errorf("当前风险等级策略不适用于新的评分配置，请同时提供风险等级策略: %v", err)
+		}
+	}
+
+	// 版本号在当前配置基础上递增
+	current, err := loadScoringProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile.Version = current.Version + 1
+	profile.TierPolicyVersion = policy.Version
+
+	// 记录修改者和修改时间，供审计使用
+	updatedBy, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者身份失败: %v", err)
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	profile.UpdatedBy = updatedBy
+	profile.UpdatedAt = time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	profile.TxID = ctx.GetStub().GetTxID()
+	profile.DocType = models.ScoringProfileObjectType
+
+	// 写入当前配置，同时按版本号保留一份
+	profileKey, err := scoringProfileKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versionKey, err := ctx.GetStub().CreateCompositeKey(models.ScoringProfileVersionObjectType, []string{fmt.Sprintf("%08d", profile.Version)})
+	if err != nil {
+		return nil, fmt.Errorf("创建风险评分配置版本复合键失败: %v", err)
+	}
+	updatedProfileJSON, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("风险评分配置序列化失败: %v", err)
+	}
+	for _, key := range []string{profileKey, versionKey} {
+		err = ctx.GetStub().PutState(key, updatedProfileJSON)
+		if err != nil {
+			return nil, fmt.Errorf("存储风险评分配置时出错: %v", err)
+		}
+	}
+
+	// 发送配置变更事件，通知各客户端刷新评分配置和风险等级策略
+	err = ctx.GetStub().SetEvent("ScoringProfileUpdated", updatedProfileJSON)
+	if err != nil {
+		return nil, fmt.Errorf("发送风险评分配置变更事件失败: %v", err)
+	}
+
+	return &profile, nil
+}
+
+// loadScoringProfile 读取链上风险评分配置，未设置时使用内置默认配置
+func loadScoringProfile(ctx contractapi.TransactionContextInterface) (*models.ScoringProfile, error) {
+	profileKey, err := scoringProfileKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profileJSON, err := ctx.GetStub().GetState(profileKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取风险评分配置时出错: %v", err)
+	}
+	if profileJSON == nil {
+		profile := models.DefaultScoringProfile
+		return &profile, nil
+	}
+
+	var profile models.ScoringProfile
+	err = json.Unmarshal(profileJSON, &profile)
+	if err != nil {
+		return nil, fmt.Errorf("风险评分配置反序列化失败: %v", err)
+	}
+
+	return &profile, nil
+}
+
+// scoringProfileKey 生成当前风险评分配置的复合键
+func scoringProfileKey(ctx contractapi.TransactionContextInterface) (string, error) {
+	profileKey, err := ctx.GetStub().CreateCompositeKey(models.ScoringProfileObjectType, []string{"current"})
+	if err != nil {
+		return "", fmt.Errorf("创建风险评分配置复合键失败: %v", err)
+	}
+	return profileKey, nil
+}
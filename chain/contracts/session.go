@@ -0,0 +1,250 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// ConnectDevice 设备建立连接
+// 按设备当前风险等级检查连接资格，以交易ID作为会话ID记录会话；设备已在线时原会话自动关闭
+func (c *IdentityContract) ConnectDevice(ctx contractapi.TransactionContextInterface, did string) (*models.DeviceSession, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.Status != models.StatusActive && deviceInfo.Status != models.StatusRisky {
+		return nil, fmt.Errorf("设备状态为 %s, 不能连接", deviceInfo.Status)
+	}
+
+	// 根据风险等级策略检查连接资格
+	tier, err := riskTierForScore(ctx, deviceInfo.RiskScore)
+	if err != nil {
+		return nil, err
+	}
+	if !tier.AllowConnect {
+		return nil, fmt.Errorf("设备风险评分 %.2f 处于 %s 等级, 不允许连接: %s", deviceInfo.RiskScore, tier.Label, tier.ConnectionNotice)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	if err := closeDeviceSession(ctx, deviceInfo, "设备重新连接", txTime); err != nil {
+		return nil, err
+	}
+
+	session := &models.DeviceSession{
+		SessionID:   ctx.GetStub().GetTxID(),
+		DID:         did,
+		Status:      models.SessionStatusOpen,
+		Tier:        tier.Name,
+		RiskScore:   deviceInfo.RiskScore,
+		ConnectedAt: txTime,
+	}
+	if err := putDeviceSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	deviceInfo.ConnectionStatus = models.StatusOnline
+	deviceInfo.SessionID = session.SessionID
+	deviceInfo.LastUpdatedAt = txTime
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+
+	// 发送设备连接事件
+	if err := emitSessionEvent(ctx, "DeviceConnected", models.EventTypeConnect, deviceInfo, session.SessionID, txTime); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// DisconnectDevice 设备断开连接，关闭当前会话
+func (c *IdentityContract) DisconnectDevice(ctx contractapi.TransactionContextInterface, did string, sessionID string) (*models.DeviceSession, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deviceInfo, err := getDeviceInfo(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if deviceInfo.ConnectionStatus != models.StatusOnline || deviceInfo.SessionID != sessionID {
+		return nil, fmt.Errorf("会话 %s 不是设备 %s 当前的连接会话", sessionID, did)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	txTime := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+
+	if err := closeDeviceSession(ctx, deviceInfo, "设备主动断开", txTime); err != nil {
+		return nil, err
+	}
+	deviceInfo.LastUpdatedAt = txTime
+	if err := putDeviceInfo(ctx, deviceInfo); err != nil {
+		return nil, err
+	}
+
+	// 发送设备断开连接事件
+	if err := emitSessionEvent(ctx, "DeviceDisconnected", models.EventTypeDisconnect, deviceInfo, sessionID, txTime); err != nil {
+		return nil, err
+	}
+
+	return getDeviceSession(ctx, did, sessionID)
+}
+
+// GetDeviceSessions 获取设备的全部连接会话
+func (c *IdentityContract) GetDeviceSessions(ctx contractapi.TransactionContextInterface, did string) ([]*models.DeviceSession, error) {
+	// 检查调用者权限
+	if err := requireDeviceAccess(ctx, did, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	if _, err := getDeviceInfo(ctx, did); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.SessionObjectType, []string{did})
+	if err != nil {
+		return nil, fmt.Errorf("查询连接会话时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	sessions := []*models.DeviceSession{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		var session models.DeviceSession
+		err = json.Unmarshal(queryResponse.Value, &session)
+		if err != nil {
+			return nil, fmt.Errorf("连接会话反序列化失败: %v", err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, nil
+}
+
+// GetOnlineDevices 分页获取当前在线的设备
+func (c *IdentityContract) GetOnlineDevices(ctx contractapi.TransactionContextInterface, pageSizeStr string, bookmark string) (*models.DevicePage, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	pageSize, err := parsePageSize(pageSizeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryDevices(ctx, &deviceFilter{Online: true}, pageSize, bookmark)
+}
+
+// closeDeviceSession 关闭设备当前的连接会话并将设备置为离线，设备不在线时不做处理
+// 只修改传入的设备信息，由调用方写回账本
+func closeDeviceSession(ctx contractapi.TransactionContextInterface, deviceInfo *models.DeviceInfo, reason string, txTime time.Time) error {
+	if deviceInfo.ConnectionStatus != models.StatusOnline {
+		return nil
+	}
+
+	session, err := getDeviceSession(ctx, deviceInfo.DID, deviceInfo.SessionID)
+	if err != nil {
+		return err
+	}
+	session.Status = models.SessionStatusClosed
+	session.DisconnectedAt = txTime
+	session.CloseReason = reason
+	if err := putDeviceSession(ctx, session); err != nil {
+		return err
+	}
+
+	deviceInfo.ConnectionStatus = models.StatusOffline
+	deviceInfo.SessionID = ""
+	return nil
+}
+
+// getDeviceSession 读取连接会话
+func getDeviceSession(ctx contractapi.TransactionContextInterface, did, sessionID string) (*models.DeviceSession, error) {
+	sessionKey, err := ctx.GetStub().CreateCompositeKey(models.SessionObjectType, []string{did, sessionID})
+	if err != nil {
+		return nil, fmt.Errorf("创建连接会话复合键失败: %v", err)
+	}
+
+	sessionJSON, err := ctx.GetStub().GetState(sessionKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取连接会话时出错: %v", err)
+	}
+	if sessionJSON == nil {
+		return nil, fmt.Errorf("连接会话 %s 不存在", sessionID)
+	}
+
+	var session models.DeviceSession
+	err = json.Unmarshal(sessionJSON, &session)
+	if err != nil {
+		return nil, fmt.Errorf("连接会话反序列化失败: %v", err)
+	}
+
+	return &session, nil
+}
+
+// putDeviceSession 写入连接会话
+func putDeviceSession(ctx contractapi.TransactionContextInterface, session *models.DeviceSession) error {
+	session.DocType = models.SessionObjectType
+	sessionKey, err := ctx.GetStub().CreateCompositeKey(models.SessionObjectType, []string{session.DID, session.SessionID})
+	if err != nil {
+		return fmt.Errorf("创建连接会话复合键失败: %v", err)
+	}
+
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("连接会话序列化失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(sessionKey, sessionJSON)
+	if err != nil {
+		return fmt.Errorf("存储连接会话时出错: %v", err)
+	}
+
+	return nil
+}
+
+// emitSessionEvent 发送设备连接或断开连接事件
+func emitSessionEvent(ctx contractapi.TransactionContextInterface, eventName, eventType string, deviceInfo *models.DeviceInfo, sessionID string, txTime time.Time) error {
+	event := models.DeviceEvent{
+		EventType: eventType,
+		DID:       deviceInfo.DID,
+		Name:      deviceInfo.Name,
+		Timestamp: txTime.Unix(),
+		RiskScore: deviceInfo.RiskScore,
+		SessionID: sessionID,
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("事件数据序列化失败: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(eventName, eventJSON)
+	if err != nil {
+		return fmt.Errorf("发送设备连接事件失败: %v", err)
+	}
+
+	return nil
+}
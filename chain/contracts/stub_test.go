@@ -0,0 +1,140 @@
+package contracts
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// testIdentity 测试用的调用者身份，证书只包含解析角色所需的主题字段
+type testIdentity struct {
+	mspID string
+	cert  *x509.Certificate
+	attrs map[string]string
+}
+
+// newTestIdentity 创建测试身份，ou 为 admin 时按证书组织单元解析为管理员
+func newTestIdentity(mspID, commonName string, ou []string, attrs map[string]string) *testIdentity {
+	return &testIdentity{
+		mspID: mspID,
+		cert:  &x509.Certificate{Subject: pkix.Name{CommonName: commonName, OrganizationalUnit: ou}},
+		attrs: attrs,
+	}
+}
+
+func (i *testIdentity) GetID() (string, error) {
+	return "x509::" + i.cert.Subject.String(), nil
+}
+
+func (i *testIdentity) GetMSPID() (string, error) {
+	return i.mspID, nil
+}
+
+func (i *testIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, found := i.attrs[name]
+	return value, found, nil
+}
+
+func (i *testIdentity) AssertAttributeValue(name, value string) error {
+	if i.attrs[name] != value {
+		return fmt.Errorf("属性 %s 的值不是 %s", name, value)
+	}
+	return nil
+}
+
+func (i *testIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return i.cert, nil
+}
+
+// pagingCall 记录一次分页查询的参数
+type pagingCall struct {
+	startKey string
+	pageSize int32
+	read     int
+}
+
+// pagingStub 在 MockStub 上实现复合键分页查询
+// MockStub 的分页查询直接返回空结果，这里按节点的行为从书签所在的键开始读取，最多返回 pageSize 条记录
+type pagingStub struct {
+	*shimtest.MockStub
+	calls []pagingCall
+}
+
+func (s *pagingStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	iterator, err := s.MockStub.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	var results []*queryresult.KV
+	for iterator.HasNext() && int32(len(results)) < pageSize {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if bookmark != "" && kv.Key < bookmark {
+			continue
+		}
+		results = append(results, kv)
+	}
+
+	s.calls = append(s.calls, pagingCall{startKey: bookmark, pageSize: pageSize, read: len(results)})
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(results))}
+	return &sliceIterator{results: results}, metadata, nil
+}
+
+// sliceIterator 按顺序返回预先读取的记录
+type sliceIterator struct {
+	results []*queryresult.KV
+}
+
+func (it *sliceIterator) HasNext() bool {
+	return len(it.results) > 0
+}
+
+func (it *sliceIterator) Next() (*queryresult.KV, error) {
+	if len(it.results) == 0 {
+		return nil, fmt.Errorf("没有更多记录")
+	}
+	kv := it.results[0]
+	it.results = it.results[1:]
+	return kv, nil
+}
+
+func (it *sliceIterator) Close() error {
+	return nil
+}
+
+// startTx 开始一个交易ID为 txID、交易时间为 txTime 的模拟交易
+func (s *pagingStub) startTx(txID string, txTime time.Time) {
+	s.MockTransactionStart(txID)
+	s.TxTimestamp = timestamppb.New(txTime)
+}
+
+// newTestContext 创建以 identity 身份调用的交易上下文
+func newTestContext(t *testing.T, identity *testIdentity) (*contractapi.TransactionContext, *pagingStub) {
+	t.Helper()
+	stub := &pagingStub{MockStub: shimtest.NewMockStub(strings.ReplaceAll(t.Name(), "/", "_"), nil)}
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(identity)
+	return ctx, stub
+}
+
+// newAdminContext 创建以 OU=admin 管理员身份调用的交易上下文
+func newAdminContext(t *testing.T) (*contractapi.TransactionContext, *pagingStub) {
+	t.Helper()
+	return newTestContext(t, newTestIdentity("Org1MSP", "Admin@org1.chain.com", []string{models.AdminOU}, nil))
+}
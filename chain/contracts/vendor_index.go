@@ -0,0 +1,191 @@
+package contracts
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// GetDevicesByVendor 获取供应商的全部设备及其当前风险等级
+func (c *RiskContract) GetDevicesByVendor(ctx contractapi.TransactionContextInterface, vendor string) ([]*models.DeviceExposure, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	if vendor == "" {
+		return nil, fmt.Errorf("供应商不能为空")
+	}
+
+	return getDeviceExposures(ctx, vendor)
+}
+
+// GetDevicesByModel 获取供应商某一型号的全部设备及其当前风险等级
+func (c *RiskContract) GetDevicesByModel(ctx contractapi.TransactionContextInterface, vendor string, model string) ([]*models.DeviceExposure, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	if vendor == "" || model == "" {
+		return nil, fmt.Errorf("供应商和型号不能为空")
+	}
+
+	return getDeviceExposures(ctx, vendor, model)
+}
+
+// GetVendorRiskSummary 按供应商和型号汇总设备风险，vendor 为空时汇总所有供应商
+func (c *RiskContract) GetVendorRiskSummary(ctx contractapi.TransactionContextInterface, vendor string) ([]*models.VendorRiskSummary, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin, models.RoleOracle); err != nil {
+		return nil, err
+	}
+
+	attributes := []string{}
+	if vendor != "" {
+		attributes = append(attributes, vendor)
+	}
+	devices, err := getIndexedDevices(ctx, attributes...)
+	if err != nil {
+		return nil, err
+	}
+
+	// 索引按供应商、型号排序，相同型号的设备相邻
+	summaries := []*models.VendorRiskSummary{}
+	var current *models.VendorRiskSummary
+	var totalScore float64
+	for _, deviceInfo := range devices {
+		if current == nil || current.Vendor != deviceInfo.Vendor || current.Model != deviceInfo.Model {
+			if current != nil {
+				current.MeanRiskScore = totalScore / float64(current.DeviceCount)
+			}
+			current = &models.VendorRiskSummary{Vendor: deviceInfo.Vendor, Model: deviceInfo.Model}
+			summaries = append(summaries, current)
+			totalScore = 0
+		}
+
+		current.DeviceCount++
+		totalScore += deviceInfo.RiskScore
+		if deviceInfo.RiskScore > current.MaxRiskScore {
+			current.MaxRiskScore = deviceInfo.RiskScore
+		}
+		if deviceInfo.Status == models.StatusRisky {
+			current.RiskyCount++
+		}
+	}
+	if current != nil {
+		current.MeanRiskScore = totalScore / float64(current.DeviceCount)
+	}
+
+	return summaries, nil
+}
+
+// RebuildVendorModelIndex 为索引建立前注册的设备分批补建供应商-型号索引，返回本批补建的设备数量和下一批的书签
+func (c *RiskContract) RebuildVendorModelIndex(ctx contractapi.TransactionContextInterface, pageSizeStr string, bookmark string) (*models.VendorIndexRebuild, error) {
+	// 检查调用者权限
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	pageSize, err := parsePageSize(pageSizeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按设备DID前缀的键范围扫描，LevelDB和CouchDB上的书签均为上一批最后一个设备的DID
+	page, err := scanDevices(ctx, &deviceFilter{}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deviceInfo := range page.Devices {
+		if err := putVendorModelIndex(ctx, deviceInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.VendorIndexRebuild{Rebuilt: len(page.Devices), Bookmark: page.Bookmark}, nil
+}
+
+// getDeviceExposures 按供应商（和型号）查询设备，并根据风险等级策略标注当前等级
+func getDeviceExposures(ctx contractapi.TransactionContextInterface, attributes ...string) ([]*models.DeviceExposure, error) {
+	devices, err := getIndexedDevices(ctx, attributes...)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := loadRiskTierPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exposures := make([]*models.DeviceExposure, 0, len(devices))
+	for _, deviceInfo := range devices {
+		exposure := &models.DeviceExposure{
+			DID:              deviceInfo.DID,
+			Name:             deviceInfo.Name,
+			Vendor:           deviceInfo.Vendor,
+			Model:            deviceInfo.Model,
+			Status:           deviceInfo.Status,
+			ConnectionStatus: deviceInfo.ConnectionStatus,
+			RiskScore:        deviceInfo.RiskScore,
+		}
+		if tier := policy.TierForScore(deviceInfo.RiskScore); tier != nil {
+			exposure.Tier = tier.Name
+			exposure.RiskLevel = tier.Label
+			exposure.AllowConnect = tier.AllowConnect
+		}
+		exposures = append(exposures, exposure)
+	}
+
+	return exposures, nil
+}
+
+// getIndexedDevices 通过供应商-型号索引查询设备，attributes 为索引键的前缀属性
+func getIndexedDevices(ctx contractapi.TransactionContextInterface, attributes ...string) ([]*models.DeviceInfo, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(models.VendorModelIndex, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("查询供应商-型号索引时出错: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	devices := []*models.DeviceInfo{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一个状态时出错: %v", err)
+		}
+
+		_, keyAttributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("解析供应商-型号索引失败: %v", err)
+		}
+		if len(keyAttributes) != 3 {
+			continue
+		}
+
+		deviceInfo, err := getDeviceInfo(ctx, keyAttributes[2])
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, deviceInfo)
+	}
+
+	return devices, nil
+}
+
+// putVendorModelIndex 写入设备的供应商-型号索引，索引只需要键，值写入 0x00，因为 Fabric 写入空值会删除该键
+func putVendorModelIndex(ctx contractapi.TransactionContextInterface, deviceInfo *models.DeviceInfo) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(models.VendorModelIndex, []string{deviceInfo.Vendor, deviceInfo.Model, deviceInfo.DID})
+	if err != nil {
+		return fmt.Errorf("创建供应商-型号索引失败: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(indexKey, []byte{0x00})
+	if err != nil {
+		return fmt.Errorf("存储供应商-型号索引时出错: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package contracts
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Tittifer/IEEE/chain/models"
+	"github.com/Tittifer/IEEE/chain/utils"
+)
+
+func TestRebuildVendorModelIndex(t *testing.T) {
+	ctx, stub := newAdminContext(t)
+	contract := &RiskContract{}
+	stub.startTx("tx-devices", eventTime0)
+
+	// 直接写入设备信息，模拟索引引入前注册的设备
+	for i := 0; i < 5; i++ {
+		did := utils.GenerateDID("camera", "C1", "acme", fmt.Sprintf("SN%03d", i))
+		if err := putDeviceInfo(ctx, &models.DeviceInfo{DID: did, Vendor: "acme", Model: "C1", Status: models.StatusActive}); err != nil {
+			t.Fatalf("putDeviceInfo() error = %v", err)
+		}
+	}
+
+	total, batches := 0, 0
+	bookmark := ""
+	for {
+		result, err := contract.RebuildVendorModelIndex(ctx, "2", bookmark)
+		if err != nil {
+			t.Fatalf("RebuildVendorModelIndex() error = %v", err)
+		}
+		batches++
+		if result.Rebuilt > 2 {
+			t.Fatalf("第 %d 批补建了 %d 个设备, 超过 2", batches, result.Rebuilt)
+		}
+		total += result.Rebuilt
+		if result.Bookmark == "" {
+			break
+		}
+		bookmark = result.Bookmark
+	}
+	if total != 5 || batches != 3 {
+		t.Errorf("补建 %d 个设备, 分 %d 批, want 5 个设备, 3 批", total, batches)
+	}
+
+	exposures, err := contract.GetDevicesByModel(ctx, "acme", "C1")
+	if err != nil {
+		t.Fatalf("GetDevicesByModel() error = %v", err)
+	}
+	if len(exposures) != 5 {
+		t.Errorf("GetDevicesByModel() 返回 %d 个设备, want 5", len(exposures))
+	}
+
+	// 索引值不能为空，否则 Fabric 会删除该键
+	key, err := stub.CreateCompositeKey(models.VendorModelIndex, []string{"acme", "C1", exposures[0].DID})
+	if err != nil {
+		t.Fatalf("CreateCompositeKey() error = %v", err)
+	}
+	if value, err := stub.GetState(key); err != nil || len(value) == 0 {
+		t.Errorf("索引值 = %v (error = %v), 不应为空", value, err)
+	}
+}
@@ -322,6 +322,51 @@ function initChaincode() {
   successln "链码已成功部署"
 }
 
+# 初始化链上风险规则库
+function initRiskRules() {
+  infoln "初始化链上风险规则库..."
+  
+  docker exec cli_chain peer chaincode invoke \
+    -o orderer.chain.com:8050 \
+    --tls --cafile /opt/gopath/src/github.com/hyperledger/fabric/peer/crypto/ordererOrganizations/chain.com/orderers/orderer.chain.com/msp/tlscacerts/tlsca.chain.com-cert.pem \
+    -C ${CHANNEL_NAME} \
+    -n ${CHAINCODE_NAME} \
+    -c '{"function":"RiskContract:InitRiskLedger","Args":[]}' \
+    --peerAddresses peer0.org1.chain.com:8051 \
+    --tlsRootCertFiles /opt/gopath/src/github.com/hyperledger/fabric/peer/crypto/peerOrganizations/org1.chain.com/peers/peer0.org1.chain.com/tls/ca.crt \
+    --waitForEvent
+  
+  if [ $? -ne 0 ]; then
+    errorln "初始化风险规则库失败"
+    exit 1
+  fi
+  
+  successln "风险规则库已初始化"
+}
+
+# 为蜜点后台身份分配风险预言机角色
+# cryptogen 生成的证书没有 role 属性，由管理员（OU=admin）在链上绑定角色
+function assignRoles() {
+  infoln "为蜜点后台身份分配 oracle 角色..."
+  
+  docker exec cli_chain peer chaincode invoke \
+    -o orderer.chain.com:8050 \
+    --tls --cafile /opt/gopath/src/github.com/hyperledger/fabric/peer/crypto/ordererOrganizations/chain.com/orderers/orderer.chain.com/msp/tlscacerts/tlsca.chain.com-cert.pem \
+    -C ${CHANNEL_NAME} \
+    -n ${CHAINCODE_NAME} \
+    -c '{"function":"AssignRole","Args":["Org1MSP","User1@org1.chain.com","oracle"]}' \
+    --peerAddresses peer0.org1.chain.com:8051 \
+    --tlsRootCertFiles /opt/gopath/src/github.com/hyperledger/fabric/peer/crypto/peerOrganizations/org1.chain.com/peers/peer0.org1.chain.com/tls/ca.crt \
+    --waitForEvent
+  
+  if [ $? -ne 0 ]; then
+    errorln "分配 oracle 角色失败"
+    exit 1
+  fi
+  
+  successln "已为 User1@org1.chain.com 分配 oracle 角色"
+}
+
 # 部署链码（包括打包、安装、批准和提交）
 function deployChaincode() {
   infoln "开始部署链码..."
@@ -331,6 +376,8 @@ function deployChaincode() {
   approveChaincode
   commitChaincode
   initChaincode
+  initRiskRules
+  assignRoles
   
   # 生成CLI交互脚本
   generateCLIScript
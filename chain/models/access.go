@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// 调用者角色常量
+const (
+	RoleAdmin  = "admin"  // 运营管理员：注册设备、签发凭证、重置评分、管理生命周期和风险配置
+	RoleOracle = "oracle" // 风险预言机（蜜点后台）：上报风险行为、维护攻击画像
+	RoleDevice = "device" // 设备：只能访问自身的记录，证书CN必须为设备DID
+	RoleVendor = "vendor" // 设备供应商：发布自身型号的固件白名单，证书属性 vendor 为供应商名称
+)
+
+// 角色来源常量
+const (
+	RoleSourceAttribute = "attribute" // 证书属性 role
+	RoleSourceBinding   = "binding"   // 链上角色绑定
+	RoleSourceOU        = "ou"        // 证书组织单元 OU=admin
+)
+
+// RoleAttribute 证书中表示调用者角色的属性名
+const RoleAttribute = "role"
+
+// VendorAttribute 证书中表示供应商名称的属性名，供应商角色必须具有该属性
+const VendorAttribute = "vendor"
+
+// AdminOU 具有管理员角色的证书组织单元，与 Fabric NodeOU 的管理员身份一致
+const AdminOU = "admin"
+
+// RoleBindingObjectType 角色绑定复合键的对象类型
+const RoleBindingObjectType = "roleBinding"
+
+// RoleBinding 链上角色绑定，为证书中没有 role 属性的身份分配角色
+type RoleBinding struct {
+	DocType    string    `json:"docType"`    // 文档类型，用于富查询区分记录类型
+	MSPID      string    `json:"mspId"`      // 身份所属MSP
+	CommonName string    `json:"commonName"` // 身份证书的CN
+	Role       string    `json:"role"`       // 分配的角色
+	AssignedBy string    `json:"assignedBy"` // 分配角色的管理员身份
+	AssignedAt time.Time `json:"assignedAt"` // 分配时间
+}
+
+// CallerIdentity 调用者身份及其角色
+type CallerIdentity struct {
+	MSPID      string `json:"mspId"`                                     // 调用者所属MSP
+	CommonName string `json:"commonName"`                                // 调用者证书的CN
+	Role       string `json:"role,omitempty" metadata:",optional"`       // 调用者角色，未分配角色时为空
+	RoleSource string `json:"roleSource,omitempty" metadata:",optional"` // 角色来源: attribute, binding, ou
+	DID        string `json:"did,omitempty" metadata:",optional"`        // 设备角色对应的设备DID
+	Vendor     string `json:"vendor,omitempty" metadata:",optional"`     // 供应商角色对应的供应商名称
+}
+
+// ValidRole 检查角色是否有效
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleOracle || role == RoleDevice || role == RoleVendor
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// AddressBinding 设备网络地址绑定，在有效期内将IP、MAC和VLAN映射到设备DID
+type AddressBinding struct {
+	DocType    string    `json:"docType"`                            // 文档类型，用于富查询区分记录类型
+	DID        string    `json:"did"`                                // 设备DID
+	IP         string    `json:"ip"`                                 // 设备IP地址
+	MAC        string    `json:"mac,omitempty" metadata:",optional"` // 设备MAC地址，小写冒号分隔
+	VLAN       int       `json:"vlan"`                               // VLAN ID，0表示不带标签
+	ValidFrom  time.Time `json:"validFrom"`                          // 绑定生效时间
+	ValidUntil time.Time `json:"validUntil"`                         // 绑定失效时间，零值表示长期有效
+	BoundBy    string    `json:"boundBy"`                            // 登记绑定的身份
+	TxID       string    `json:"txId"`                               // 登记绑定的交易ID
+}
+
+// ActiveAt 判断绑定在指定时间是否有效
+func (b *AddressBinding) ActiveAt(t time.Time) bool {
+	return !t.Before(b.ValidFrom) && (b.ValidUntil.IsZero() || t.Before(b.ValidUntil))
+}
+
+// EdgeSubnet 子网的边缘设备，子网内未绑定地址上的告警归属到边缘设备
+type EdgeSubnet struct {
+	DocType   string    `json:"docType"`   // 文档类型，用于富查询区分记录类型
+	CIDR      string    `json:"cidr"`      // 子网，CIDR格式
+	VLAN      int       `json:"vlan"`      // VLAN ID，0表示不带标签
+	EdgeDID   string    `json:"edgeDid"`   // 边缘设备DID
+	UpdatedBy string    `json:"updatedBy"` // 最后修改的身份
+	UpdatedAt time.Time `json:"updatedAt"` // 最后修改时间
+	TxID      string    `json:"txId"`      // 最后修改的交易ID
+}
+
+// AddressResolution 网络地址解析结果
+type AddressResolution struct {
+	DID       string          `json:"did"`                                    // 告警归属的设备DID
+	MatchedBy string          `json:"matchedBy"`                              // 匹配方式: mac, ip, edge
+	Binding   *AddressBinding `json:"binding,omitempty" metadata:",optional"` // 匹配到的地址绑定
+	Subnet    *EdgeSubnet     `json:"subnet,omitempty" metadata:",optional"`  // 归属到边缘设备时匹配到的子网
+}
+
+// 地址解析匹配方式常量
+const (
+	AddressMatchMAC  = "mac"  // 按MAC地址匹配到绑定
+	AddressMatchIP   = "ip"   // 按VLAN和IP地址匹配到绑定
+	AddressMatchEdge = "edge" // 地址未绑定，归属到所在子网的边缘设备
+)
+
+// 地址绑定复合键对象类型
+const (
+	AddressBindingObjectType = "addressBinding"    // 地址绑定，键格式 addressBinding~vlan~ip~validFrom~did
+	AddressBindingMACIndex   = "addressBindingMAC" // MAC地址索引，键格式 addressBindingMAC~mac~validFrom~did，值为地址绑定的键
+	EdgeSubnetObjectType     = "edgeSubnet"        // 子网边缘设备，键格式 edgeSubnet~vlan~cidr
+)
+
+// MaxVLAN 最大的VLAN ID
+const MaxVLAN = 4094
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// AuthChallenge 设备认证挑战，设备需用登记的私钥对挑战消息签名
+type AuthChallenge struct {
+	DocType   string    `json:"docType"`   // 文档类型，用于富查询区分记录类型
+	DID       string    `json:"did"`       // 设备DID
+	Nonce     string    `json:"nonce"`     // 挑战随机数，由交易ID派生以保证各背书节点一致
+	Message   string    `json:"message"`   // 待签名的挑战消息
+	IssuedAt  time.Time `json:"issuedAt"`  // 签发时间
+	ExpiresAt time.Time `json:"expiresAt"` // 过期时间
+}
+
+// 认证挑战相关常量
+const (
+	AuthChallengeObjectType = "authChallenge" // 认证挑战复合键对象类型，键格式 authChallenge~did~nonce
+	AuthChallengeTTL        = 5 * time.Minute // 认证挑战有效期
+)
+
+// AuthChallengeMessage 生成挑战消息，消息绑定DID防止挑战被用于其他设备
+func AuthChallengeMessage(did, nonce string) string {
+	return did + ":" + nonce
+}
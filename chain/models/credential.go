@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// VerifiableCredential 设备可验证凭证，结构参照 W3C VC 数据模型
+type VerifiableCredential struct {
+	Context           []string          `json:"@context"`                             // JSON-LD 上下文
+	ID                string            `json:"id"`                                   // 凭证ID
+	Type              []string          `json:"type"`                                 // 凭证类型
+	Issuer            string            `json:"issuer"`                               // 签发者标识
+	IssuanceDate      string            `json:"issuanceDate"`                         // 签发时间，RFC3339格式
+	ExpirationDate    string            `json:"expirationDate"`                       // 过期时间，RFC3339格式
+	CredentialSubject CredentialSubject `json:"credentialSubject"`                    // 凭证主体
+	Proof             *CredentialProof  `json:"proof,omitempty" metadata:",optional"` // 签发者签名，链码签发时为空，由签发者在链下补充
+}
+
+// CredentialSubject 凭证主体，即被认证的设备
+type CredentialSubject struct {
+	ID     string `json:"id"`     // 设备DID
+	Name   string `json:"name"`   // 设备名称
+	Model  string `json:"model"`  // 设备型号
+	Vendor string `json:"vendor"` // 设备供应商
+}
+
+// CredentialProof 凭证签名
+type CredentialProof struct {
+	Type               string `json:"type"`               // 签名类型
+	Created            string `json:"created"`            // 签名时间，RFC3339格式
+	VerificationMethod string `json:"verificationMethod"` // 验证签名使用的公钥标识
+	ProofPurpose       string `json:"proofPurpose"`       // 签名用途
+	ProofValue         string `json:"proofValue"`         // Base64编码的ASN.1 ECDSA签名，签名内容为凭证哈希
+}
+
+// CredentialRecord 链上凭证记录，只保存凭证哈希和状态，凭证本身由设备持有
+type CredentialRecord struct {
+	DocType          string    `json:"docType"`                                         // 文档类型，用于富查询区分记录类型
+	ID               string    `json:"id"`                                              // 凭证ID
+	DID              string    `json:"did"`                                             // 设备DID
+	Hash             string    `json:"hash"`                                            // 不含签名的凭证SHA256哈希
+	Status           string    `json:"status"`                                          // 凭证状态: active, revoked
+	Issuer           string    `json:"issuer"`                                          // 签发者标识
+	IssuerPublicKey  string    `json:"issuerPublicKey"`                                 // 签发者公钥（PEM），取自签发交易的提交者证书
+	IssuedAt         time.Time `json:"issuedAt"`                                        // 签发时间
+	ExpiresAt        time.Time `json:"expiresAt"`                                       // 过期时间
+	RevokedAt        time.Time `json:"revokedAt"`                                       // 吊销时间
+	RevocationReason string    `json:"revocationReason,omitempty" metadata:",optional"` // 吊销原因
+	TxID             string    `json:"txId"`                                            // 签发交易ID
+}
+
+// CredentialVerification 凭证验证结果
+type CredentialVerification struct {
+	CredentialID string `json:"credentialId"`                          // 凭证ID
+	DID          string `json:"did"`                                   // 设备DID
+	Valid        bool   `json:"valid"`                                 // 是否有效
+	Reason       string `json:"reason,omitempty" metadata:",optional"` // 无效原因
+}
+
+// 凭证状态常量
+const (
+	CredentialStatusActive  = "active"  // 凭证有效
+	CredentialStatusRevoked = "revoked" // 凭证已吊销
+)
+
+// 凭证相关常量
+const (
+	CredentialObjectType   = "credential"                             // 凭证记录复合键对象类型，键格式 credential~did~credentialID
+	CredentialContext      = "https://www.w3.org/2018/credentials/v1" // W3C VC 上下文
+	CredentialType         = "DeviceIdentityCredential"               // 设备身份凭证类型
+	CredentialProofType    = "EcdsaSecp256r1Signature2019"            // 凭证签名类型
+	CredentialValidity     = 365 * 24 * time.Hour                     // 凭证有效期
+	CredentialIDPrefix     = "urn:ieee:vc:"                           // 凭证ID前缀，后接签发交易ID
+	CredentialIssuerPrefix = "did:ieee:issuer:"                       // 签发者标识前缀，后接组织MSP ID
+)
@@ -2,62 +2,111 @@ package models
 
 import (
 	"time"
+
+	"github.com/Tittifer/IEEE/chain/scoring"
 )
 
 // DeviceInfo 设备信息结构体
 type DeviceInfo struct {
+	DocType          string    `json:"docType"`          // 文档类型，用于富查询区分记录类型
 	DID              string    `json:"did"`              // 设备的分布式身份标识符
 	Name             string    `json:"name"`             // 设备名称
 	Model            string    `json:"model"`            // 设备型号
 	Vendor           string    `json:"vendor"`           // 设备供应商
+	DeviceID         string    `json:"deviceId,omitempty" metadata:",optional"`          // 设备ID（出厂序列号），第一版DID注册的设备没有记录
+	RegistrationNonce string   `json:"registrationNonce,omitempty" metadata:",optional"` // 注册随机数（注册交易ID），与公钥共同派生第二版DID
+	PublicKey        string    `json:"publicKey"`        // 设备公钥（PEM），用于挑战-响应认证
+	Controller       string    `json:"controller"`       // 控制者DID，即注册设备的组织
+	Services         []ServiceEndpoint `json:"services,omitempty" metadata:",optional"` // 设备声明的服务端点
+	FirmwareVersion  string    `json:"firmwareVersion,omitempty" metadata:",optional"`  // 最近一次证明的固件版本
+	FirmwareHash     string    `json:"firmwareHash,omitempty" metadata:",optional"`     // 最近一次证明的固件哈希（SHA256）
+	FirmwareStatus   string    `json:"firmwareStatus,omitempty" metadata:",optional"`   // 固件核验结果: verified, mismatch, unknown_version, unlisted
+	ConfigHash       string    `json:"configHash,omitempty" metadata:",optional"`       // 最近一次证明的配置哈希（SHA256）
+	AttestedAt       time.Time `json:"attestedAt"`       // 最近一次固件或配置证明的时间
 	RiskScore        float64   `json:"riskScore"`        // 设备历史风险分数 (S_{t-1})，范围 [0, S_{max}]
 	AttackIndexI     float64   `json:"attackIndexI"`     // 攻击画像指数 (I)，范围 [0, ∞)
 	AttackProfile    []string  `json:"attackProfile"`    // 攻击画像，存储设备已触发过的不重复的行为类别
 	LastEventTime    time.Time `json:"lastEventTime"`    // 上次事件时间 (t_{last})
-	Status           string    `json:"status"`           // 设备状态: active, inactive, risky
+	LastDecayTime    time.Time `json:"lastDecayTime"`    // 上次衰减攻击画像指数的时间，衰减不属于风险事件，不影响上次事件时间
+	ScoringProfileVersion int  `json:"scoringProfileVersion"` // 最近一次计算风险评分或衰减攻击画像指数时使用的评分配置版本
+	Status           string    `json:"status"`           // 设备状态: active, risky（由风险等级策略决定）, inactive（已暂停）, decommissioned（已退役）
+	StatusReason     string    `json:"statusReason,omitempty" metadata:",optional"`    // 最近一次生命周期变更的原因
+	StatusChangedBy  string    `json:"statusChangedBy,omitempty" metadata:",optional"` // 最近一次生命周期变更的执行者
+	StatusChangedAt  time.Time `json:"statusChangedAt"`  // 最近一次生命周期变更的时间
+	ReplacedBy       string    `json:"replacedBy,omitempty" metadata:",optional"`      // 替换本设备的新设备DID
+	Replaces         string    `json:"replaces,omitempty" metadata:",optional"`        // 本设备替换的旧设备DID
+	ConnectionStatus string    `json:"connectionStatus,omitempty" metadata:",optional"` // 连接状态: online, offline
+	SessionID        string    `json:"sessionId,omitempty" metadata:",optional"`        // 当前连接会话ID，离线时为空
 	CreatedAt        time.Time `json:"createdAt"`        // 创建时间
 	LastUpdatedAt    time.Time `json:"lastUpdatedAt"`    // 最后更新时间
 }
 
 // DeviceEvent 设备事件结构体，用于链码事件
 type DeviceEvent struct {
-	EventType    string    `json:"eventType"`    // 事件类型
-	DID          string    `json:"did"`          // 设备DID
-	Name         string    `json:"name"`         // 设备名称
-	Timestamp    int64     `json:"timestamp"`    // 事件时间戳
-	RiskScore    float64   `json:"riskScore"`    // 风险评分
-	Category     string    `json:"category"`     // 行为类别
-	BehaviorType string    `json:"behaviorType"` // 具体行为类型
+	EventType     string  `json:"eventType"`               // 事件类型
+	DID           string  `json:"did"`                     // 设备DID
+	Name          string  `json:"name"`                    // 设备名称
+	Timestamp     int64   `json:"timestamp"`               // 事件时间戳
+	RiskScore     float64 `json:"riskScore"`               // 风险评分
+	Category      string  `json:"category"`                // 行为类别
+	BehaviorType  string  `json:"behaviorType"`            // 具体行为类型
+	EvidenceHash  string  `json:"evidenceHash,omitempty"`  // 风险行为证据哈希
+	SessionID     string  `json:"sessionId,omitempty"`     // 设备连接会话ID，设备离线时为空
+	SessionClosed bool    `json:"sessionClosed,omitempty"` // 风险评分进入禁止连接的等级，sessionId 对应的会话已在同一交易中关闭
 }
 
+// 设备记录常量
+const (
+	DeviceDocType   = "device"           // 设备信息的文档类型
+	DeviceDIDPrefix = "did:ieee:device:" // 设备DID前缀，设备信息以DID为键存储
+)
+
+// PreconditionFailed 设备最后更新时间与调用方期望不一致时错误信息的前缀，调用方据此识别并重新读取设备后重试
+const PreconditionFailed = "设备版本前置条件不满足"
+
+// DeviceRegistrationIndex 设备注册索引复合键对象类型，键格式 deviceRegistration~vendor~model~deviceID，值为设备DID
+const DeviceRegistrationIndex = "deviceRegistration"
+
 // 事件类型常量
 const (
 	EventTypeRegister   = "register"    // 设备注册事件
 	EventTypeConnect    = "connect"     // 设备连接事件
 	EventTypeDisconnect = "disconnect"  // 设备断开连接事件
 	EventTypeRiskUpdate = "risk_update" // 风险评分更新事件
-	EventTypeRiskReset  = "risk_reset"  // 风险评分重置事件
 )
 
 // 设备状态常量
 const (
-	StatusActive   = "active"   // 设备活跃状态
-	StatusInactive = "inactive" // 设备非活跃状态
-	StatusRisky    = "risky"    // 设备风险状态
-	StatusOnline   = "online"   // 设备在线状态
-	StatusOffline  = "offline"  // 设备离线状态
+	StatusActive         = "active"         // 设备活跃状态
+	StatusInactive       = "inactive"       // 设备非活跃状态（已暂停）
+	StatusRisky          = "risky"          // 设备风险状态
+	StatusDecommissioned = "decommissioned" // 设备已退役，终止状态
+	StatusOnline         = "online"         // 设备在线状态（连接状态）
+	StatusOffline        = "offline"        // 设备离线状态（连接状态）
 )
 
 // 风险评分相关常量
 const (
 	InitialRiskScore   = 0.00  // 初始风险评分
-	RiskScoreThreshold = 50.00 // 风险评分阈值，超过此值将禁止设备连接
-	MaxRiskScore       = 1000.0 // 最大风险分数 S_{max}
+	MaxRiskScore       = scoring.DefaultMaxScore // 默认最大风险分数 S_{max}，生效值由风险评分配置决定
 )
 
-// 风险评估参数常量
-const (
-	Delta = 0.05  // 影响低分时降温速度参数
-	Alpha = 0.02  // 影响高分时降温速度参数
-	Lambda = 0.01 // 攻击画像指数衰减系数
-)
+// RiskState 返回设备的风险状态，用于风险评分计算
+func (d *DeviceInfo) RiskState() scoring.State {
+	return scoring.State{
+		RiskScore:     d.RiskScore,
+		AttackIndex:   d.AttackIndexI,
+		AttackProfile: d.AttackProfile,
+		LastEventTime: d.LastEventTime,
+		LastDecayTime: d.LastDecayTime,
+	}
+}
+
+// SetRiskState 将风险评分计算结果写回设备信息
+func (d *DeviceInfo) SetRiskState(state scoring.State) {
+	d.RiskScore = state.RiskScore
+	d.AttackIndexI = state.AttackIndex
+	d.AttackProfile = state.AttackProfile
+	d.LastEventTime = state.LastEventTime
+	d.LastDecayTime = state.LastDecayTime
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+)
+
+// DIDDocument W3C DID Core 文档
+type DIDDocument struct {
+	Context            []string             `json:"@context"`           // JSON-LD 上下文
+	ID                 string               `json:"id"`                 // DID
+	Controller         string               `json:"controller"`         // 控制者DID，即注册设备的电网运营方
+	VerificationMethod []VerificationMethod `json:"verificationMethod"` // 验证方法
+	Authentication     []string             `json:"authentication"`     // 可用于认证的验证方法
+	AssertionMethod    []string             `json:"assertionMethod"`    // 可用于声明签名的验证方法
+	Service            []ServiceEndpoint    `json:"service"`            // 服务端点
+}
+
+// VerificationMethod DID 验证方法
+type VerificationMethod struct {
+	ID           string      `json:"id"`           // 验证方法ID
+	Type         string      `json:"type"`         // 验证方法类型
+	Controller   string      `json:"controller"`   // 控制者DID
+	PublicKeyJwk *JSONWebKey `json:"publicKeyJwk"` // JWK格式公钥
+}
+
+// JSONWebKey EC公钥的JWK表示
+type JSONWebKey struct {
+	Kty string `json:"kty"` // 密钥类型
+	Crv string `json:"crv"` // 曲线
+	X   string `json:"x"`   // X坐标，Base64url编码
+	Y   string `json:"y"`   // Y坐标，Base64url编码
+}
+
+// ServiceEndpoint DID 服务端点
+type ServiceEndpoint struct {
+	ID              string `json:"id"`              // 服务ID，形如 <did>#<名称>
+	Type            string `json:"type"`            // 服务类型
+	ServiceEndpoint string `json:"serviceEndpoint"` // 服务地址
+}
+
+// DIDDocumentMetadata DID 文档元数据
+type DIDDocumentMetadata struct {
+	Created     string `json:"created"`     // 创建时间，RFC3339格式
+	Updated     string `json:"updated"`     // 最后更新时间，RFC3339格式
+	Deactivated bool   `json:"deactivated"` // 是否已停用
+}
+
+// DIDResolutionMetadata DID 解析元数据
+type DIDResolutionMetadata struct {
+	ContentType string `json:"contentType"` // 文档内容类型
+}
+
+// DIDResolutionResult DID 解析结果，结构与 Universal Resolver 的返回一致
+type DIDResolutionResult struct {
+	DIDDocument           *DIDDocument          `json:"didDocument"`           // DID 文档
+	DIDDocumentMetadata   DIDDocumentMetadata   `json:"didDocumentMetadata"`   // DID 文档元数据
+	DIDResolutionMetadata DIDResolutionMetadata `json:"didResolutionMetadata"` // 解析元数据
+}
+
+// DID 文档相关常量
+const (
+	DIDContext             = "https://www.w3.org/ns/did/v1"                // DID Core 上下文
+	JWS2020Context         = "https://w3id.org/security/suites/jws-2020/v1" // JsonWebKey2020 上下文
+	VerificationMethodType = "JsonWebKey2020"                               // 验证方法类型
+	DeviceKeyFragment      = "#key-1"                                       // 设备公钥验证方法片段
+	DIDLDContentType       = "application/did+ld+json"                      // DID 文档内容类型
+)
+
+// OrganizationDID 根据组织MSP ID生成组织标识，作为设备DID的控制者和凭证签发者
+func OrganizationDID(mspID string) string {
+	return CredentialIssuerPrefix + strings.ToLower(mspID)
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// FirmwareRelease 供应商发布的已知可信固件版本
+type FirmwareRelease struct {
+	Version     string `json:"version"`                                    // 固件版本
+	Hash        string `json:"hash"`                                       // 固件哈希（SHA256十六进制）
+	Description string `json:"description,omitempty" metadata:",optional"` // 版本说明
+}
+
+// FirmwareAllowlist 供应商为某一型号发布的固件白名单
+type FirmwareAllowlist struct {
+	DocType     string            `json:"docType"`                             // 文档类型，用于富查询区分记录类型
+	Vendor      string            `json:"vendor"`                              // 设备供应商
+	Model       string            `json:"model"`                               // 设备型号
+	Releases    []FirmwareRelease `json:"releases"`                            // 已知可信的固件版本
+	PublishedBy string            `json:"publishedBy"`                         // 发布白名单的身份
+	UpdatedAt   time.Time         `json:"updatedAt"`                           // 最后发布时间
+	TxID        string            `json:"txId,omitempty" metadata:",optional"` // 发布白名单的交易ID
+}
+
+// FirmwareAttestation 设备固件证明的核验结果
+type FirmwareAttestation struct {
+	DID             string    `json:"did"`             // 设备DID
+	FirmwareVersion string    `json:"firmwareVersion"` // 设备证明的固件版本
+	FirmwareHash    string    `json:"firmwareHash"`    // 设备证明的固件哈希
+	Status          string    `json:"status"`          // 核验结果: verified, mismatch, unknown_version, unlisted
+	RiskScore       float64   `json:"riskScore"`       // 核验后的风险评分
+	Timestamp       time.Time `json:"timestamp"`       // 证明时间
+	TxID            string    `json:"txId"`            // 证明交易ID
+}
+
+// FirmwareAllowlistObjectType 固件白名单复合键对象类型，键格式 firmwareAllowlist~vendor~model
+const FirmwareAllowlistObjectType = "firmwareAllowlist"
+
+// FirmwareTamperBehavior 固件版本在白名单中但哈希不一致时自动上报的风险行为类型
+const FirmwareTamperBehavior = "firmware_tamper"
+
+// FirmwareAttestationSource 固件核验自动上报风险行为时记录的来源，代替蜜点ID
+const FirmwareAttestationSource = "firmware-attestation"
+
+// 固件核验结果常量
+const (
+	FirmwareVerified       = "verified"        // 固件哈希与白名单中的版本一致
+	FirmwareMismatch       = "mismatch"        // 白名单中有该版本，但固件哈希与该版本不一致
+	FirmwareUnknownVersion = "unknown_version" // 型号已发布白名单，但白名单中没有该版本，无法核验，不计分
+	FirmwareUnlisted       = "unlisted"        // 型号尚未发布白名单，无法核验
+)
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceHistoryEntry 设备信息的一个历史版本，取自账本的键历史
+type DeviceHistoryEntry struct {
+	TxID      string        `json:"txId"`                                   // 写入该版本的交易ID
+	Timestamp time.Time     `json:"timestamp"`                              // 交易时间
+	IsDelete  bool          `json:"isDelete"`                               // 该交易是否删除了设备信息
+	Device    *DeviceInfo   `json:"device,omitempty" metadata:",optional"`  // 该版本的设备信息，删除时为空
+	Changes   []FieldChange `json:"changes,omitempty" metadata:",optional"` // 与上一版本相比发生变化的字段，首个版本为空
+}
+
+// FieldChange 设备信息字段的变化，新旧值均为字段的JSON编码
+type FieldChange struct {
+	Field    string `json:"field"`                                   // 字段名，与设备信息的JSON字段名一致
+	OldValue string `json:"oldValue,omitempty" metadata:",optional"` // 变化前的值，新增字段时为空
+	NewValue string `json:"newValue,omitempty" metadata:",optional"` // 变化后的值，删除字段时为空
+}
@@ -0,0 +1,31 @@
+package models
+
+// LifecycleEvent 设备生命周期状态变更事件
+type LifecycleEvent struct {
+	DID        string `json:"did"`                  // 设备DID
+	FromStatus string `json:"fromStatus"`           // 变更前状态
+	ToStatus   string `json:"toStatus"`             // 变更后状态
+	Reason     string `json:"reason"`               // 变更原因
+	Actor      string `json:"actor"`                // 执行变更的身份
+	Timestamp  int64  `json:"timestamp"`            // 变更时间戳
+	ReplacedBy string `json:"replacedBy,omitempty"` // 替换后的设备DID，仅设备替换时有值
+}
+
+// lifecycleTransitions 生命周期状态机允许的状态转换
+// active 和 risky 之间的转换由风险等级策略驱动，其余转换须通过生命周期交易完成
+var lifecycleTransitions = map[string][]string{
+	StatusActive:         {StatusInactive, StatusDecommissioned},
+	StatusRisky:          {StatusInactive, StatusDecommissioned},
+	StatusInactive:       {StatusActive, StatusRisky, StatusDecommissioned},
+	StatusDecommissioned: {},
+}
+
+// CanTransition 检查设备状态能否从 from 转换到 to
+func CanTransition(from, to string) bool {
+	for _, status := range lifecycleTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: StatusActive, to: StatusInactive, want: true},
+		{from: StatusActive, to: StatusDecommissioned, want: true},
+		{from: StatusRisky, to: StatusInactive, want: true},
+		{from: StatusRisky, to: StatusDecommissioned, want: true},
+		{from: StatusInactive, to: StatusActive, want: true},
+		{from: StatusInactive, to: StatusRisky, want: true},
+		{from: StatusInactive, to: StatusDecommissioned, want: true},
+		// active 和 risky 之间的转换由风险等级策略驱动
+		{from: StatusActive, to: StatusRisky, want: false},
+		{from: StatusRisky, to: StatusActive, want: false},
+		{from: StatusActive, to: StatusActive, want: false},
+		// 退役后不可恢复
+		{from: StatusDecommissioned, to: StatusActive, want: false},
+		{from: StatusDecommissioned, to: StatusInactive, want: false},
+		{from: StatusDecommissioned, to: StatusRisky, want: false},
+		// 连接状态不属于生命周期状态
+		{from: StatusOnline, to: StatusInactive, want: false},
+		{from: StatusActive, to: StatusOffline, want: false},
+		{from: "", to: StatusActive, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// RiskTier 风险等级，定义分数区间、对应设备状态和响应策略
+type RiskTier struct {
+	Name             string   `json:"name"`             // 等级标识: normal, watch, alert, critical
+	Label            string   `json:"label"`            // 等级名称
+	MinScore         float64  `json:"minScore"`         // 分数下限（包含）
+	MaxScore         float64  `json:"maxScore"`         // 分数上限（不包含，最高等级包含 S_{max}）
+	DeviceStatus     string   `json:"deviceStatus"`     // 处于该等级时设备应处的状态: active, risky
+	AllowConnect     bool     `json:"allowConnect"`     // 是否允许设备连接
+	ConnectionNotice string   `json:"connectionNotice"` // 连接资格检查结果说明
+	Strategy         string   `json:"strategy"`         // 响应策略
+	Measures         []string `json:"measures"`         // 响应措施代码，见 MeasureCatalog
+}
+
+// RiskTierPolicy 风险等级策略，身份合约、风险合约和各客户端共用同一份策略
+type RiskTierPolicy struct {
+	DocType   string     `json:"docType"`                                  // 文档类型，用于富查询区分记录类型
+	Version   int        `json:"version"`                                  // 策略版本号，每次修改递增
+	Tiers     []RiskTier `json:"tiers"`                                    // 按分数从低到高排列的风险等级
+	UpdatedBy string     `json:"updatedBy,omitempty" metadata:",optional"` // 最后修改者身份
+	UpdatedAt time.Time  `json:"updatedAt"`                                // 最后修改时间
+	TxID      string     `json:"txId,omitempty" metadata:",optional"`      // 修改策略的交易ID
+}
+
+// 风险等级标识常量
+const (
+	TierNormal   = "normal"   // 常规
+	TierWatch    = "watch"    // 关注
+	TierAlert    = "alert"    // 警戒
+	TierCritical = "critical" // 高危
+)
+
+// 风险等级策略复合键对象类型
+const (
+	RiskTierPolicyObjectType        = "riskTierPolicy"        // 当前生效的策略
+	RiskTierPolicyVersionObjectType = "riskTierPolicyVersion" // 按版本号保存的全部策略
+)
+
+// DefaultRiskTierPolicy 大纲中定义的动态风险响应策略
+var DefaultRiskTierPolicy = RiskTierPolicy{
+	Version: 0,
+	Tiers: []RiskTier{
+		{
+			Name:             TierNormal,
+			Label:            "常规",
+			MinScore:         0,
+			MaxScore:         1,
+			DeviceStatus:     StatusActive,
+			AllowConnect:     true,
+			ConnectionNotice: "常规风险，设备可以正常连接",
+			Strategy:         "标准化信任与监控",
+			Measures: []string{
+				MeasureDefaultLogging,
+				MeasureSessionDIDVerify,
+			},
+		},
+		{
+			Name:             TierWatch,
+			Label:            "关注",
+			MinScore:         1,
+			MaxScore:         200,
+			DeviceStatus:     StatusActive,
+			AllowConnect:     true,
+			ConnectionNotice: "关注风险，增强监控，主动引诱",
+			Strategy:         "增强监控，主动引诱，无感知的情报收集",
+			Measures: []string{
+				MeasureFullPCAP,
+				MeasureBehaviorAudit,
+				MeasureRateLimit,
+				MeasureExposeLure,
+			},
+		},
+		{
+			Name:             TierAlert,
+			Label:            "警戒",
+			MinScore:         200,
+			MaxScore:         700,
+			DeviceStatus:     StatusRisky,
+			AllowConnect:     true,
+			ConnectionNotice: "警戒风险，将设备流量重定向至隔离的蜜网环境",
+			Strategy:         "主动欺骗与隔离引导",
+			Measures: []string{
+				MeasureDNSSinkhole,
+				MeasureIsolateVLAN,
+				MeasurePlantHoneytoken,
+			},
+		},
+		{
+			Name:             TierCritical,
+			Label:            "高危",
+			MinScore:         700,
+			MaxScore:         MaxRiskScore,
+			DeviceStatus:     StatusRisky,
+			AllowConnect:     false,
+			ConnectionNotice: "高危风险，禁止设备连接",
+			Strategy:         "硬性阻断",
+			Measures: []string{
+				MeasureDropConnections,
+				MeasureLockAccount,
+				MeasureSnapshotHoneypoint,
+				MeasureManualForensics,
+			},
+		},
+	},
+}
+
+// TierForScore 根据风险评分查找所属风险等级
+func (p *RiskTierPolicy) TierForScore(score float64) *RiskTier {
+	last := len(p.Tiers) - 1
+	for i := range p.Tiers {
+		tier := &p.Tiers[i]
+		if score >= tier.MinScore && (score < tier.MaxScore || i == last) {
+			return tier
+		}
+	}
+	return nil
+}
+
+// HighestTier 获取最高风险等级
+func (p *RiskTierPolicy) HighestTier() *RiskTier {
+	if len(p.Tiers) == 0 {
+		return nil
+	}
+	return &p.Tiers[len(p.Tiers)-1]
+}
+
+// Validate 验证风险等级策略，等级须从0开始连续覆盖到风险评分配置的 S_{max}
+func (p *RiskTierPolicy) Validate(maxScore float64) error {
+	if len(p.Tiers) == 0 {
+		return fmt.Errorf("风险等级策略至少需要一个等级")
+	}
+
+	names := make(map[string]bool)
+	for i, tier := range p.Tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("第 %d 个风险等级缺少标识", i+1)
+		}
+		if names[tier.Name] {
+			return fmt.Errorf("风险等级标识重复: %s", tier.Name)
+		}
+		names[tier.Name] = true
+
+		if tier.MinScore >= tier.MaxScore {
+			return fmt.Errorf("风险等级 %s 的分数区间无效", tier.Name)
+		}
+		if i == 0 && tier.MinScore != 0 {
+			return fmt.Errorf("最低风险等级的分数下限必须为0")
+		}
+		if i > 0 && tier.MinScore != p.Tiers[i-1].MaxScore {
+			return fmt.Errorf("风险等级 %s 与前一等级的分数区间不连续", tier.Name)
+		}
+		if tier.DeviceStatus != StatusActive && tier.DeviceStatus != StatusRisky {
+			return fmt.Errorf("风险等级 %s 的设备状态必须为 %s 或 %s", tier.Name, StatusActive, StatusRisky)
+		}
+		for _, code := range tier.Measures {
+			if _, ok := MeasureCatalog[code]; !ok {
+				return fmt.Errorf("风险等级 %s 包含未知的响应措施代码: %s", tier.Name, code)
+			}
+		}
+	}
+	if p.Tiers[len(p.Tiers)-1].MaxScore != maxScore {
+		return fmt.Errorf("最高风险等级的分数上限必须为 %.2f", maxScore)
+	}
+
+	return nil
+}
+
+// ApplyTierStatus 根据风险等级在活跃和风险状态之间切换设备状态，其他状态保持不变
+func ApplyTierStatus(device *DeviceInfo, tier *RiskTier) {
+	if tier == nil {
+		return
+	}
+	if device.Status == StatusActive || device.Status == StatusRisky {
+		device.Status = tier.DeviceStatus
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import "testing"
+
+// testPolicy 复制默认风险等级策略，避免测试修改全局变量
+func testPolicy() RiskTierPolicy {
+	policy := DefaultRiskTierPolicy
+	policy.Tiers = append([]RiskTier(nil), DefaultRiskTierPolicy.Tiers...)
+	return policy
+}
+
+func TestTierForScore(t *testing.T) {
+	policy := testPolicy()
+
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{score: 0, want: TierNormal},
+		{score: 0.5, want: TierNormal},
+		{score: 1, want: TierWatch},
+		{score: 199.99, want: TierWatch},
+		{score: 200, want: TierAlert},
+		{score: 699.99, want: TierAlert},
+		{score: 700, want: TierCritical},
+		{score: MaxRiskScore, want: TierCritical},
+	}
+
+	for _, tt := range tests {
+		tier := policy.TierForScore(tt.score)
+		if tier == nil {
+			t.Errorf("TierForScore(%v) = nil, want %s", tt.score, tt.want)
+			continue
+		}
+		if tier.Name != tt.want {
+			t.Errorf("TierForScore(%v) = %s, want %s", tt.score, tier.Name, tt.want)
+		}
+	}
+
+	if tier := policy.TierForScore(-1); tier != nil {
+		t.Errorf("TierForScore(-1) = %s, want nil", tier.Name)
+	}
+	empty := RiskTierPolicy{}
+	if tier := empty.TierForScore(0); tier != nil {
+		t.Errorf("空策略 TierForScore(0) = %s, want nil", tier.Name)
+	}
+}
+
+func TestRiskTierPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(p *RiskTierPolicy)
+		maxScore float64
+		wantErr  bool
+	}{
+		{name: "默认策略", modify: func(p *RiskTierPolicy) {}, maxScore: MaxRiskScore},
+		{
+			name: "调整最高等级上限后匹配新的最大风险分数",
+			modify: func(p *RiskTierPolicy) {
+				p.Tiers[len(p.Tiers)-1].MaxScore = 2000
+			},
+			maxScore: 2000,
+		},
+		{name: "最高等级上限与最大风险分数不一致", modify: func(p *RiskTierPolicy) {}, maxScore: 2000, wantErr: true},
+		{name: "没有等级", modify: func(p *RiskTierPolicy) { p.Tiers = nil }, maxScore: MaxRiskScore, wantErr: true},
+		{name: "等级缺少标识", modify: func(p *RiskTierPolicy) { p.Tiers[1].Name = "" }, maxScore: MaxRiskScore, wantErr: true},
+		{name: "等级标识重复", modify: func(p *RiskTierPolicy) { p.Tiers[1].Name = TierNormal }, maxScore: MaxRiskScore, wantErr: true},
+		{name: "分数区间为空", modify: func(p *RiskTierPolicy) { p.Tiers[0].MaxScore = 0; p.Tiers[1].MinScore = 0 }, maxScore: MaxRiskScore, wantErr: true},
+		{name: "最低等级不从0开始", modify: func(p *RiskTierPolicy) { p.Tiers[0].MinScore = 0.5 }, maxScore: MaxRiskScore, wantErr: true},
+		{name: "分数区间不连续", modify: func(p *RiskTierPolicy) { p.Tiers[2].MinScore = 250 }, maxScore: MaxRiskScore, wantErr: true},
+		{name: "设备状态无效", modify: func(p *RiskTierPolicy) { p.Tiers[3].DeviceStatus = StatusInactive }, maxScore: MaxRiskScore, wantErr: true},
+		{name: "未知的响应措施", modify: func(p *RiskTierPolicy) { p.Tiers[0].Measures = []string{"unknown_measure"} }, maxScore: MaxRiskScore, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := testPolicy()
+			tt.modify(&policy)
+			err := policy.Validate(tt.maxScore)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
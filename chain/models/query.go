@@ -0,0 +1,13 @@
+package models
+
+// DevicePage 设备分页查询结果
+type DevicePage struct {
+	Devices  []*DeviceInfo `json:"devices"`                                 // 本页设备
+	Bookmark string        `json:"bookmark,omitempty" metadata:",optional"` // 下一页书签，没有更多记录时为空
+}
+
+// 分页查询常量
+const (
+	DefaultPageSize = 50  // 默认每页记录数
+	MaxPageSize     = 500 // 每页最大记录数
+)
@@ -0,0 +1,24 @@
+package models
+
+// DeviceRegistration 批量注册中的一台设备
+type DeviceRegistration struct {
+	Name      string `json:"name"`      // 设备名称
+	Model     string `json:"model"`     // 设备型号
+	Vendor    string `json:"vendor"`    // 设备供应商
+	DeviceID  string `json:"deviceId"`  // 设备ID（出厂序列号）
+	PublicKey string `json:"publicKey"` // 设备公钥（PEM）
+}
+
+// DeviceRegistrationResult 批量注册或校验中一台设备的结果
+type DeviceRegistrationResult struct {
+	Index       int    `json:"index"`                                      // 设备在批次中的序号，从1开始
+	Vendor      string `json:"vendor"`                                     // 设备供应商
+	Model       string `json:"model"`                                      // 设备型号
+	DeviceID    string `json:"deviceId"`                                   // 设备ID
+	DID         string `json:"did,omitempty" metadata:",optional"`         // 注册成功时为新设备的DID
+	ExistingDID string `json:"existingDid,omitempty" metadata:",optional"` // 设备已注册时为已有的DID
+	Error       string `json:"error,omitempty" metadata:",optional"`       // 校验不通过的原因
+}
+
+// MaxRegistrationBatchSize 一次批量注册的最大设备数量
+const MaxRegistrationBatchSize = 500
@@ -0,0 +1,111 @@
+package models
+
+// 风险响应措施代码，执行工具根据代码直接下发处置动作
+const (
+	MeasureDefaultLogging     = "DEFAULT_LOGGING"     // 默认日志记录
+	MeasureSessionDIDVerify   = "SESSION_DID_VERIFY"  // 会话建立时验证DID
+	MeasureFullPCAP           = "FULL_PCAP"           // 全数据包捕获
+	MeasureBehaviorAudit      = "BEHAVIOR_AUDIT"      // 详细记录蜜点操作行为
+	MeasureRateLimit          = "RATE_LIMIT"          // 速率限制
+	MeasureExposeLure         = "EXPOSE_LURE"         // 暴露高价值诱饵
+	MeasureDNSSinkhole        = "DNS_SINKHOLE"        // 域名解析指向伪造服务
+	MeasureIsolateVLAN        = "ISOLATE_VLAN"        // 流量重定向至隔离蜜网
+	MeasurePlantHoneytoken    = "PLANT_HONEYTOKEN"    // 植入伪造凭证等蜜标
+	MeasureDropConnections    = "DROP_CONNECTIONS"    // 中断已建立的网络连接
+	MeasureLockAccount        = "LOCK_ACCOUNT"        // 锁定设备账户
+	MeasureSnapshotHoneypoint = "SNAPSHOT_HONEYPOINT" // 蜜点快照存证
+	MeasureManualForensics    = "MANUAL_FORENSICS"    // 人工介入和深度溯源
+)
+
+// LocalizedText 多语言文本
+type LocalizedText struct {
+	ZH string `json:"zh"` // 中文
+	EN string `json:"en"` // 英文
+}
+
+// ResponseMeasure 风险响应措施
+type ResponseMeasure struct {
+	Code        string        `json:"code"`        // 措施代码
+	Description LocalizedText `json:"description"` // 措施描述
+}
+
+// RiskResponse 设备风险响应策略
+type RiskResponse struct {
+	DID           string            `json:"did"`           // 设备DID
+	RiskScore     float64           `json:"riskScore"`     // 当前风险评分，保留两位小数
+	Tier          string            `json:"tier"`          // 风险等级标识
+	RiskLevel     string            `json:"riskLevel"`     // 风险等级名称
+	AllowConnect  bool              `json:"allowConnect"`  // 是否允许设备连接
+	Strategy      string            `json:"strategy"`      // 响应策略
+	Measures      []ResponseMeasure `json:"measures"`      // 具体响应措施
+	PolicyVersion int               `json:"policyVersion"` // 生成响应时使用的风险等级策略版本
+}
+
+// MeasureCatalog 风险响应措施代码及其描述
+var MeasureCatalog = map[string]LocalizedText{
+	MeasureDefaultLogging: {
+		ZH: "维持默认的日志记录",
+		EN: "Keep default logging",
+	},
+	MeasureSessionDIDVerify: {
+		ZH: "仅在会话建立或关键操作时通过智能合约验证其DID身份的有效性",
+		EN: "Verify the DID via smart contract only on session setup or critical operations",
+	},
+	MeasureFullPCAP: {
+		ZH: "针对该设备的源IP，自动启用全数据包捕获",
+		EN: "Enable full packet capture for the device's source IP",
+	},
+	MeasureBehaviorAudit: {
+		ZH: "详细记录其在蜜点中的所有操作行为",
+		EN: "Record every operation the device performs on honeypoints",
+	},
+	MeasureRateLimit: {
+		ZH: "实施轻微的服务质量策略，对其扫描或连接行为进行速率限制",
+		EN: "Apply a light QoS policy that rate-limits scanning and connections",
+	},
+	MeasureExposeLure: {
+		ZH: "在其当前互动的蜜点环境中，主动暴露更具吸引力的诱饵",
+		EN: "Expose more attractive lures in the honeypoint the device is interacting with",
+	},
+	MeasureDNSSinkhole: {
+		ZH: "将该设备的所有内部域名解析请求指向对应的伪造服务",
+		EN: "Resolve all internal DNS queries from the device to decoy services",
+	},
+	MeasureIsolateVLAN: {
+		ZH: "在网络层将其流量重定向至一个隔离的蜜网环境中",
+		EN: "Redirect the device's traffic into an isolated honeynet at the network layer",
+	},
+	MeasurePlantHoneytoken: {
+		ZH: "在其所处的蜜网环境中，动态植入伪造的凭证文件、数据库连接字符串、API密钥等蜜点",
+		EN: "Plant fake credentials, database connection strings and API keys in the honeynet",
+	},
+	MeasureDropConnections: {
+		ZH: "立即强制中断该设备所有已建立的网络连接",
+		EN: "Immediately drop all established connections of the device",
+	},
+	MeasureLockAccount: {
+		ZH: "临时锁定设备账户",
+		EN: "Temporarily lock the device account",
+	},
+	MeasureSnapshotHoneypoint: {
+		ZH: "对其交互过的蜜点进行快照存证",
+		EN: "Snapshot the honeypoints the device interacted with as evidence",
+	},
+	MeasureManualForensics: {
+		ZH: "进入人工介入和深度溯源",
+		EN: "Escalate to manual investigation and in-depth forensics",
+	},
+}
+
+// ResolveMeasures 将措施代码转换为带描述的响应措施，未知代码以代码本身作为描述
+func ResolveMeasures(codes []string) []ResponseMeasure {
+	measures := make([]ResponseMeasure, 0, len(codes))
+	for _, code := range codes {
+		description, ok := MeasureCatalog[code]
+		if !ok {
+			description = LocalizedText{ZH: code, EN: code}
+		}
+		measures = append(measures, ResponseMeasure{Code: code, Description: description})
+	}
+	return measures
+}
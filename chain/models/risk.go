@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Tittifer/IEEE/chain/scoring"
+)
+
+// RiskRule 风险行为规则结构体，链上按行为类型和版本号存储
+type RiskRule struct {
+	DocType      string    `json:"docType"`                                  // 文档类型，用于富查询区分记录类型
+	BehaviorType string    `json:"behaviorType"`                             // 行为类型标识符
+	Category     string    `json:"category"`                                 // 行为类别
+	Score        float64   `json:"score"`                                    // 基础风险分数 S_{base}
+	Weight       float64   `json:"weight"`                                   // 行为权重 W
+	Description  string    `json:"description"`                              // 描述
+	Version      int       `json:"version"`                                  // 规则版本号，每次修改递增
+	Status       string    `json:"status"`                                   // 规则状态: active, deprecated
+	UpdatedBy    string    `json:"updatedBy,omitempty" metadata:",optional"` // 最后修改者身份
+	UpdatedAt    time.Time `json:"updatedAt"`                                // 最后修改时间
+	TxID         string    `json:"txId,omitempty" metadata:",optional"`      // 修改规则的交易ID
+}
+
+// ScoringRule 返回风险评分计算所需的规则字段
+func (r *RiskRule) ScoringRule() scoring.Rule {
+	return scoring.Rule{
+		Category: r.Category,
+		Score:    r.Score,
+		Weight:   r.Weight,
+	}
+}
+
+// 风险规则状态常量
+const (
+	RuleStatusActive     = "active"     // 规则生效
+	RuleStatusDeprecated = "deprecated" // 规则已废弃
+)
+
+// RiskRuleObjectType 风险规则复合键对象类型，键格式 riskRule~behaviorType~version
+const RiskRuleObjectType = "riskRule"
+
+// DefaultRiskRules 大纲中定义的风险评估规则，用于初始化链上规则库
+var DefaultRiskRules = []RiskRule{
+	// 侦察阶段
+	{BehaviorType: "visit_trap_ip", Category: "Recon.NetworkScan", Score: 10.0, Weight: 0.2, Description: "访问陷阱IP"},
+	{BehaviorType: "connect_bait_wifi", Category: "Recon.WirelessScan", Score: 15.0, Weight: 0.2, Description: "连接诱饵WiFi"},
+	{BehaviorType: "port_scan_honeypot", Category: "Recon.PortScan", Score: 20.0, Weight: 0.2, Description: "对蜜点进行端口扫描"},
+
+	// 初始接入阶段
+	{BehaviorType: "weak_password_login", Category: "InitialAccess.WeakCred", Score: 40.0, Weight: 0.5, Description: "尝试弱口令登录"},
+	{BehaviorType: "exploit_known_vulnerability", Category: "InitialAccess.Exploit", Score: 80.0, Weight: 0.8, Description: "利用已知漏洞攻击"},
+
+	// 执行阶段
+	{BehaviorType: "execute_info_gathering", Category: "Execution.Discovery", Score: 30.0, Weight: 0.4, Description: "执行信息收集命令"},
+	{BehaviorType: "upload_script", Category: "Execution.FileUpload", Score: 100.0, Weight: 1.0, Description: "上传脚本文件"},
+	{BehaviorType: "upload_known_backdoor", Category: "Execution.Malware", Score: 1000.0, Weight: 0.0, Description: "上传已知后门程序"},
+	{BehaviorType: "modify_config_file", Category: "Execution.Tamper", Score: 150.0, Weight: 1.2, Description: "修改系统配置文件"},
+
+	// 持久化阶段
+	{BehaviorType: "create_scheduled_task", Category: "Persistence.CronJob", Score: 120.0, Weight: 1.2, Description: "创建定时任务"},
+	{BehaviorType: "modify_system_service", Category: "Persistence.ServiceMod", Score: 150.0, Weight: 1.2, Description: "修改系统服务"},
+	{BehaviorType: "firmware_tamper", Category: "Persistence.FirmwareTamper", Score: 500.0, Weight: 1.5, Description: "固件哈希与供应商白名单中同一版本的哈希不一致"},
+
+	// 防御规避阶段
+	{BehaviorType: "clear_stop_log_service", Category: "DefenseEvasion.ClearLog", Score: 100.0, Weight: 0.8, Description: "清空或停止日志服务"},
+	{BehaviorType: "use_rootkit", Category: "DefenseEvasion.Rootkit", Score: 1000.0, Weight: 0.0, Description: "使用Rootkit技术"},
+
+	// 凭证访问阶段
+	{BehaviorType: "read_fake_credential", Category: "CredentialAccess.File", Score: 200.0, Weight: 1.5, Description: "读取伪造的凭证文件"},
+	{BehaviorType: "attempt_memory_credential", Category: "CredentialAccess.Memory", Score: 250.0, Weight: 1.5, Description: "尝试内存抓取凭证"},
+
+	// 横向移动阶段
+	{BehaviorType: "login_with_stolen_credential", Category: "LateralMovement.StolenCred", Score: 1000.0, Weight: 0.0, Description: "使用窃取的凭证登录"},
+
+	// 数据收集阶段
+	{BehaviorType: "compress_sensitive_files", Category: "Collection.Archive", Score: 180.0, Weight: 1.0, Description: "打包压缩敏感文件"},
+
+	// 渗出阶段
+	{BehaviorType: "transfer_data_outside", Category: "Exfiltration.DataTransfer", Score: 300.0, Weight: 1.8, Description: "向外网传输数据"},
+	{BehaviorType: "trigger_bait_file_callback", Category: "Exfiltration.CanaryToken", Score: 1000.0, Weight: 0.0, Description: "触发诱饵文件回调"},
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// RiskEvent 风险事件记录，每次上报的风险行为单独存储，只追加不修改
+// 键格式 riskEvent~did~txTimestamp~txID，同一设备的记录按交易时间排序
+type RiskEvent struct {
+	DocType               string    `json:"docType"`                                     // 文档类型，用于富查询区分记录类型
+	DID                   string    `json:"did"`                                         // 设备DID
+	TxID                  string    `json:"txId"`                                        // 上报风险行为的交易ID
+	Timestamp             time.Time `json:"timestamp"`                                   // 交易时间
+	BehaviorType          string    `json:"behaviorType"`                                // 行为类型
+	Category              string    `json:"category"`                                    // 行为类别
+	BaseScore             float64   `json:"baseScore"`                                   // 规则基础风险分数 S_{base}
+	Weight                float64   `json:"weight"`                                      // 规则行为权重 W
+	RuleVersion           int       `json:"ruleVersion"`                                 // 计算时使用的规则版本
+	ScoringProfileVersion int       `json:"scoringProfileVersion"`                       // 计算时使用的风险评分配置版本
+	TierPolicyVersion     int       `json:"tierPolicyVersion"`                           // 划分风险等级时使用的风险等级策略版本
+	ScoreBefore           float64   `json:"scoreBefore"`                                 // 上报前的风险评分
+	ScoreAfter            float64   `json:"scoreAfter"`                                  // 上报后的风险评分
+	AttackIndexBefore     float64   `json:"attackIndexBefore"`                           // 上报前的攻击画像指数
+	AttackIndexAfter      float64   `json:"attackIndexAfter"`                            // 上报后的攻击画像指数
+	Tier                  string    `json:"tier"`                                        // 上报后设备所处的风险等级
+	HoneypointID          string    `json:"honeypointId"`                                // 捕获该行为的蜜点ID
+	Reporter              string    `json:"reporter"`                                    // 上报者身份（MSP ID/证书CN）
+	EvidenceHash          string    `json:"evidenceHash,omitempty" metadata:",optional"` // 风险行为证据哈希
+}
+
+// RiskEventPage 风险事件分页查询结果
+type RiskEventPage struct {
+	Events   []*RiskEvent `json:"events"`                                  // 本页风险事件，按时间升序
+	Bookmark string       `json:"bookmark,omitempty" metadata:",optional"` // 下一页书签，没有更多记录时为空
+}
+
+// RiskEventObjectType 风险事件复合键的对象类型
+const RiskEventObjectType = "riskEvent"
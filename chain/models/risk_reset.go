@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// RiskReset 风险评分重置记录，每次重置单独存储，只追加不修改，同时作为 RiskScoreReset 事件的数据
+// 键格式 riskReset~did~txTimestamp~txID，同一设备的记录按交易时间排序
+type RiskReset struct {
+	DocType           string    `json:"docType"`           // 文档类型，用于富查询区分记录类型
+	DID               string    `json:"did"`               // 设备DID
+	Name              string    `json:"name"`              // 设备名称
+	TxID              string    `json:"txId"`              // 重置交易ID
+	Timestamp         time.Time `json:"timestamp"`         // 交易时间
+	Mode              string    `json:"mode"`              // 重置方式: full, soft
+	Reason            string    `json:"reason"`            // 重置原因
+	ApprovedBy        string    `json:"approvedBy"`        // 批准重置的身份（MSP ID/证书CN），即提交批准交易的管理员，不能是执行重置的身份本身
+	ApprovalTxID      string    `json:"approvalTxId"`      // 批准重置的交易ID
+	ResetBy           string    `json:"resetBy"`           // 执行重置的身份（MSP ID/证书CN）
+	ScoreBefore       float64   `json:"scoreBefore"`       // 重置前的风险评分
+	AttackIndexBefore float64   `json:"attackIndexBefore"` // 重置前的攻击画像指数
+	AttackIndexAfter  float64   `json:"attackIndexAfter"`  // 重置后的攻击画像指数，软重置时保持不变
+	AttackProfile     []string  `json:"attackProfile"`     // 重置前的攻击画像
+	Status            string    `json:"status"`            // 重置后的设备状态
+	TierPolicyVersion int       `json:"tierPolicyVersion"` // 恢复设备状态时使用的风险等级策略版本
+}
+
+// 风险评分重置方式常量
+const (
+	RiskResetModeFull = "full" // 完全重置：清零风险评分、攻击画像指数和攻击画像
+	RiskResetModeSoft = "soft" // 软重置：只清零风险评分，保留攻击画像指数和攻击画像作为长期信誉
+)
+
+// RiskResetObjectType 风险评分重置记录复合键的对象类型
+const RiskResetObjectType = "riskReset"
+
+// RiskResetApproval 风险评分重置批准记录，由批准人通过 ApproveRiskReset 提交，ResetDeviceRiskScore 执行重置时消费
+// 键格式 riskResetApproval~did，每个设备最多一条待执行的批准，重复批准覆盖之前的记录
+type RiskResetApproval struct {
+	DocType    string    `json:"docType"`    // 文档类型，用于富查询区分记录类型
+	DID        string    `json:"did"`        // 设备DID
+	Reason     string    `json:"reason"`     // 批准的重置原因，执行重置时须一致
+	ApprovedBy string    `json:"approvedBy"` // 批准人身份（MSP ID/证书CN）
+	TxID       string    `json:"txId"`       // 批准交易ID
+	ApprovedAt time.Time `json:"approvedAt"` // 批准交易时间
+}
+
+// RiskResetApprovalObjectType 风险评分重置批准记录复合键的对象类型
+const RiskResetApprovalObjectType = "riskResetApproval"
+
+// RiskResetApprovalTTL 重置批准的有效期，超过有效期未执行的批准须重新提交
+const RiskResetApprovalTTL = 24 * time.Hour
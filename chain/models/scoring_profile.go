@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tittifer/IEEE/chain/scoring"
+)
+
+// ScoringProfile 风险评分配置，定义评分模型参数，每次修改生成新版本，历史版本保留在账本中
+// 风险事件记录计算时使用的配置版本和风险等级策略版本，调整参数后历史评分仍可按当时的配置复算
+type ScoringProfile struct {
+	DocType           string         `json:"docType"`                                    // 文档类型，用于富查询区分记录类型
+	Version           int            `json:"version"`                                    // 配置版本号，每次修改递增，0 为内置默认配置
+	Params            scoring.Params `json:"params"`                                     // 模型参数: S_{max}, δ, α, λ 和一票否决分数
+	TierPolicyVersion int            `json:"tierPolicyVersion"`                          // 发布时生效的风险等级策略版本，等级划分须覆盖到 S_{max}
+	Description       string         `json:"description,omitempty" metadata:",optional"` // 修改说明
+	UpdatedBy         string         `json:"updatedBy,omitempty" metadata:",optional"`   // 修改者身份
+	UpdatedAt         time.Time      `json:"updatedAt"`                                  // 修改时间
+	TxID              string         `json:"txId,omitempty" metadata:",optional"`        // 修改配置的交易ID
+}
+
+// 风险评分配置复合键对象类型
+const (
+	ScoringProfileObjectType        = "scoringProfile"        // 当前生效的配置
+	ScoringProfileVersionObjectType = "scoringProfileVersion" // 按版本号保存的全部配置
+)
+
+// DefaultScoringProfile 未设置配置时使用的内置默认配置
+var DefaultScoringProfile = ScoringProfile{
+	Version: 0,
+	Params:  scoring.DefaultParams(),
+}
+
+// Validate 验证风险评分配置
+func (p *ScoringProfile) Validate() error {
+	if err := p.Params.Validate(); err != nil {
+		return err
+	}
+	if p.Params.VetoScore > p.Params.MaxScore {
+		return fmt.Errorf("一票否决分数不能大于最大风险分数")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceSession 设备连接会话，以复合键 session~did~sessionID 存储
+type DeviceSession struct {
+	DocType        string    `json:"docType"`                                    // 文档类型，用于富查询区分记录类型
+	SessionID      string    `json:"sessionId"`                                  // 会话ID，取自建立连接的交易ID
+	DID            string    `json:"did"`                                        // 设备DID
+	Status         string    `json:"status"`                                     // 会话状态: open, closed
+	Tier           string    `json:"tier"`                                       // 建立连接时设备所处的风险等级
+	RiskScore      float64   `json:"riskScore"`                                  // 建立连接时设备的风险评分
+	ConnectedAt    time.Time `json:"connectedAt"`                                // 连接时间
+	DisconnectedAt time.Time `json:"disconnectedAt"`                             // 断开时间，会话未关闭时为零值
+	CloseReason    string    `json:"closeReason,omitempty" metadata:",optional"` // 会话关闭原因
+}
+
+// 会话状态常量
+const (
+	SessionStatusOpen   = "open"   // 会话进行中
+	SessionStatusClosed = "closed" // 会话已关闭
+)
+
+// SessionObjectType 会话记录复合键的对象类型
+const SessionObjectType = "session"
@@ -0,0 +1,34 @@
+package models
+
+// DeviceExposure 设备及其当前风险等级，用于供应链漏洞影响面排查
+type DeviceExposure struct {
+	DID              string  `json:"did"`                                             // 设备DID
+	Name             string  `json:"name"`                                            // 设备名称
+	Vendor           string  `json:"vendor"`                                          // 设备供应商
+	Model            string  `json:"model"`                                           // 设备型号
+	Status           string  `json:"status"`                                          // 设备状态
+	ConnectionStatus string  `json:"connectionStatus,omitempty" metadata:",optional"` // 连接状态
+	RiskScore        float64 `json:"riskScore"`                                       // 当前风险评分
+	Tier             string  `json:"tier"`                                            // 当前风险等级标识
+	RiskLevel        string  `json:"riskLevel"`                                       // 当前风险等级名称
+	AllowConnect     bool    `json:"allowConnect"`                                    // 当前等级是否允许连接
+}
+
+// VendorRiskSummary 按供应商和型号汇总的设备风险
+type VendorRiskSummary struct {
+	Vendor        string  `json:"vendor"`        // 设备供应商
+	Model         string  `json:"model"`         // 设备型号
+	DeviceCount   int     `json:"deviceCount"`   // 设备数量
+	MeanRiskScore float64 `json:"meanRiskScore"` // 平均风险评分
+	MaxRiskScore  float64 `json:"maxRiskScore"`  // 最高风险评分
+	RiskyCount    int     `json:"riskyCount"`    // 处于风险状态（risky）的设备数量
+}
+
+// VendorIndexRebuild 分批补建供应商-型号索引的结果
+type VendorIndexRebuild struct {
+	Rebuilt  int    `json:"rebuilt"`                                 // 本批补建索引的设备数量
+	Bookmark string `json:"bookmark,omitempty" metadata:",optional"` // 下一批的书签，全部补建完成时为空
+}
+
+// VendorModelIndex 供应商-型号索引复合键对象类型，键格式 vendor~model~did，值为单个 0x00 字节（Fabric 中写入空值等同于删除键）
+const VendorModelIndex = "vendor~model~did"
@@ -0,0 +1,134 @@
+// Package scoring 实现设备风险评分模型，不读取系统时钟，时间由调用方传入
+// 链码以交易时间戳调用，riskctl 以回放事件的时间调用，两者的计算结果一致
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// 默认模型参数
+const (
+	DefaultMaxScore  = 1000.0 // 最大风险分数 S_{max}
+	DefaultDelta     = 2.0    // 影响低分时降温速度参数 δ，沿用蜜点后台原评分实现的取值
+	DefaultAlpha     = 0.05   // 影响高分时降温速度参数 α，沿用蜜点后台原评分实现的取值
+	DefaultLambda    = 0.01   // 攻击画像指数衰减系数 λ
+	DefaultVetoScore = 1000.0 // 一票否决分数，基础分数达到该值的行为直接判定为 S_{max}
+)
+
+// Params 风险评分模型参数
+type Params struct {
+	MaxScore  float64 `json:"maxScore"`  // 最大风险分数 S_{max}
+	Delta     float64 `json:"delta"`     // 影响低分时降温速度参数 δ
+	Alpha     float64 `json:"alpha"`     // 影响高分时降温速度参数 α
+	Lambda    float64 `json:"lambda"`    // 攻击画像指数衰减系数 λ
+	VetoScore float64 `json:"vetoScore"` // 一票否决分数，0 表示不启用
+}
+
+// DefaultParams 返回默认模型参数
+func DefaultParams() Params {
+	return Params{
+		MaxScore:  DefaultMaxScore,
+		Delta:     DefaultDelta,
+		Alpha:     DefaultAlpha,
+		Lambda:    DefaultLambda,
+		VetoScore: DefaultVetoScore,
+	}
+}
+
+// Validate 检查模型参数
+func (p Params) Validate() error {
+	if p.MaxScore <= 0 {
+		return fmt.Errorf("最大风险分数必须大于0")
+	}
+	if p.Delta < 0 || p.Alpha < 0 || p.Lambda < 0 {
+		return fmt.Errorf("降温参数和衰减系数不能为负数")
+	}
+	if p.VetoScore < 0 {
+		return fmt.Errorf("一票否决分数不能为负数")
+	}
+	return nil
+}
+
+// Rule 计算评分所需的风险规则字段
+type Rule struct {
+	Category string  // 行为类别
+	Score    float64 // 基础风险分数 S_{base}
+	Weight   float64 // 行为权重 W
+}
+
+// State 设备的风险状态
+type State struct {
+	RiskScore     float64   // 历史风险分数 S_{t-1}
+	AttackIndex   float64   // 攻击画像指数 I
+	AttackProfile []string  // 已触发过的不重复的行为类别
+	LastEventTime time.Time // 上次风险事件时间 t_{last}
+	LastDecayTime time.Time // 上次衰减攻击画像指数的时间
+}
+
+// Apply 计算设备在 now 发生一次风险行为后的状态，不修改 state
+func (p Params) Apply(state State, rule Rule, now time.Time) State {
+	// 复制当前攻击画像
+	attackProfile := make([]string, len(state.AttackProfile))
+	copy(attackProfile, state.AttackProfile)
+
+	// 更新攻击画像指数 (I)，类别首次出现（意图升级）时累加权重并记录类别
+	attackIndex := state.AttackIndex
+	if !profileContains(attackProfile, rule.Category) {
+		attackIndex += rule.Weight
+		attackProfile = append(attackProfile, rule.Category)
+	}
+
+	// 对历史分数进行降温，Δt 以天为单位
+	deltaT := days(state.LastEventTime, now)
+	coolingFactor := (p.Delta * deltaT) / (1 + p.Alpha*state.RiskScore)
+	cooledPreviousScore := math.Max(0.0, state.RiskScore-coolingFactor)
+
+	// 一票否决：触及最高风险红线的行为直接判定为最高风险
+	score := math.Min(p.MaxScore, rule.Score*(1+attackIndex)+cooledPreviousScore)
+	if p.VetoScore > 0 && rule.Score >= p.VetoScore {
+		score = p.MaxScore
+	}
+
+	return State{
+		RiskScore:     score,
+		AttackIndex:   attackIndex,
+		AttackProfile: attackProfile,
+		LastEventTime: now,
+		LastDecayTime: state.LastDecayTime,
+	}
+}
+
+// Decay 计算攻击画像指数在 now 的慢速衰减 I_{new} = I_{old} * e^{-λ*Δt}，不修改 state
+// Δt 从上次事件时间和上次衰减时间中较晚的一个算起，重复衰减不会叠加；衰减不属于风险事件，不修改上次事件时间
+func (p Params) Decay(state State, now time.Time) State {
+	since := state.LastEventTime
+	if state.LastDecayTime.After(since) {
+		since = state.LastDecayTime
+	}
+
+	state.AttackIndex = state.AttackIndex * math.Exp(-p.Lambda*days(since, now))
+	state.LastDecayTime = now
+	return state
+}
+
+// profileContains 检查攻击画像中是否已有该类别，兼容完全匹配和主类别匹配（点号前的部分）
+func profileContains(attackProfile []string, category string) bool {
+	mainCategory := category
+	if dotIndex := strings.Index(category, "."); dotIndex != -1 {
+		mainCategory = category[:dotIndex]
+	}
+	for _, c := range attackProfile {
+		if c == category || c == mainCategory || strings.HasPrefix(c, mainCategory+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// days 计算两个时间之间的天数，from 晚于 to 时为0
+func days(from, to time.Time) float64 {
+	return math.Max(0.0, to.Sub(from).Hours()/24.0)
+}
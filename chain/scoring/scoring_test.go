@@ -0,0 +1,238 @@
+package scoring
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// day 返回 t0 之后第 n 天的时间
+func day(n float64) time.Time {
+	return t0.Add(time.Duration(n * 24 * float64(time.Hour)))
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestApply(t *testing.T) {
+	portScan := Rule{Category: "Recon.PortScan", Score: 20, Weight: 0.2}
+	networkScan := Rule{Category: "Recon.NetworkScan", Score: 10, Weight: 0.2}
+	weakCred := Rule{Category: "InitialAccess.WeakCred", Score: 40, Weight: 0.5}
+	rootkit := Rule{Category: "DefenseEvasion.Rootkit", Score: 1000, Weight: 0}
+
+	tests := []struct {
+		name        string
+		params      Params
+		state       State
+		rule        Rule
+		now         time.Time
+		wantScore   float64
+		wantIndex   float64
+		wantProfile []string
+	}{
+		{
+			name:        "首个类别累加权重",
+			params:      DefaultParams(),
+			state:       State{LastEventTime: t0},
+			rule:        portScan,
+			now:         t0,
+			wantScore:   24,
+			wantIndex:   0.2,
+			wantProfile: []string{"Recon.PortScan"},
+		},
+		{
+			name:        "同一主类别不重复累加权重",
+			params:      DefaultParams(),
+			state:       State{RiskScore: 24, AttackIndex: 0.2, AttackProfile: []string{"Recon.PortScan"}, LastEventTime: t0},
+			rule:        networkScan,
+			now:         t0,
+			wantScore:   24 + 10*1.2,
+			wantIndex:   0.2,
+			wantProfile: []string{"Recon.PortScan"},
+		},
+		{
+			name:        "新主类别累加权重",
+			params:      DefaultParams(),
+			state:       State{RiskScore: 24, AttackIndex: 0.2, AttackProfile: []string{"Recon.PortScan"}, LastEventTime: t0},
+			rule:        weakCred,
+			now:         t0,
+			wantScore:   24 + 40*1.7,
+			wantIndex:   0.7,
+			wantProfile: []string{"Recon.PortScan", "InitialAccess.WeakCred"},
+		},
+		{
+			name:        "历史分数按间隔天数降温",
+			params:      Params{MaxScore: 1000, Delta: 2, Alpha: 0.05},
+			state:       State{RiskScore: 100, LastEventTime: t0},
+			rule:        portScan,
+			now:         day(1),
+			wantScore:   24 + 100 - 2.0/(1+0.05*100),
+			wantIndex:   0.2,
+			wantProfile: []string{"Recon.PortScan"},
+		},
+		{
+			name:        "降温后的历史分数不小于0",
+			params:      Params{MaxScore: 1000, Delta: 2, Alpha: 0.05},
+			state:       State{RiskScore: 1, LastEventTime: t0},
+			rule:        portScan,
+			now:         day(100),
+			wantScore:   24,
+			wantIndex:   0.2,
+			wantProfile: []string{"Recon.PortScan"},
+		},
+		{
+			name:        "时间早于上次事件时不降温",
+			params:      Params{MaxScore: 1000, Delta: 2, Alpha: 0.05},
+			state:       State{RiskScore: 100, LastEventTime: day(1)},
+			rule:        portScan,
+			now:         t0,
+			wantScore:   124,
+			wantIndex:   0.2,
+			wantProfile: []string{"Recon.PortScan"},
+		},
+		{
+			name:        "不超过最大风险分数",
+			params:      DefaultParams(),
+			state:       State{RiskScore: 990, LastEventTime: t0},
+			rule:        weakCred,
+			now:         t0,
+			wantScore:   1000,
+			wantIndex:   0.5,
+			wantProfile: []string{"InitialAccess.WeakCred"},
+		},
+		{
+			name:        "一票否决直接判定为最大风险分数",
+			params:      Params{MaxScore: 2000, VetoScore: 1000},
+			state:       State{LastEventTime: t0},
+			rule:        rootkit,
+			now:         t0,
+			wantScore:   2000,
+			wantIndex:   0,
+			wantProfile: []string{"DefenseEvasion.Rootkit"},
+		},
+		{
+			name:        "未启用一票否决时按公式计算",
+			params:      Params{MaxScore: 2000},
+			state:       State{LastEventTime: t0},
+			rule:        rootkit,
+			now:         t0,
+			wantScore:   1000,
+			wantIndex:   0,
+			wantProfile: []string{"DefenseEvasion.Rootkit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.params.Apply(tt.state, tt.rule, tt.now)
+			if !approxEqual(got.RiskScore, tt.wantScore) {
+				t.Errorf("RiskScore = %v, want %v", got.RiskScore, tt.wantScore)
+			}
+			if !approxEqual(got.AttackIndex, tt.wantIndex) {
+				t.Errorf("AttackIndex = %v, want %v", got.AttackIndex, tt.wantIndex)
+			}
+			if !reflect.DeepEqual(got.AttackProfile, tt.wantProfile) {
+				t.Errorf("AttackProfile = %v, want %v", got.AttackProfile, tt.wantProfile)
+			}
+			if !got.LastEventTime.Equal(tt.now) {
+				t.Errorf("LastEventTime = %v, want %v", got.LastEventTime, tt.now)
+			}
+		})
+	}
+}
+
+func TestApplyDoesNotModifyState(t *testing.T) {
+	profile := make([]string, 1, 4)
+	profile[0] = "Recon.PortScan"
+	state := State{RiskScore: 24, AttackIndex: 0.2, AttackProfile: profile, LastEventTime: t0}
+
+	DefaultParams().Apply(state, Rule{Category: "InitialAccess.WeakCred", Score: 40, Weight: 0.5}, day(1))
+
+	if state.RiskScore != 24 || state.AttackIndex != 0.2 || !state.LastEventTime.Equal(t0) {
+		t.Errorf("state 被修改: %+v", state)
+	}
+	if got := profile[:cap(profile)][1]; got != "" {
+		t.Errorf("攻击画像的底层数组被修改: %q", got)
+	}
+}
+
+func TestDecay(t *testing.T) {
+	params := Params{MaxScore: 1000, Lambda: 0.01}
+	state := State{RiskScore: 50, AttackIndex: 2, AttackProfile: []string{"Recon.PortScan"}, LastEventTime: t0}
+	want := 2 * math.Exp(-0.01*10)
+
+	tests := []struct {
+		name  string
+		times []time.Time
+	}{
+		{name: "一次衰减", times: []time.Time{day(10)}},
+		{name: "分两次衰减", times: []time.Time{day(4), day(10)}},
+		{name: "每天衰减", times: []time.Time{day(1), day(2), day(3), day(4), day(5), day(6), day(7), day(8), day(9), day(10)}},
+		{name: "同一时间重复衰减", times: []time.Time{day(10), day(10), day(10)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := state
+			for _, now := range tt.times {
+				got = params.Decay(got, now)
+			}
+			if !approxEqual(got.AttackIndex, want) {
+				t.Errorf("AttackIndex = %v, want %v", got.AttackIndex, want)
+			}
+			if !got.LastEventTime.Equal(t0) {
+				t.Errorf("LastEventTime = %v, 衰减不应修改上次事件时间", got.LastEventTime)
+			}
+			if !got.LastDecayTime.Equal(day(10)) {
+				t.Errorf("LastDecayTime = %v, want %v", got.LastDecayTime, day(10))
+			}
+			if got.RiskScore != 50 {
+				t.Errorf("RiskScore = %v, 衰减不应修改风险评分", got.RiskScore)
+			}
+		})
+	}
+}
+
+func TestDecayAfterNewEvent(t *testing.T) {
+	// 衰减之后发生新事件，下一次衰减从新事件的时间算起
+	params := Params{MaxScore: 1000, Lambda: 0.01}
+	state := State{AttackIndex: 1, LastEventTime: t0}
+	state = params.Decay(state, day(5))
+	state.LastEventTime = day(8)
+
+	got := params.Decay(state, day(10))
+	want := math.Exp(-0.01*5) * math.Exp(-0.01*2)
+	if !approxEqual(got.AttackIndex, want) {
+		t.Errorf("AttackIndex = %v, want %v", got.AttackIndex, want)
+	}
+}
+
+func TestParamsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr bool
+	}{
+		{name: "默认参数", params: DefaultParams()},
+		{name: "不启用一票否决", params: Params{MaxScore: 1000}},
+		{name: "最大风险分数为0", params: Params{MaxScore: 0}, wantErr: true},
+		{name: "最大风险分数为负数", params: Params{MaxScore: -1}, wantErr: true},
+		{name: "δ为负数", params: Params{MaxScore: 1000, Delta: -1}, wantErr: true},
+		{name: "α为负数", params: Params{MaxScore: 1000, Alpha: -0.1}, wantErr: true},
+		{name: "λ为负数", params: Params{MaxScore: 1000, Lambda: -0.01}, wantErr: true},
+		{name: "一票否决分数为负数", params: Params{MaxScore: 1000, VetoScore: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// HashCredential 计算不含签名的凭证SHA256哈希，签发者对该哈希签名
+func HashCredential(vc *models.VerifiableCredential) ([]byte, error) {
+	unsigned := *vc
+	unsigned.Proof = nil
+
+	vcJSON, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("凭证序列化失败: %v", err)
+	}
+
+	hash := sha256.Sum256(vcJSON)
+	return hash[:], nil
+}
+
+// PublicKeyToPEM 将证书公钥编码为PEM格式
+func PublicKeyToPEM(cert *x509.Certificate) (string, error) {
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("公钥编码失败: %v", err)
+	}
+
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})
+	return string(publicKeyPEM), nil
+}
+
+// PublicKeyFingerprint 计算PEM公钥的指纹，用作签名验证方法标识
+func PublicKeyFingerprint(publicKeyPEM string) string {
+	hash := sha256.Sum256([]byte(publicKeyPEM))
+	return hex.EncodeToString(hash[:8])
+}
+
+// ParseECDSAPublicKey 解析PEM格式的ECDSA公钥
+func ParseECDSAPublicKey(publicKeyPEM string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("无效的PEM公钥")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %v", err)
+	}
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("公钥不是ECDSA公钥")
+	}
+
+	return ecdsaKey, nil
+}
+
+// PublicKeyToJWK 将PEM格式的ECDSA公钥转换为JWK
+func PublicKeyToJWK(publicKeyPEM string) (*models.JSONWebKey, error) {
+	ecdsaKey, err := ParseECDSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	// 坐标按曲线长度补零后编码
+	size := (ecdsaKey.Curve.Params().BitSize + 7) / 8
+	x := make([]byte, size)
+	y := make([]byte, size)
+	ecdsaKey.X.FillBytes(x)
+	ecdsaKey.Y.FillBytes(y)
+
+	return &models.JSONWebKey{
+		Kty: "EC",
+		Crv: ecdsaKey.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(x),
+		Y:   base64.RawURLEncoding.EncodeToString(y),
+	}, nil
+}
+
+// VerifyECDSASignature 使用PEM公钥验证Base64编码的ASN.1 ECDSA签名
+func VerifyECDSASignature(publicKeyPEM string, digest []byte, signature string) error {
+	ecdsaKey, err := ParseECDSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("签名解码失败: %v", err)
+	}
+
+	if !ecdsa.VerifyASN1(ecdsaKey, digest, sig) {
+		return fmt.Errorf("签名验证失败")
+	}
+
+	return nil
+}
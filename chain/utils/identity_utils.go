@@ -2,13 +2,30 @@ package utils
 
 import (
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"regexp"
 	"strings"
+
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// 第二版DID使用的多重格式前缀
+const (
+	multibaseBase58BTC = "z"  // 多重基前缀: base58btc
+	multihashSHA256    = 0x12 // 多重哈希算法代码: sha2-256
 )
 
-// GenerateDID 根据设备信息生成DID
+// base58Alphabet base58btc 字母表（比特币字母表）
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// legacyDIDPattern 第一版DID后缀格式
+var legacyDIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// GenerateDID 根据设备信息生成第一版DID（SHA256截断为16位十六进制）
+// 第一版DID只由公开的设备信息决定，可以被预先计算，仅用于兼容已注册的设备，新设备使用 GenerateDeviceDID
 func GenerateDID(name, model, vendor, deviceID string) string {
 	// 合并设备信息
 	info := fmt.Sprintf("%s:%s:%s:%s", name, model, vendor, deviceID)
@@ -23,12 +40,49 @@ func GenerateDID(name, model, vendor, deviceID string) string {
 	return did
 }
 
-// ValidateDID 验证DID格式
+// GenerateDeviceDID 根据设备公钥和注册随机数生成第二版DID
+// DID后缀为 SHA256(公钥DER ‖ 注册随机数) 的完整多重哈希，以 base58btc 多重基编码（前缀 z），
+// 形如 did:ieee:device:zQm...，总长63个字符，不超过证书CN的64字符上限
+func GenerateDeviceDID(publicKeyPEM string, nonce string) (string, error) {
+	publicKey, err := ParseECDSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("公钥编码失败: %v", err)
+	}
+
+	hash := sha256.Sum256(append(publicKeyDER, nonce...))
+	multihash := append([]byte{multihashSHA256, sha256.Size}, hash[:]...)
+	
+	return models.DeviceDIDPrefix + multibaseBase58BTC + encodeBase58(multihash), nil
+}
+
+// ValidateDID 验证DID格式，同时接受第一版和第二版DID
 func ValidateDID(did string) bool {
-	// DID格式: did:ieee:device:<16位十六进制字符>
-	pattern := `^did:ieee:device:[0-9a-f]{16}$`
-	match, _ := regexp.MatchString(pattern, did)
-	return match
+	return DIDVersion(did) != 0
+}
+
+// DIDVersion 返回DID的格式版本，格式无效时返回0
+// 第一版: did:ieee:device:<16位十六进制字符>
+// 第二版: did:ieee:device:z<base58btc编码的SHA256多重哈希>
+func DIDVersion(did string) int {
+	if !strings.HasPrefix(did, models.DeviceDIDPrefix) {
+		return 0
+	}
+	suffix := strings.TrimPrefix(did, models.DeviceDIDPrefix)
+
+	if legacyDIDPattern.MatchString(suffix) {
+		return 1
+	}
+	if strings.HasPrefix(suffix, multibaseBase58BTC) {
+		multihash, err := decodeBase58(strings.TrimPrefix(suffix, multibaseBase58BTC))
+		if err == nil && len(multihash) == 2+sha256.Size && multihash[0] == multihashSHA256 && multihash[1] == sha256.Size {
+			return 2
+		}
+	}
+	return 0
 }
 
 // CalculateInitialRiskScore 计算初始风险评分
@@ -51,4 +105,49 @@ func ExtractDIDFromSubject(subject string) (string, error) {
 	}
 	
 	return "", fmt.Errorf("无法从证书主题中提取有效的DID: %s", subject)
-}
\ No newline at end of file
+}
+
+// encodeBase58 按 base58btc 编码字节串，前导零字节编码为字符 1
+func encodeBase58(data []byte) string {
+	value := new(big.Int).SetBytes(data)
+	base := big.NewInt(int64(len(base58Alphabet)))
+	mod := new(big.Int)
+
+	var encoded []byte
+	for value.Sign() > 0 {
+		value.DivMod(value, base, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	// 逆序得到高位在前的编码
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+// decodeBase58 解码 base58btc 字符串
+func decodeBase58(encoded string) ([]byte, error) {
+	value := new(big.Int)
+	base := big.NewInt(int64(len(base58Alphabet)))
+	for _, c := range encoded {
+		index := strings.IndexRune(base58Alphabet, c)
+		if index < 0 {
+			return nil, fmt.Errorf("无效的base58字符: %q", c)
+		}
+		value.Mul(value, base)
+		value.Add(value, big.NewInt(int64(index)))
+	}
+
+	leadingZeros := 0
+	for leadingZeros < len(encoded) && encoded[leadingZeros] == base58Alphabet[0] {
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), value.Bytes()...), nil
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/Tittifer/IEEE/chain/models"
+)
+
+// newPublicKeyPEM 生成测试用的PEM公钥
+func newPublicKeyPEM(t *testing.T, publicKey interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("公钥编码失败: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func newECDSAPublicKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成ECDSA密钥失败: %v", err)
+	}
+	return newPublicKeyPEM(t, &key.PublicKey)
+}
+
+func TestGenerateDeviceDID(t *testing.T) {
+	publicKeyPEM := newECDSAPublicKeyPEM(t)
+
+	did, err := GenerateDeviceDID(publicKeyPEM, "nonce-1")
+	if err != nil {
+		t.Fatalf("GenerateDeviceDID() error = %v", err)
+	}
+	if !strings.HasPrefix(did, models.DeviceDIDPrefix+"zQm") {
+		t.Errorf("DID = %s, 应以 %szQm 开头", did, models.DeviceDIDPrefix)
+	}
+	if len(did) != 63 {
+		t.Errorf("len(DID) = %d, want 63", len(did))
+	}
+	if got := DIDVersion(did); got != 2 {
+		t.Errorf("DIDVersion(%s) = %d, want 2", did, got)
+	}
+
+	again, err := GenerateDeviceDID(publicKeyPEM, "nonce-1")
+	if err != nil {
+		t.Fatalf("GenerateDeviceDID() error = %v", err)
+	}
+	if again != did {
+		t.Errorf("相同公钥和随机数生成的DID不一致: %s, %s", did, again)
+	}
+
+	otherNonce, err := GenerateDeviceDID(publicKeyPEM, "nonce-2")
+	if err != nil {
+		t.Fatalf("GenerateDeviceDID() error = %v", err)
+	}
+	if otherNonce == did {
+		t.Errorf("不同随机数生成了相同的DID: %s", did)
+	}
+
+	otherKey, err := GenerateDeviceDID(newECDSAPublicKeyPEM(t), "nonce-1")
+	if err != nil {
+		t.Fatalf("GenerateDeviceDID() error = %v", err)
+	}
+	if otherKey == did {
+		t.Errorf("不同公钥生成了相同的DID: %s", did)
+	}
+}
+
+func TestGenerateDeviceDIDInvalidKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		publicKeyPEM string
+	}{
+		{name: "空公钥", publicKeyPEM: ""},
+		{name: "非PEM格式", publicKeyPEM: "not a pem"},
+		{name: "非ECDSA公钥", publicKeyPEM: newPublicKeyPEM(t, &rsaKey.PublicKey)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if did, err := GenerateDeviceDID(tt.publicKeyPEM, "nonce"); err == nil {
+				t.Errorf("GenerateDeviceDID() = %s, 应返回错误", did)
+			}
+		})
+	}
+}
+
+func TestDIDVersion(t *testing.T) {
+	v2, err := GenerateDeviceDID(newECDSAPublicKeyPEM(t), "nonce")
+	if err != nil {
+		t.Fatalf("GenerateDeviceDID() error = %v", err)
+	}
+	suffix := strings.TrimPrefix(v2, models.DeviceDIDPrefix+"z")
+
+	tests := []struct {
+		name string
+		did  string
+		want int
+	}{
+		{name: "第一版DID", did: GenerateDID("camera", "C1", "acme", "SN001"), want: 1},
+		{name: "第二版DID", did: v2, want: 2},
+		{name: "空字符串", did: "", want: 0},
+		{name: "缺少前缀", did: "did:example:0123456789abcdef", want: 0},
+		{name: "第一版后缀过短", did: models.DeviceDIDPrefix + "0123456789abcde", want: 0},
+		{name: "第一版后缀包含大写字母", did: models.DeviceDIDPrefix + "0123456789ABCDEF", want: 0},
+		{name: "第二版缺少多重基前缀", did: models.DeviceDIDPrefix + suffix, want: 0},
+		{name: "第二版包含非base58字符", did: models.DeviceDIDPrefix + "z0" + suffix[1:], want: 0},
+		{name: "第二版哈希被截断", did: models.DeviceDIDPrefix + "z" + suffix[:len(suffix)-1], want: 0},
+		{name: "第二版哈希算法代码错误", did: models.DeviceDIDPrefix + "z" + encodeBase58(append([]byte{0x13, 32}, make([]byte, 32)...)), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DIDVersion(tt.did); got != tt.want {
+				t.Errorf("DIDVersion(%q) = %d, want %d", tt.did, got, tt.want)
+			}
+			if got := ValidateDID(tt.did); got != (tt.want != 0) {
+				t.Errorf("ValidateDID(%q) = %v", tt.did, got)
+			}
+		})
+	}
+}
+
+func TestBase58RoundTrip(t *testing.T) {
+	tests := [][]byte{
+		{},
+		{0},
+		{0, 0, 1},
+		{0x12, 0x20, 0xff, 0x00, 0x7f},
+	}
+
+	for _, data := range tests {
+		encoded := encodeBase58(data)
+		decoded, err := decodeBase58(encoded)
+		if err != nil {
+			t.Fatalf("decodeBase58(%q) error = %v", encoded, err)
+		}
+		if string(decoded) != string(data) {
+			t.Errorf("decodeBase58(encodeBase58(%v)) = %v", data, decoded)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"encoding/hex"
+)
+
+// ValidateEvidenceHash 验证证据哈希格式（SHA256十六进制），空值表示无证据
+func ValidateEvidenceHash(evidenceHash string) bool {
+	if evidenceHash == "" {
+		return true
+	}
+	if len(evidenceHash) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(evidenceHash)
+	return err == nil
+}
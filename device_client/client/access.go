@@ -0,0 +1,67 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// CallerIdentity 链码解析出的调用者身份及其角色
+type CallerIdentity struct {
+	MSPID      string `json:"mspId"`                // 调用者所属MSP
+	CommonName string `json:"commonName"`           // 调用者证书的CN
+	Role       string `json:"role,omitempty"`       // 调用者角色: admin, oracle, device
+	RoleSource string `json:"roleSource,omitempty"` // 角色来源: attribute, binding, ou
+	DID        string `json:"did,omitempty"`        // 设备角色对应的设备DID
+}
+
+// GetCallerIdentity 查询当前客户端身份在链码中的角色
+func (c *DeviceClient) GetCallerIdentity() (*CallerIdentity, error) {
+	result, err := c.contract.EvaluateTransaction(identityContract + ":GetCallerIdentity")
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var caller CallerIdentity
+	if err := json.Unmarshal(result, &caller); err != nil {
+		return nil, fmt.Errorf("解析调用者身份失败: %w", err)
+	}
+
+	return &caller, nil
+}
+
+// AssignRole 为证书中没有 role 属性的身份分配链上角色，需要管理员身份
+func (c *DeviceClient) AssignRole(mspID, commonName, role string) (string, error) {
+	log.Printf("分配角色: %s/%s -> %s", mspID, commonName, role)
+
+	// 参数验证
+	if mspID == "" || commonName == "" || role == "" {
+		return "", fmt.Errorf("MSP ID、证书CN和角色不能为空")
+	}
+
+	// 调用链码分配角色
+	_, err := c.contract.SubmitTransaction(identityContract+":AssignRole", mspID, commonName, role)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	return fmt.Sprintf("已为 %s/%s 分配 %s 角色", mspID, commonName, role), nil
+}
+
+// RevokeRole 删除身份的链上角色绑定，需要管理员身份
+func (c *DeviceClient) RevokeRole(mspID, commonName string) (string, error) {
+	log.Printf("撤销角色: %s/%s", mspID, commonName)
+
+	// 参数验证
+	if mspID == "" || commonName == "" {
+		return "", fmt.Errorf("MSP ID和证书CN不能为空")
+	}
+
+	// 调用链码撤销角色
+	_, err := c.contract.SubmitTransaction(identityContract+":RevokeRole", mspID, commonName)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	return fmt.Sprintf("已撤销 %s/%s 的角色绑定", mspID, commonName), nil
+}
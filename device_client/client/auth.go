@@ -0,0 +1,158 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keyDir 设备私钥存放目录
+const keyDir = "keys"
+
+// AuthChallenge 链码返回的认证挑战
+type AuthChallenge struct {
+	DID     string `json:"did"`
+	Nonce   string `json:"nonce"`
+	Message string `json:"message"`
+}
+
+// Authenticate 使用设备私钥完成挑战-响应认证
+func (c *DeviceClient) Authenticate(did string) (bool, error) {
+	log.Printf("设备认证: %s", did)
+
+	// 参数验证
+	if did == "" {
+		return false, fmt.Errorf("DID不能为空")
+	}
+
+	deviceKey, err := loadDeviceKey(did)
+	if err != nil {
+		return false, err
+	}
+
+	// 申请认证挑战，挑战须写入账本，因此通过提交交易获取
+	challengeJSON, err := c.contract.SubmitTransaction(identityContract+":CreateAuthChallenge", did)
+	if err != nil {
+		return false, fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	var challenge AuthChallenge
+	if err := json.Unmarshal(challengeJSON, &challenge); err != nil {
+		return false, fmt.Errorf("解析认证挑战失败: %w", err)
+	}
+
+	// 对挑战消息签名
+	digest := sha256.Sum256([]byte(challenge.Message))
+	signature, err := ecdsa.SignASN1(rand.Reader, deviceKey, digest[:])
+	if err != nil {
+		return false, fmt.Errorf("签署认证挑战失败: %w", err)
+	}
+
+	// 提交签名，由链码验证并销毁挑战
+	result, err := c.contract.SubmitTransaction(identityContract+":VerifyAuthResponse", did, challenge.Nonce, base64.StdEncoding.EncodeToString(signature))
+	if err != nil {
+		return false, fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	return string(result) == "true", nil
+}
+
+// loadOrCreatePendingKey 加载待注册设备的私钥，不存在时生成新的P-256私钥并保存
+// 设备DID由链码在注册时根据公钥派生，注册前私钥按设备信息暂存，注册成功后由 commitPendingKey 改名
+func loadOrCreatePendingKey(vendor, model, deviceID string) (*ecdsa.PrivateKey, error) {
+	keyPath := pendingKeyPath(vendor, model, deviceID)
+	if _, err := os.Stat(keyPath); err == nil {
+		return loadKeyFile(keyPath)
+	}
+
+	deviceKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成设备密钥失败: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(deviceKey)
+	if err != nil {
+		return nil, fmt.Errorf("设备私钥编码失败: %w", err)
+	}
+	if err := os.MkdirAll(keyDir, 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥目录失败: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return nil, fmt.Errorf("保存设备私钥失败: %w", err)
+	}
+
+	log.Printf("已为设备 %s 生成密钥: %s", deviceID, keyPath)
+	return deviceKey, nil
+}
+
+// commitPendingKey 设备注册成功后，将暂存的私钥改名为按DID命名的私钥文件
+func commitPendingKey(vendor, model, deviceID, did string) error {
+	if err := os.Rename(pendingKeyPath(vendor, model, deviceID), deviceKeyPath(did)); err != nil {
+		return fmt.Errorf("保存设备私钥失败: %w", err)
+	}
+
+	log.Printf("设备 %s 的私钥已保存: %s", did, deviceKeyPath(did))
+	return nil
+}
+
+// loadDeviceKey 从密钥目录加载设备私钥
+func loadDeviceKey(did string) (*ecdsa.PrivateKey, error) {
+	return loadKeyFile(deviceKeyPath(did))
+}
+
+// loadKeyFile 从文件加载PKCS8格式的ECDSA私钥
+func loadKeyFile(keyPath string) (*ecdsa.PrivateKey, error) {
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取设备私钥失败: %w", err)
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("无效的设备私钥文件: %s", keyPath)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析设备私钥失败: %w", err)
+	}
+	deviceKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("设备私钥不是ECDSA私钥")
+	}
+
+	return deviceKey, nil
+}
+
+// encodePublicKey 将公钥编码为PEM格式
+func encodePublicKey(publicKey *ecdsa.PublicKey) (string, error) {
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("公钥编码失败: %w", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})), nil
+}
+
+// deviceKeyPath 设备私钥文件路径，DID中的冒号替换为下划线
+func deviceKeyPath(did string) string {
+	return filepath.Join(keyDir, strings.ReplaceAll(did, ":", "_")+"_key.pem")
+}
+
+// pendingKeyPath 待注册设备的私钥文件路径，以供应商、型号和设备ID的哈希命名
+func pendingKeyPath(vendor, model, deviceID string) string {
+	hash := sha256.Sum256([]byte(vendor + "/" + model + "/" + deviceID))
+	return filepath.Join(keyDir, "pending_"+hex.EncodeToString(hash[:8])+"_key.pem")
+}
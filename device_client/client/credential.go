@@ -0,0 +1,197 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// credentialDir 设备持有的可验证凭证存放目录
+const credentialDir = "credentials"
+
+// VerifiableCredential 设备可验证凭证，字段顺序须与链码模型一致
+type VerifiableCredential struct {
+	Context           []string          `json:"@context"`
+	ID                string            `json:"id"`
+	Type              []string          `json:"type"`
+	Issuer            string            `json:"issuer"`
+	IssuanceDate      string            `json:"issuanceDate"`
+	ExpirationDate    string            `json:"expirationDate"`
+	CredentialSubject CredentialSubject `json:"credentialSubject"`
+	Proof             *CredentialProof  `json:"proof,omitempty"`
+}
+
+// CredentialSubject 凭证主体
+type CredentialSubject struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Model  string `json:"model"`
+	Vendor string `json:"vendor"`
+}
+
+// CredentialProof 凭证签名
+type CredentialProof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	ProofValue         string `json:"proofValue"`
+}
+
+// CredentialVerification 凭证验证结果
+type CredentialVerification struct {
+	CredentialID string `json:"credentialId"`
+	DID          string `json:"did"`
+	Valid        bool   `json:"valid"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// IssueCredential 为设备签发可验证凭证
+// 链码登记凭证哈希后返回不含签名的凭证，由当前身份私钥签名并保存到凭证目录
+func (c *DeviceClient) IssueCredential(did string) (string, error) {
+	log.Printf("签发设备凭证: %s", did)
+
+	// 参数验证
+	if did == "" {
+		return "", fmt.Errorf("DID不能为空")
+	}
+
+	signer, ok := c.privateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("当前身份私钥不是ECDSA私钥，无法签署凭证")
+	}
+
+	// 调用链码签发凭证
+	unsignedJSON, err := c.contract.SubmitTransaction(identityContract+":IssueCredential", did)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	var vc VerifiableCredential
+	if err := json.Unmarshal(unsignedJSON, &vc); err != nil {
+		return "", fmt.Errorf("解析凭证失败: %w", err)
+	}
+
+	// 对链码返回的凭证原文哈希签名，与链上登记的哈希一致
+	hash := sha256.Sum256(unsignedJSON)
+	signature, err := ecdsa.SignASN1(rand.Reader, signer, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("签署凭证失败: %w", err)
+	}
+
+	verificationMethod, err := c.verificationMethod(vc.Issuer)
+	if err != nil {
+		return "", err
+	}
+	vc.Proof = &CredentialProof{
+		Type:               "EcdsaSecp256r1Signature2019",
+		Created:            time.Now().UTC().Format(time.RFC3339),
+		VerificationMethod: verificationMethod,
+		ProofPurpose:       "assertionMethod",
+		ProofValue:         base64.StdEncoding.EncodeToString(signature),
+	}
+
+	credentialPath, err := saveCredential(&vc)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("设备 %s 的凭证 %s 已签发", did, vc.ID)
+	return fmt.Sprintf("凭证签发成功! 凭证ID: %s，已保存到 %s", vc.ID, credentialPath), nil
+}
+
+// PresentCredential 出示设备持有的可验证凭证，由链码验证签名、有效期和吊销状态
+func (c *DeviceClient) PresentCredential(did string) (*CredentialVerification, error) {
+	log.Printf("出示设备凭证: %s", did)
+
+	// 参数验证
+	if did == "" {
+		return nil, fmt.Errorf("DID不能为空")
+	}
+
+	vcJSON, err := ioutil.ReadFile(credentialPath(did))
+	if err != nil {
+		return nil, fmt.Errorf("读取设备凭证失败: %w", err)
+	}
+
+	// 调用链码验证凭证
+	result, err := c.contract.EvaluateTransaction(identityContract+":VerifyCredential", string(vcJSON))
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var verification CredentialVerification
+	if err := json.Unmarshal(result, &verification); err != nil {
+		return nil, fmt.Errorf("解析凭证验证结果失败: %w", err)
+	}
+
+	return &verification, nil
+}
+
+// RevokeCredential 吊销设备当前有效的凭证
+func (c *DeviceClient) RevokeCredential(did, reason string) (string, error) {
+	log.Printf("吊销设备凭证: %s, 原因: %s", did, reason)
+
+	// 参数验证
+	if did == "" || reason == "" {
+		return "", fmt.Errorf("DID和吊销原因不能为空")
+	}
+
+	// 调用链码吊销凭证
+	_, err := c.contract.SubmitTransaction(identityContract+":RevokeCredential", did, reason)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	log.Printf("设备 %s 的凭证已吊销", did)
+	return "设备凭证已吊销", nil
+}
+
+// verificationMethod 生成签名验证方法标识，与链码中的公钥指纹计算方式一致
+func (c *DeviceClient) verificationMethod(issuer string) (string, error) {
+	publicKey, ok := c.certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("当前身份证书公钥不是ECDSA公钥")
+	}
+	publicKeyPEM, err := encodePublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint := sha256.Sum256([]byte(publicKeyPEM))
+	return issuer + "#" + hex.EncodeToString(fingerprint[:8]), nil
+}
+
+// saveCredential 将签名后的凭证保存到凭证目录
+func saveCredential(vc *VerifiableCredential) (string, error) {
+	if err := os.MkdirAll(credentialDir, 0700); err != nil {
+		return "", fmt.Errorf("创建凭证目录失败: %w", err)
+	}
+
+	vcJSON, err := json.MarshalIndent(vc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("凭证序列化失败: %w", err)
+	}
+
+	path := credentialPath(vc.CredentialSubject.ID)
+	if err := ioutil.WriteFile(path, vcJSON, 0600); err != nil {
+		return "", fmt.Errorf("保存凭证失败: %w", err)
+	}
+
+	return path, nil
+}
+
+// credentialPath 设备凭证文件路径，DID中的冒号替换为下划线
+func credentialPath(did string) string {
+	return filepath.Join(credentialDir, strings.ReplaceAll(did, ":", "_")+".json")
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
@@ -18,13 +19,15 @@ import (
 
 // DeviceClient 设备客户端结构体
 type DeviceClient struct {
-	contract    *client.Contract
-	gateway     *client.Gateway
-	conn        *grpc.ClientConn
-	config      *ConnectionConfig
-	deviceName  string // 设备名称
-	deviceModel string // 设备型号
-	deviceVendor string // 设备供应商
+	contract     *client.Contract
+	gateway      *client.Gateway
+	conn         *grpc.ClientConn
+	config       *ConnectionConfig
+	deviceName   string            // 设备名称
+	deviceModel  string            // 设备型号
+	deviceVendor string            // 设备供应商
+	privateKey   crypto.PrivateKey // 客户端身份私钥，用于签署可验证凭证
+	certificate  *x509.Certificate // 客户端身份证书
 }
 
 // 设备事件结构体，用于解析链码事件
@@ -36,6 +39,31 @@ type DeviceEvent struct {
 	RiskScore    float64   `json:"riskScore"`    // 风险评分
 	Category     string    `json:"category"`     // 行为类别
 	BehaviorType string    `json:"behaviorType"` // 具体行为类型
+	SessionID    string    `json:"sessionId"`    // 设备连接会话ID
+}
+
+// RiskResponse 链码返回的设备风险响应策略
+type RiskResponse struct {
+	DID           string            `json:"did"`           // 设备DID
+	RiskScore     float64           `json:"riskScore"`     // 当前风险评分
+	Tier          string            `json:"tier"`          // 风险等级标识
+	RiskLevel     string            `json:"riskLevel"`     // 风险等级名称
+	AllowConnect  bool              `json:"allowConnect"`  // 是否允许设备连接
+	Strategy      string            `json:"strategy"`      // 响应策略
+	Measures      []ResponseMeasure `json:"measures"`      // 具体响应措施
+	PolicyVersion int               `json:"policyVersion"` // 风险等级策略版本
+}
+
+// ResponseMeasure 风险响应措施
+type ResponseMeasure struct {
+	Code        string        `json:"code"`        // 措施代码，如 FULL_PCAP、ISOLATE_VLAN
+	Description LocalizedText `json:"description"` // 措施描述
+}
+
+// LocalizedText 多语言文本
+type LocalizedText struct {
+	ZH string `json:"zh"` // 中文
+	EN string `json:"en"` // 英文
 }
 
 // 合约名称常量
@@ -45,22 +73,38 @@ const (
 	configPath       = "../config.json"
 )
 
+// Options 设备客户端的创建选项
+type Options struct {
+	AdminCertPath string // 管理员身份证书，与 AdminKeyPath 同时指定时以管理员身份连接网络
+	AdminKeyPath  string // 管理员身份私钥目录
+}
+
 // NewDeviceClient 创建新的设备客户端
-func NewDeviceClient() (*DeviceClient, error) {
+// 默认使用配置文件中 certPath 和 keyPath 指定的身份，只有在 opts 中显式指定管理员证书和私钥时才使用管理员身份
+func NewDeviceClient(opts Options) (*DeviceClient, error) {
 	// 加载配置
 	config, err := LoadConfig(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("加载配置失败: %w", err)
 	}
 
+	certPath, keyPath := config.CertPath, config.KeyPath
+	if (opts.AdminCertPath == "") != (opts.AdminKeyPath == "") {
+		return nil, fmt.Errorf("管理员证书和私钥必须同时指定")
+	}
+	if opts.AdminCertPath != "" {
+		certPath, keyPath = opts.AdminCertPath, opts.AdminKeyPath
+		log.Printf("使用管理员身份连接网络: %s", certPath)
+	}
+
 	// 创建客户端证书
-	clientCert, err := loadCertificate(config.CertPath)
+	clientCert, err := loadCertificate(certPath)
 	if err != nil {
 		return nil, fmt.Errorf("加载客户端证书失败: %w", err)
 	}
 
 	// 加载客户端私钥
-	clientKey, err := loadPrivateKey(config.KeyPath)
+	clientKey, err := loadPrivateKey(keyPath)
 	if err != nil {
 		return nil, fmt.Errorf("加载客户端私钥失败: %w", err)
 	}
@@ -120,10 +164,12 @@ func NewDeviceClient() (*DeviceClient, error) {
 	
 	// 创建设备客户端
 	deviceClient := &DeviceClient{
-		contract: contract,
-		gateway:  gw,
-		conn:     conn,
-		config:   config,
+		contract:    contract,
+		gateway:     gw,
+		conn:        conn,
+		config:      config,
+		privateKey:  clientKey,
+		certificate: clientCert,
 	}
 	
 	return deviceClient, nil
@@ -144,24 +190,27 @@ func (c *DeviceClient) RegisterDevice(name, model, vendor, deviceID string) (str
 		return "", fmt.Errorf("所有参数都不能为空")
 	}
 	
-	// 先生成DID，检查设备是否已存在
-	did, err := c.GetDIDByInfo(name, model, vendor, deviceID)
+	// 生成或加载设备密钥，公钥随注册交易登记到链上
+	deviceKey, err := loadOrCreatePendingKey(vendor, model, deviceID)
 	if err != nil {
-		return "", fmt.Errorf("生成DID失败: %w", err)
+		return "", err
 	}
-	
-	// 检查设备是否已存在
-	deviceJSON, err := c.contract.EvaluateTransaction(identityContract+":GetDevice", did)
-	if err == nil && len(deviceJSON) > 0 {
-		// 设备已存在
-		return "", fmt.Errorf("设备已存在，DID: %s", did)
+	publicKeyPEM, err := encodePublicKey(&deviceKey.PublicKey)
+	if err != nil {
+		return "", err
 	}
 	
-	// 调用链码注册设备
-	_, err = c.contract.SubmitTransaction(identityContract+":RegisterDevice", name, model, vendor, deviceID)
+	// 调用链码注册设备，DID由链码根据公钥和注册随机数生成
+	result, err := c.contract.SubmitTransaction(identityContract+":RegisterDevice", name, model, vendor, deviceID, publicKeyPEM)
 	if err != nil {
 		return "", fmt.Errorf("提交交易失败: %w", err)
 	}
+	did := string(result)
+	
+	// 注册成功后按DID保存设备私钥
+	if err := commitPendingKey(vendor, model, deviceID, did); err != nil {
+		return "", err
+	}
 	
 	// 保存设备信息
 	c.deviceName = name
@@ -196,7 +245,7 @@ func (c *DeviceClient) GetDevice(did string) (string, error) {
 	return prettyJSON.String(), nil
 }
 
-// GetDIDByInfo 根据设备信息获取DID
+// GetDIDByInfo 根据设备信息查找已注册设备的DID
 func (c *DeviceClient) GetDIDByInfo(name, model, vendor, deviceID string) (string, error) {
 	log.Printf("获取DID: %s, %s, %s, %s", name, model, vendor, deviceID)
 	
@@ -214,56 +263,69 @@ func (c *DeviceClient) GetDIDByInfo(name, model, vendor, deviceID string) (strin
 	return string(result), nil
 }
 
-// ResetDeviceRiskScore 重置设备风险评分
-func (c *DeviceClient) ResetDeviceRiskScore(did string) (string, error) {
-	log.Printf("重置设备风险评分: %s", did)
+// GetRiskResponse 获取风险响应策略
+func (c *DeviceClient) GetRiskResponse(did string) (*RiskResponse, error) {
+	log.Printf("获取设备风险响应策略: %s", did)
 	
 	// 参数验证
 	if did == "" {
-		return "", fmt.Errorf("DID不能为空")
+		return nil, fmt.Errorf("DID不能为空")
 	}
 	
-	// 检查设备是否存在
-	deviceJSON, err := c.contract.EvaluateTransaction(identityContract+":GetDevice", did)
+	// 调用链码获取风险响应策略
+	result, err := c.contract.EvaluateTransaction(riskContract+":GetDeviceRiskResponse", did)
 	if err != nil {
-		return "", fmt.Errorf("获取设备信息失败: %w", err)
+		return nil, fmt.Errorf("评估交易失败: %w", err)
 	}
-	if len(deviceJSON) == 0 {
-		return "", fmt.Errorf("设备DID %s 不存在", did)
+	
+	var response RiskResponse
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("解析风险响应策略失败: %w", err)
 	}
 	
-	// 调用链码重置设备风险评分
-	_, err = c.contract.SubmitTransaction(identityContract+":ResetDeviceRiskScore", did)
+	return &response, nil
+}
+
+// DeviceExists 检查设备是否已在链上注册
+func (c *DeviceClient) DeviceExists(did string) (bool, error) {
+	result, err := c.contract.EvaluateTransaction(identityContract+":DeviceExists", did)
 	if err != nil {
-		return "", fmt.Errorf("提交交易失败: %w", err)
+		return false, fmt.Errorf("评估交易失败: %w", err)
 	}
 	
-	log.Printf("设备 %s 的风险评分已重置为0", did)
-	return "设备风险评分已重置为0", nil
+	return string(result) == "true", nil
 }
 
-// GetRiskResponse 获取风险响应策略
-func (c *DeviceClient) GetRiskResponse(did string) (string, error) {
-	log.Printf("获取设备风险响应策略: %s", did)
-	
+// ResolveDID 从链上解析设备DID，返回包含DID文档和元数据的解析结果JSON
+func (c *DeviceClient) ResolveDID(did string) ([]byte, error) {
 	// 参数验证
 	if did == "" {
-		return "", fmt.Errorf("DID不能为空")
+		return nil, fmt.Errorf("DID不能为空")
 	}
 	
-	// 调用链码获取风险响应策略
-	result, err := c.contract.EvaluateTransaction(riskContract+":GetDeviceRiskResponse", did)
+	result, err := c.contract.EvaluateTransaction(identityContract+":ResolveDID", did)
 	if err != nil {
-		return "", fmt.Errorf("评估交易失败: %w", err)
+		return nil, fmt.Errorf("评估交易失败: %w", err)
 	}
 	
-	// 格式化JSON输出
-	var prettyJSON bytes.Buffer
-	if err := json.Indent(&prettyJSON, result, "", "  "); err != nil {
-		return string(result), nil // 如果格式化失败，返回原始结果
+	return result, nil
+}
+
+// UpdateDeviceServices 更新设备DID文档中的服务端点
+func (c *DeviceClient) UpdateDeviceServices(did, servicesJSON string) (string, error) {
+	log.Printf("更新设备服务端点: %s", did)
+	
+	// 参数验证
+	if did == "" || servicesJSON == "" {
+		return "", fmt.Errorf("DID和服务端点不能为空")
 	}
 	
-	return prettyJSON.String(), nil
+	_, err := c.contract.SubmitTransaction(identityContract+":UpdateDeviceServices", did, servicesJSON)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+	
+	return "设备服务端点已更新", nil
 }
 
 // 辅助函数：加载证书
@@ -276,7 +338,7 @@ func loadCertificate(filename string) (*x509.Certificate, error) {
 }
 
 // 辅助函数：加载私钥
-func loadPrivateKey(dirPath string) (interface{}, error) {
+func loadPrivateKey(dirPath string) (crypto.PrivateKey, error) {
 	// 读取目录中的文件
 	files, err := ioutil.ReadDir(dirPath)
 	if err != nil {
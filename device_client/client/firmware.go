@@ -0,0 +1,90 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// FirmwareAttestation 设备固件证明的核验结果
+type FirmwareAttestation struct {
+	DID             string    `json:"did"`             // 设备DID
+	FirmwareVersion string    `json:"firmwareVersion"` // 设备证明的固件版本
+	FirmwareHash    string    `json:"firmwareHash"`    // 设备证明的固件哈希
+	Status          string    `json:"status"`          // 核验结果: verified, mismatch, unknown_version, unlisted
+	RiskScore       float64   `json:"riskScore"`       // 核验后的风险评分
+	Timestamp       time.Time `json:"timestamp"`       // 证明时间
+	TxID            string    `json:"txId"`            // 证明交易ID
+}
+
+// AttestFirmware 计算固件文件的SHA256哈希，连同固件版本提交链上核验
+func (c *DeviceClient) AttestFirmware(did, version, firmwarePath string) (*FirmwareAttestation, error) {
+	log.Printf("证明设备固件: %s, 版本: %s, 固件文件: %s", did, version, firmwarePath)
+
+	// 参数验证
+	if did == "" || version == "" {
+		return nil, fmt.Errorf("DID和固件版本不能为空")
+	}
+
+	hash, err := fileSHA256(firmwarePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.contract.SubmitTransaction(identityContract+":AttestFirmware", did, version, hash)
+	if err != nil {
+		return nil, fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	var attestation FirmwareAttestation
+	if err := json.Unmarshal(result, &attestation); err != nil {
+		return nil, fmt.Errorf("解析固件核验结果失败: %w", err)
+	}
+
+	log.Printf("设备 %s 固件核验结果: %s", did, attestation.Status)
+	return &attestation, nil
+}
+
+// AttestConfiguration 计算配置文件的SHA256哈希并记录到链上
+func (c *DeviceClient) AttestConfiguration(did, configPath string) (string, error) {
+	log.Printf("证明设备配置: %s, 配置文件: %s", did, configPath)
+
+	// 参数验证
+	if did == "" {
+		return "", fmt.Errorf("DID不能为空")
+	}
+
+	hash, err := fileSHA256(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = c.contract.SubmitTransaction(identityContract+":AttestConfiguration", did, hash)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	log.Printf("设备 %s 配置哈希已记录: %s", did, hash)
+	return fmt.Sprintf("配置哈希已记录: %s", hash), nil
+}
+
+// fileSHA256 计算文件内容的SHA256哈希（十六进制）
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
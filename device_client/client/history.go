@@ -0,0 +1,46 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DeviceHistoryEntry 设备信息的一个历史版本
+type DeviceHistoryEntry struct {
+	TxID      string          `json:"txId"`             // 写入该版本的交易ID
+	Timestamp time.Time       `json:"timestamp"`        // 交易时间
+	IsDelete  bool            `json:"isDelete"`         // 该交易是否删除了设备信息
+	Device    json.RawMessage `json:"device,omitempty"` // 该版本的设备信息
+	Changes   []FieldChange   `json:"changes"`          // 与上一版本相比发生变化的字段
+}
+
+// FieldChange 设备信息字段的变化，新旧值均为字段的JSON编码
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+// GetDeviceHistory 获取设备信息的全部历史版本，按时间升序排列
+func (c *DeviceClient) GetDeviceHistory(did string) ([]DeviceHistoryEntry, error) {
+	log.Printf("获取设备历史: %s", did)
+
+	// 参数验证
+	if did == "" {
+		return nil, fmt.Errorf("DID不能为空")
+	}
+
+	result, err := c.contract.EvaluateTransaction(identityContract+":GetDeviceHistory", did)
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var history []DeviceHistoryEntry
+	if err := json.Unmarshal(result, &history); err != nil {
+		return nil, fmt.Errorf("解析设备历史失败: %w", err)
+	}
+
+	return history, nil
+}
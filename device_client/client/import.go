@@ -0,0 +1,260 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importBatchSize 每笔批量注册交易包含的设备数量，不超过链码的批量注册上限
+const importBatchSize = 200
+
+// notRegisteredMessage 链码 GetDIDByInfo 在设备未注册时返回的错误信息
+const notRegisteredMessage = "设备未注册"
+
+// 导入结果状态常量
+const (
+	ImportStatusNew        = "new"        // 未注册，预演模式下表示将会注册
+	ImportStatusRegistered = "registered" // 已注册成功
+	ImportStatusDuplicate  = "duplicate"  // 链上已注册或文件内重复，已跳过
+	ImportStatusInvalid    = "invalid"    // 设备信息不完整，已跳过
+	ImportStatusFailed     = "failed"     // 注册交易失败
+)
+
+// ImportRecord 导入文件中的一台设备
+type ImportRecord struct {
+	Name     string `json:"name"`     // 设备名称
+	Model    string `json:"model"`    // 设备型号
+	Vendor   string `json:"vendor"`   // 设备供应商
+	DeviceID string `json:"deviceId"` // 设备ID
+}
+
+// ImportItem 一台设备的导入结果
+type ImportItem struct {
+	Line    int    // 设备在导入文件中的序号，从1开始
+	Record  ImportRecord
+	Status  string // 导入结果状态
+	DID     string // 新注册或已注册设备的DID
+	Message string // 跳过或失败的原因
+}
+
+// ImportReport 导入汇总报告
+type ImportReport struct {
+	DryRun bool
+	Items  []*ImportItem
+}
+
+// Count 统计指定状态的设备数量
+func (r *ImportReport) Count(status string) int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// deviceRegistration 批量注册交易中的一台设备
+type deviceRegistration struct {
+	Name      string `json:"name"`
+	Model     string `json:"model"`
+	Vendor    string `json:"vendor"`
+	DeviceID  string `json:"deviceId"`
+	PublicKey string `json:"publicKey"`
+}
+
+// deviceRegistrationResult 批量注册交易返回的一台设备的结果
+type deviceRegistrationResult struct {
+	Index int    `json:"index"`
+	DID   string `json:"did"`
+}
+
+// LoadImportFile 读取CSV或JSON格式的设备导入文件
+// CSV文件首行为列名，须包含 name、model、vendor、deviceId 四列；JSON文件为设备对象数组
+func LoadImportFile(path string) ([]ImportRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开导入文件失败: %w", err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return parseImportCSV(file)
+	case ".json":
+		var records []ImportRecord
+		if err := json.NewDecoder(file).Decode(&records); err != nil {
+			return nil, fmt.Errorf("解析JSON导入文件失败: %w", err)
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("不支持的导入文件格式: %s，仅支持 .csv 和 .json", filepath.Ext(path))
+	}
+}
+
+// parseImportCSV 按列名解析CSV导入文件，列的顺序不限
+func parseImportCSV(r io.Reader) ([]ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV列名失败: %w", err)
+	}
+	// 列名忽略大小写，并去掉Excel导出时带有的UTF-8 BOM
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"name", "model", "vendor", "deviceid"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV文件缺少 %s 列", name)
+		}
+	}
+
+	var records []ImportRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取CSV文件失败: %w", err)
+		}
+		records = append(records, ImportRecord{
+			Name:     strings.TrimSpace(row[columns["name"]]),
+			Model:    strings.TrimSpace(row[columns["model"]]),
+			Vendor:   strings.TrimSpace(row[columns["vendor"]]),
+			DeviceID: strings.TrimSpace(row[columns["deviceid"]]),
+		})
+	}
+
+	return records, nil
+}
+
+// ImportDevices 批量注册导入文件中的设备
+// 依次跳过信息不完整、文件内重复和通过 GetDIDByInfo 查到已注册的设备，其余设备按批提交 RegisterDevices
+// dryRun 为 true 时只检查不注册
+func (c *DeviceClient) ImportDevices(records []ImportRecord, dryRun bool) (*ImportReport, error) {
+	log.Printf("导入设备: %d 台，预演模式: %v", len(records), dryRun)
+
+	report := &ImportReport{DryRun: dryRun}
+	seen := make(map[ImportRecord]int)
+	var pending []*ImportItem
+	for i, record := range records {
+		item := &ImportItem{Line: i + 1, Record: record}
+		report.Items = append(report.Items, item)
+
+		if record.Name == "" || record.Model == "" || record.Vendor == "" || record.DeviceID == "" {
+			item.Status = ImportStatusInvalid
+			item.Message = "设备名称、型号、供应商和设备ID不能为空"
+			continue
+		}
+
+		// 链码以供应商、型号和设备ID识别同一台设备
+		key := ImportRecord{Model: record.Model, Vendor: record.Vendor, DeviceID: record.DeviceID}
+		if first, ok := seen[key]; ok {
+			item.Status = ImportStatusDuplicate
+			item.Message = fmt.Sprintf("与第 %d 台设备重复", first)
+			continue
+		}
+		seen[key] = item.Line
+
+		did, err := c.contract.EvaluateTransaction(identityContract+":GetDIDByInfo", record.Name, record.Model, record.Vendor, record.DeviceID)
+		if err == nil {
+			item.Status = ImportStatusDuplicate
+			item.DID = string(did)
+			item.Message = "设备已注册"
+			continue
+		}
+		if !strings.Contains(err.Error(), notRegisteredMessage) {
+			return nil, fmt.Errorf("查询第 %d 台设备是否已注册失败: %w", item.Line, err)
+		}
+
+		item.Status = ImportStatusNew
+		pending = append(pending, item)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for start := 0; start < len(pending); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		c.registerImportBatch(pending[start:end])
+	}
+
+	return report, nil
+}
+
+// registerImportBatch 为一批设备生成密钥并提交批量注册交易，交易失败时整批标记为失败
+func (c *DeviceClient) registerImportBatch(items []*ImportItem) {
+	fail := func(err error) {
+		for _, item := range items {
+			item.Status = ImportStatusFailed
+			item.Message = err.Error()
+		}
+	}
+
+	registrations := make([]deviceRegistration, 0, len(items))
+	for _, item := range items {
+		deviceKey, err := loadOrCreatePendingKey(item.Record.Vendor, item.Record.Model, item.Record.DeviceID)
+		if err != nil {
+			fail(err)
+			return
+		}
+		publicKeyPEM, err := encodePublicKey(&deviceKey.PublicKey)
+		if err != nil {
+			fail(err)
+			return
+		}
+		registrations = append(registrations, deviceRegistration{
+			Name:      item.Record.Name,
+			Model:     item.Record.Model,
+			Vendor:    item.Record.Vendor,
+			DeviceID:  item.Record.DeviceID,
+			PublicKey: publicKeyPEM,
+		})
+	}
+
+	devicesJSON, err := json.Marshal(registrations)
+	if err != nil {
+		fail(fmt.Errorf("设备列表序列化失败: %w", err))
+		return
+	}
+	result, err := c.contract.SubmitTransaction(identityContract+":RegisterDevices", string(devicesJSON))
+	if err != nil {
+		fail(fmt.Errorf("提交交易失败: %w", err))
+		return
+	}
+
+	var results []deviceRegistrationResult
+	if err := json.Unmarshal(result, &results); err != nil {
+		fail(fmt.Errorf("解析批量注册结果失败: %w", err))
+		return
+	}
+	for _, result := range results {
+		if result.Index < 1 || result.Index > len(items) {
+			continue
+		}
+		item := items[result.Index-1]
+		item.Status = ImportStatusRegistered
+		item.DID = result.DID
+
+		// 注册成功后按DID保存设备私钥
+		if err := commitPendingKey(item.Record.Vendor, item.Record.Model, item.Record.DeviceID, result.DID); err != nil {
+			item.Message = err.Error()
+		}
+	}
+
+	log.Printf("批量注册 %d 台设备成功", len(results))
+}
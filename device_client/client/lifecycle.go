@@ -0,0 +1,59 @@
+package client
+
+import (
+	"fmt"
+	"log"
+)
+
+// SuspendDevice 暂停设备
+func (c *DeviceClient) SuspendDevice(did, reason string) (string, error) {
+	return c.changeLifecycle("SuspendDevice", "暂停", did, reason)
+}
+
+// ReactivateDevice 恢复已暂停的设备
+func (c *DeviceClient) ReactivateDevice(did, reason string) (string, error) {
+	return c.changeLifecycle("ReactivateDevice", "恢复", did, reason)
+}
+
+// DecommissionDevice 退役设备，退役后不可恢复
+func (c *DeviceClient) DecommissionDevice(did, reason string) (string, error) {
+	return c.changeLifecycle("DecommissionDevice", "退役", did, reason)
+}
+
+// ReplaceDevice 用新设备替换旧设备，旧设备随之退役
+func (c *DeviceClient) ReplaceDevice(oldDID, newDID, reason string) (string, error) {
+	log.Printf("替换设备: %s -> %s, 原因: %s", oldDID, newDID, reason)
+
+	// 参数验证
+	if oldDID == "" || newDID == "" || reason == "" {
+		return "", fmt.Errorf("新旧设备DID和替换原因不能为空")
+	}
+
+	// 调用链码替换设备
+	_, err := c.contract.SubmitTransaction(identityContract+":ReplaceDevice", oldDID, newDID, reason)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	log.Printf("设备 %s 已被 %s 替换", oldDID, newDID)
+	return fmt.Sprintf("设备替换成功! 旧设备 %s 已退役", oldDID), nil
+}
+
+// changeLifecycle 提交设备生命周期状态变更交易
+func (c *DeviceClient) changeLifecycle(function, action, did, reason string) (string, error) {
+	log.Printf("%s设备: %s, 原因: %s", action, did, reason)
+
+	// 参数验证
+	if did == "" || reason == "" {
+		return "", fmt.Errorf("DID和%s原因不能为空", action)
+	}
+
+	// 调用链码变更设备状态
+	_, err := c.contract.SubmitTransaction(identityContract+":"+function, did, reason)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	log.Printf("设备 %s 已%s", did, action)
+	return fmt.Sprintf("设备已%s", action), nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DeviceSession 设备连接会话
+type DeviceSession struct {
+	SessionID      string    `json:"sessionId"`             // 会话ID
+	DID            string    `json:"did"`                   // 设备DID
+	Status         string    `json:"status"`                // 会话状态: open, closed
+	Tier           string    `json:"tier"`                  // 建立连接时设备所处的风险等级
+	RiskScore      float64   `json:"riskScore"`             // 建立连接时设备的风险评分
+	ConnectedAt    time.Time `json:"connectedAt"`           // 连接时间
+	DisconnectedAt time.Time `json:"disconnectedAt"`        // 断开时间
+	CloseReason    string    `json:"closeReason,omitempty"` // 会话关闭原因
+}
+
+// ConnectDevice 设备建立连接，链码按风险等级检查连接资格后返回新会话
+func (c *DeviceClient) ConnectDevice(did string) (*DeviceSession, error) {
+	log.Printf("设备建立连接: %s", did)
+
+	// 参数验证
+	if did == "" {
+		return nil, fmt.Errorf("DID不能为空")
+	}
+
+	// 调用链码建立连接
+	result, err := c.contract.SubmitTransaction(identityContract+":ConnectDevice", did)
+	if err != nil {
+		return nil, fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	var session DeviceSession
+	if err := json.Unmarshal(result, &session); err != nil {
+		return nil, fmt.Errorf("解析连接会话失败: %w", err)
+	}
+
+	log.Printf("设备 %s 已连接，会话ID: %s", did, session.SessionID)
+	return &session, nil
+}
+
+// DisconnectDevice 设备断开连接，会话ID为空时断开设备当前的会话
+func (c *DeviceClient) DisconnectDevice(did, sessionID string) (*DeviceSession, error) {
+	log.Printf("设备断开连接: %s", did)
+
+	// 参数验证
+	if did == "" {
+		return nil, fmt.Errorf("DID不能为空")
+	}
+
+	if sessionID == "" {
+		deviceJSON, err := c.contract.EvaluateTransaction(identityContract+":GetDevice", did)
+		if err != nil {
+			return nil, fmt.Errorf("获取设备信息失败: %w", err)
+		}
+		var device struct {
+			SessionID string `json:"sessionId"`
+		}
+		if err := json.Unmarshal(deviceJSON, &device); err != nil {
+			return nil, fmt.Errorf("解析设备信息失败: %w", err)
+		}
+		if device.SessionID == "" {
+			return nil, fmt.Errorf("设备 %s 当前不在线", did)
+		}
+		sessionID = device.SessionID
+	}
+
+	// 调用链码断开连接
+	result, err := c.contract.SubmitTransaction(identityContract+":DisconnectDevice", did, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("提交交易失败: %w", err)
+	}
+
+	var session DeviceSession
+	if err := json.Unmarshal(result, &session); err != nil {
+		return nil, fmt.Errorf("解析连接会话失败: %w", err)
+	}
+
+	log.Printf("设备 %s 已断开连接，会话ID: %s", did, session.SessionID)
+	return &session, nil
+}
@@ -2,17 +2,29 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/Tittifer/IEEE/device_client/client"
+	"github.com/Tittifer/IEEE/device_client/resolver"
 )
 
 func main() {
+	// 指定监听地址时以DID解析服务模式运行
+	resolverAddr := flag.String("resolver", "", "以DID解析服务模式运行的监听地址，例如 :8080")
+	// 注册、凭证、生命周期和角色管理需要管理员身份，须显式指定管理员证书和私钥
+	adminCert := flag.String("admin-cert", "", "管理员身份证书路径，与 -admin-key 同时指定时以管理员身份运行")
+	adminKey := flag.String("admin-key", "", "管理员身份私钥目录")
+	flag.Parse()
+
 	// 创建设备客户端
-	deviceClient, err := client.NewDeviceClient()
+	deviceClient, err := client.NewDeviceClient(client.Options{AdminCertPath: *adminCert, AdminKeyPath: *adminKey})
 	if err != nil {
 		log.Fatalf("创建设备客户端失败: %v", err)
 	}
@@ -21,6 +33,11 @@ func main() {
 	// 设置日志格式
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	if *resolverAddr != "" {
+		server := resolver.NewServer(deviceClient)
+		log.Fatal(server.ListenAndServe(*resolverAddr))
+	}
+
 	// 命令行交互
 	scanner := bufio.NewScanner(os.Stdin)
 
@@ -53,6 +70,22 @@ func main() {
 			} else {
 				fmt.Println(result)
 			}
+		case "import":
+			if len(args) < 2 || len(args) > 3 || (len(args) == 3 && args[2] != "--dry-run") {
+				fmt.Println("用法: import <文件.csv|文件.json> [--dry-run]")
+				continue
+			}
+			records, err := client.LoadImportFile(args[1])
+			if err != nil {
+				fmt.Printf("读取导入文件失败: %v\n", err)
+				continue
+			}
+			report, err := deviceClient.ImportDevices(records, len(args) == 3)
+			if err != nil {
+				fmt.Printf("导入设备失败: %v\n", err)
+			} else {
+				printImportReport(report)
+			}
 		case "info":
 			if len(args) != 2 {
 				fmt.Println("用法: info <DID>")
@@ -75,25 +108,206 @@ func main() {
 			} else {
 				fmt.Println("设备DID:", result)
 			}
-		case "reset":
+		case "risk":
 			if len(args) != 2 {
-				fmt.Println("用法: reset <DID>")
+				fmt.Println("用法: risk <DID>")
 				continue
 			}
-			result, err := deviceClient.ResetDeviceRiskScore(args[1])
+			response, err := deviceClient.GetRiskResponse(args[1])
 			if err != nil {
-				fmt.Printf("重置设备风险评分失败: %v\n", err)
+				fmt.Printf("获取风险响应策略失败: %v\n", err)
+			} else {
+				printRiskResponse(response)
+			}
+		case "resolve":
+			if len(args) != 2 {
+				fmt.Println("用法: resolve <DID>")
+				continue
+			}
+			result, err := deviceClient.ResolveDID(args[1])
+			if err != nil {
+				fmt.Printf("解析DID失败: %v\n", err)
+				continue
+			}
+			var prettyJSON bytes.Buffer
+			if err := json.Indent(&prettyJSON, result, "", "  "); err != nil {
+				fmt.Println(string(result))
+			} else {
+				fmt.Println(prettyJSON.String())
+			}
+		case "services":
+			if len(args) < 3 {
+				fmt.Println("用法: services <DID> <服务端点JSON数组>")
+				continue
+			}
+			result, err := deviceClient.UpdateDeviceServices(args[1], strings.Join(args[2:], " "))
+			if err != nil {
+				fmt.Printf("更新设备服务端点失败: %v\n", err)
 			} else {
 				fmt.Println(result)
 			}
-		case "risk":
+		case "authenticate":
 			if len(args) != 2 {
-				fmt.Println("用法: risk <DID>")
+				fmt.Println("用法: authenticate <DID>")
 				continue
 			}
-			result, err := deviceClient.GetRiskResponse(args[1])
+			ok, err := deviceClient.Authenticate(args[1])
 			if err != nil {
-				fmt.Printf("获取风险响应策略失败: %v\n", err)
+				fmt.Printf("设备认证失败: %v\n", err)
+			} else if ok {
+				fmt.Println("设备认证通过")
+			} else {
+				fmt.Println("设备认证未通过: 签名无效或挑战已过期")
+			}
+		case "issue":
+			if len(args) != 2 {
+				fmt.Println("用法: issue <DID>")
+				continue
+			}
+			result, err := deviceClient.IssueCredential(args[1])
+			if err != nil {
+				fmt.Printf("签发设备凭证失败: %v\n", err)
+			} else {
+				fmt.Println(result)
+			}
+		case "present":
+			if len(args) != 2 {
+				fmt.Println("用法: present <DID>")
+				continue
+			}
+			verification, err := deviceClient.PresentCredential(args[1])
+			if err != nil {
+				fmt.Printf("出示设备凭证失败: %v\n", err)
+			} else if verification.Valid {
+				fmt.Printf("凭证 %s 验证通过\n", verification.CredentialID)
+			} else {
+				fmt.Printf("凭证 %s 验证失败: %s\n", verification.CredentialID, verification.Reason)
+			}
+		case "revoke":
+			if len(args) < 3 {
+				fmt.Println("用法: revoke <DID> <吊销原因>")
+				continue
+			}
+			result, err := deviceClient.RevokeCredential(args[1], strings.Join(args[2:], " "))
+			if err != nil {
+				fmt.Printf("吊销设备凭证失败: %v\n", err)
+			} else {
+				fmt.Println(result)
+			}
+		case "connect":
+			if len(args) != 2 {
+				fmt.Println("用法: connect <DID>")
+				continue
+			}
+			session, err := deviceClient.ConnectDevice(args[1])
+			if err != nil {
+				fmt.Printf("设备连接失败: %v\n", err)
+			} else {
+				fmt.Printf("设备已连接! 会话ID: %s，风险等级: %s，风险评分: %.2f\n", session.SessionID, session.Tier, session.RiskScore)
+			}
+		case "disconnect":
+			if len(args) != 2 && len(args) != 3 {
+				fmt.Println("用法: disconnect <DID> [会话ID]")
+				continue
+			}
+			sessionID := ""
+			if len(args) == 3 {
+				sessionID = args[2]
+			}
+			session, err := deviceClient.DisconnectDevice(args[1], sessionID)
+			if err != nil {
+				fmt.Printf("设备断开连接失败: %v\n", err)
+			} else {
+				fmt.Printf("设备已断开连接，会话ID: %s，连接时长: %s\n", session.SessionID, session.DisconnectedAt.Sub(session.ConnectedAt).Round(time.Second))
+			}
+		case "suspend", "reactivate", "decommission":
+			if len(args) < 3 {
+				fmt.Printf("用法: %s <DID> <原因>\n", args[0])
+				continue
+			}
+			did, reason := args[1], strings.Join(args[2:], " ")
+			var result string
+			var err error
+			switch args[0] {
+			case "suspend":
+				result, err = deviceClient.SuspendDevice(did, reason)
+			case "reactivate":
+				result, err = deviceClient.ReactivateDevice(did, reason)
+			default:
+				result, err = deviceClient.DecommissionDevice(did, reason)
+			}
+			if err != nil {
+				fmt.Printf("变更设备状态失败: %v\n", err)
+			} else {
+				fmt.Println(result)
+			}
+		case "replace":
+			if len(args) < 4 {
+				fmt.Println("用法: replace <旧DID> <新DID> <原因>")
+				continue
+			}
+			result, err := deviceClient.ReplaceDevice(args[1], args[2], strings.Join(args[3:], " "))
+			if err != nil {
+				fmt.Printf("替换设备失败: %v\n", err)
+			} else {
+				fmt.Println(result)
+			}
+		case "history":
+			if len(args) != 2 {
+				fmt.Println("用法: history <DID>")
+				continue
+			}
+			history, err := deviceClient.GetDeviceHistory(args[1])
+			if err != nil {
+				fmt.Printf("获取设备历史失败: %v\n", err)
+			} else {
+				printDeviceHistory(history)
+			}
+		case "firmware":
+			if len(args) != 4 {
+				fmt.Println("用法: firmware <DID> <固件版本> <固件文件>")
+				continue
+			}
+			attestation, err := deviceClient.AttestFirmware(args[1], args[2], args[3])
+			if err != nil {
+				fmt.Printf("固件证明失败: %v\n", err)
+			} else {
+				printFirmwareAttestation(attestation)
+			}
+		case "config":
+			if len(args) != 3 {
+				fmt.Println("用法: config <DID> <配置文件>")
+				continue
+			}
+			result, err := deviceClient.AttestConfiguration(args[1], args[2])
+			if err != nil {
+				fmt.Printf("配置证明失败: %v\n", err)
+			} else {
+				fmt.Println(result)
+			}
+		case "whoami":
+			caller, err := deviceClient.GetCallerIdentity()
+			if err != nil {
+				fmt.Printf("查询当前身份失败: %v\n", err)
+			} else if caller.Role == "" {
+				fmt.Printf("当前身份: %s/%s，未分配角色\n", caller.MSPID, caller.CommonName)
+			} else {
+				fmt.Printf("当前身份: %s/%s，角色: %s（来源: %s）\n", caller.MSPID, caller.CommonName, caller.Role, caller.RoleSource)
+			}
+		case "role":
+			var result string
+			var err error
+			switch {
+			case len(args) == 5 && args[1] == "assign":
+				result, err = deviceClient.AssignRole(args[2], args[3], args[4])
+			case len(args) == 4 && args[1] == "revoke":
+				result, err = deviceClient.RevokeRole(args[2], args[3])
+			default:
+				fmt.Println("用法: role assign <MSP ID> <证书CN> <角色> 或 role revoke <MSP ID> <证书CN>")
+				continue
+			}
+			if err != nil {
+				fmt.Printf("管理角色失败: %v\n", err)
 			} else {
 				fmt.Println(result)
 			}
@@ -110,14 +324,126 @@ func main() {
 	}
 }
 
+// 打印风险响应策略
+func printRiskResponse(response *client.RiskResponse) {
+	fmt.Printf("设备DID: %s\n", response.DID)
+	fmt.Printf("风险评分: %.2f\n", response.RiskScore)
+	fmt.Printf("风险等级: %s (%s)\n", response.RiskLevel, response.Tier)
+	fmt.Printf("允许连接: %t\n", response.AllowConnect)
+	fmt.Printf("响应策略: %s\n", response.Strategy)
+	fmt.Println("响应措施:")
+	for _, measure := range response.Measures {
+		fmt.Printf("  [%s] %s\n", measure.Code, measure.Description.ZH)
+	}
+	fmt.Printf("策略版本: %d\n", response.PolicyVersion)
+}
+
+// 按时间顺序打印设备信息的变更记录
+func printDeviceHistory(history []client.DeviceHistoryEntry) {
+	for i, entry := range history {
+		fmt.Printf("[%d] %s  交易ID: %s\n", i+1, entry.Timestamp.Local().Format("2006-01-02 15:04:05"), entry.TxID)
+		switch {
+		case entry.IsDelete:
+			fmt.Println("    设备信息已删除")
+		case i == 0 || history[i-1].IsDelete:
+			fmt.Println("    创建设备信息")
+		case len(entry.Changes) == 0:
+			fmt.Println("    字段无变化")
+		default:
+			for _, change := range entry.Changes {
+				fmt.Printf("    %s: %s -> %s\n", change.Field, displayValue(change.OldValue), displayValue(change.NewValue))
+			}
+		}
+	}
+	fmt.Printf("共 %d 个版本\n", len(history))
+}
+
+// displayValue 显示字段值，空值显示为 -
+func displayValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// 打印设备导入汇总报告，逐台列出跳过和失败的设备
+func printImportReport(report *client.ImportReport) {
+	for _, item := range report.Items {
+		record := item.Record
+		switch item.Status {
+		case client.ImportStatusRegistered:
+			fmt.Printf("[%d] %s/%s/%s 注册成功，DID: %s\n", item.Line, record.Vendor, record.Model, record.DeviceID, item.DID)
+			if item.Message != "" {
+				fmt.Printf("    %s\n", item.Message)
+			}
+		case client.ImportStatusNew:
+			fmt.Printf("[%d] %s/%s/%s 将会注册\n", item.Line, record.Vendor, record.Model, record.DeviceID)
+		case client.ImportStatusDuplicate:
+			if item.DID != "" {
+				fmt.Printf("[%d] %s/%s/%s 跳过: %s，DID: %s\n", item.Line, record.Vendor, record.Model, record.DeviceID, item.Message, item.DID)
+			} else {
+				fmt.Printf("[%d] %s/%s/%s 跳过: %s\n", item.Line, record.Vendor, record.Model, record.DeviceID, item.Message)
+			}
+		case client.ImportStatusInvalid:
+			fmt.Printf("[%d] %s/%s/%s 跳过: %s\n", item.Line, record.Vendor, record.Model, record.DeviceID, item.Message)
+		case client.ImportStatusFailed:
+			fmt.Printf("[%d] %s/%s/%s 注册失败: %s\n", item.Line, record.Vendor, record.Model, record.DeviceID, item.Message)
+		}
+	}
+
+	fmt.Printf("共 %d 台设备", len(report.Items))
+	if report.DryRun {
+		fmt.Printf("（预演，未注册）: 将注册 %d 台", report.Count(client.ImportStatusNew))
+	} else {
+		fmt.Printf(": 注册成功 %d 台，失败 %d 台", report.Count(client.ImportStatusRegistered), report.Count(client.ImportStatusFailed))
+	}
+	fmt.Printf("，已注册或重复 %d 台，信息不完整 %d 台\n", report.Count(client.ImportStatusDuplicate), report.Count(client.ImportStatusInvalid))
+}
+
+// 打印固件核验结果
+func printFirmwareAttestation(attestation *client.FirmwareAttestation) {
+	fmt.Printf("固件版本: %s\n", attestation.FirmwareVersion)
+	fmt.Printf("固件哈希: %s\n", attestation.FirmwareHash)
+	switch attestation.Status {
+	case "verified":
+		fmt.Println("核验结果: 固件与供应商白名单一致")
+	case "mismatch":
+		fmt.Printf("核验结果: 固件哈希与供应商白名单中该版本的哈希不一致，已按固件篡改上报，当前风险评分: %.2f\n", attestation.RiskScore)
+	case "unknown_version":
+		fmt.Println("核验结果: 供应商白名单中没有该固件版本，仅记录固件信息，不计分")
+	case "unlisted":
+		fmt.Println("核验结果: 该型号尚未发布固件白名单，仅记录固件信息")
+	default:
+		fmt.Printf("核验结果: %s\n", attestation.Status)
+	}
+}
+
 // 打印帮助信息
 func printHelp() {
 	fmt.Println("可用命令:")
 	fmt.Println("  help                                       - 显示帮助信息")
 	fmt.Println("  register <设备名称> <设备型号> <设备供应商> <设备ID> - 注册新设备")
+	fmt.Println("  import <文件.csv|文件.json> [--dry-run]    - 批量导入设备，--dry-run 只检查不注册")
 	fmt.Println("  info <DID>                                 - 获取设备信息")
 	fmt.Println("  did <设备名称> <设备型号> <设备供应商> <设备ID>   - 根据设备信息获取DID")
-	fmt.Println("  reset <DID>                                - 重置设备风险评分")
 	fmt.Println("  risk <DID>                                 - 获取设备风险响应策略")
+	fmt.Println("  resolve <DID>                              - 解析设备DID文档")
+	fmt.Println("  services <DID> <服务端点JSON数组>          - 更新设备DID文档中的服务端点")
+	fmt.Println("  authenticate <DID>                         - 使用设备私钥完成挑战-响应认证")
+	fmt.Println("  issue <DID>                                - 签发设备可验证凭证")
+	fmt.Println("  present <DID>                              - 出示设备凭证并由链上验证")
+	fmt.Println("  revoke <DID> <吊销原因>                    - 吊销设备凭证")
+	fmt.Println("  connect <DID>                              - 设备建立连接（按风险等级检查连接资格）")
+	fmt.Println("  disconnect <DID> [会话ID]                  - 设备断开连接，默认断开当前会话")
+	fmt.Println("  suspend <DID> <原因>                       - 暂停设备")
+	fmt.Println("  reactivate <DID> <原因>                    - 恢复已暂停的设备")
+	fmt.Println("  decommission <DID> <原因>                  - 退役设备（不可恢复）")
+	fmt.Println("  replace <旧DID> <新DID> <原因>             - 用新设备替换旧设备")
+	fmt.Println("  history <DID>                              - 查看设备信息的变更历史")
+	fmt.Println("  firmware <DID> <固件版本> <固件文件>       - 证明设备固件，按供应商固件白名单核验")
+	fmt.Println("  config <DID> <配置文件>                    - 记录设备配置文件的哈希")
+	fmt.Println("  whoami                                     - 查询当前客户端身份的链上角色")
+	fmt.Println("  role assign <MSP ID> <证书CN> <角色>       - 为身份分配角色（admin/oracle/device）")
+	fmt.Println("  role revoke <MSP ID> <证书CN>              - 撤销身份的角色绑定")
 	fmt.Println("  exit                                       - 退出程序")
 }
\ No newline at end of file
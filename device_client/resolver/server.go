@@ -0,0 +1,128 @@
+package resolver
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 解析接口路径前缀，与 Universal Resolver 的 REST 接口一致
+const identifiersPath = "/1.0/identifiers/"
+
+// 内容类型常量
+const (
+	contentTypeResolution = `application/ld+json;profile="https://w3id.org/did-resolution"`
+	contentTypeDIDLD      = "application/did+ld+json"
+)
+
+// didPattern 设备DID格式，第一版后缀为16位十六进制字符，第二版后缀为 z 开头的 base58btc 多重哈希
+var didPattern = regexp.MustCompile(`^did:ieee:device:([0-9a-f]{16}|z[1-9A-HJ-NP-Za-km-z]{46})$`)
+
+// DIDSource DID数据来源，由设备客户端实现
+type DIDSource interface {
+	DeviceExists(did string) (bool, error)
+	ResolveDID(did string) ([]byte, error)
+}
+
+// Server 本地DID解析服务
+type Server struct {
+	source DIDSource
+}
+
+// NewServer 创建新的DID解析服务
+func NewServer(source DIDSource) *Server {
+	return &Server{source: source}
+}
+
+// ListenAndServe 在指定地址启动HTTP解析服务
+func (s *Server) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(identifiersPath, s.handleResolve)
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	log.Printf("DID解析服务已启动，监听地址: %s，接口: GET %s{did}", addr, identifiersPath)
+	return server.ListenAndServe()
+}
+
+// handleResolve 处理 GET /1.0/identifiers/{did}
+func (s *Server) handleResolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "仅支持GET请求", http.StatusMethodNotAllowed)
+		return
+	}
+
+	did := strings.TrimPrefix(r.URL.Path, identifiersPath)
+	if !strings.HasPrefix(did, "did:") {
+		writeError(w, http.StatusBadRequest, "invalidDid")
+		return
+	}
+	if !strings.HasPrefix(did, "did:ieee:") {
+		writeError(w, http.StatusNotImplemented, "methodNotSupported")
+		return
+	}
+	if !didPattern.MatchString(did) {
+		writeError(w, http.StatusBadRequest, "invalidDid")
+		return
+	}
+
+	exists, err := s.source.DeviceExists(did)
+	if err != nil {
+		log.Printf("检查设备 %s 是否存在失败: %v", did, err)
+		writeError(w, http.StatusInternalServerError, "internalError")
+		return
+	}
+	if !exists {
+		writeError(w, http.StatusNotFound, "notFound")
+		return
+	}
+
+	result, err := s.source.ResolveDID(did)
+	if err != nil {
+		log.Printf("解析设备DID %s 失败: %v", did, err)
+		writeError(w, http.StatusInternalServerError, "internalError")
+		return
+	}
+
+	// 请求DID文档格式时只返回文档本身，否则返回完整的解析结果
+	if strings.Contains(r.Header.Get("Accept"), contentTypeDIDLD) {
+		var resolution struct {
+			DIDDocument json.RawMessage `json:"didDocument"`
+		}
+		if err := json.Unmarshal(result, &resolution); err != nil {
+			log.Printf("解析设备DID %s 的解析结果失败: %v", did, err)
+			writeError(w, http.StatusInternalServerError, "internalError")
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeDIDLD)
+		w.Write(resolution.DIDDocument)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypeResolution)
+	w.Write(result)
+}
+
+// writeError 按 DID Resolution 规范返回错误
+func writeError(w http.ResponseWriter, status int, code string) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"didDocument":         nil,
+		"didDocumentMetadata": map[string]interface{}{},
+		"didResolutionMetadata": map[string]string{
+			"error": code,
+		},
+	})
+
+	w.Header().Set("Content-Type", contentTypeResolution)
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -1,11 +1,15 @@
 package chain
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
 )
 
+// ErrConflict 设备在读取后被其他交易修改（MVCC读冲突或最后更新时间前置条件不满足），重新读取设备后可以重试
+var ErrConflict = errors.New("设备状态已被并发修改")
+
 // ChainManager 区块链管理器
 type ChainManager struct {
 	chainClient ChainClient
@@ -21,15 +25,148 @@ type Device struct {
 	AttackIndexI  float64   `json:"attackIndexI"`
 	AttackProfile []string  `json:"attackProfile"`
 	LastEventTime time.Time `json:"lastEventTime"`
+	LastDecayTime time.Time `json:"lastDecayTime"`
+	ScoringProfileVersion int `json:"scoringProfileVersion"`
 	Status        string    `json:"status"`
+	ConnectionStatus string `json:"connectionStatus"`
+	SessionID     string    `json:"sessionId"`
 	CreatedAt     time.Time `json:"createdAt"`
 	LastUpdatedAt time.Time `json:"lastUpdatedAt"`
 }
 
+// RiskEvent 链上风险事件记录
+type RiskEvent struct {
+	DID               string    `json:"did"`
+	TxID              string    `json:"txId"`
+	Timestamp         time.Time `json:"timestamp"`
+	BehaviorType      string    `json:"behaviorType"`
+	Category          string    `json:"category"`
+	BaseScore         float64   `json:"baseScore"`
+	Weight            float64   `json:"weight"`
+	RuleVersion       int       `json:"ruleVersion"`
+	ScoringProfileVersion int   `json:"scoringProfileVersion"`
+	TierPolicyVersion int       `json:"tierPolicyVersion"`
+	ScoreBefore       float64   `json:"scoreBefore"`
+	ScoreAfter        float64   `json:"scoreAfter"`
+	AttackIndexBefore float64   `json:"attackIndexBefore"`
+	AttackIndexAfter  float64   `json:"attackIndexAfter"`
+	Tier              string    `json:"tier"`
+	HoneypointID      string    `json:"honeypointId"`
+	Reporter          string    `json:"reporter"`
+	EvidenceHash      string    `json:"evidenceHash"`
+}
+
+// RiskEventPage 链上风险事件分页查询结果
+type RiskEventPage struct {
+	Events   []*RiskEvent `json:"events"`
+	Bookmark string       `json:"bookmark"`
+}
+
+// DeviceHistoryEntry 链上设备信息的一个历史版本
+type DeviceHistoryEntry struct {
+	TxID      string        `json:"txId"`
+	Timestamp time.Time     `json:"timestamp"`
+	IsDelete  bool          `json:"isDelete"`
+	Device    *Device       `json:"device"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// FieldChange 设备信息字段的变化，新旧值均为字段的JSON编码
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+// AddressResolution 链上网络地址解析结果
+type AddressResolution struct {
+	DID       string `json:"did"`
+	MatchedBy string `json:"matchedBy"`
+	Subnet    *struct {
+		CIDR string `json:"cidr"`
+	} `json:"subnet"`
+}
+
+// 地址解析匹配方式常量
+const (
+	AddressMatchEdge = "edge" // 地址未绑定，归属到所在子网的边缘设备
+)
+
+// 设备状态常量
+const (
+	StatusDecommissioned = "decommissioned" // 设备已退役，不再参与风险评估和维护
+	StatusOnline         = "online"         // 设备在线（连接状态）
+)
+
+// RiskRule 链上风险规则结构体
+type RiskRule struct {
+	BehaviorType string    `json:"behaviorType"`
+	Category     string    `json:"category"`
+	Score        float64   `json:"score"`
+	Weight       float64   `json:"weight"`
+	Description  string    `json:"description"`
+	Version      int       `json:"version"`
+	Status       string    `json:"status"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// RiskTier 链上风险等级
+type RiskTier struct {
+	Name             string   `json:"name"`
+	Label            string   `json:"label"`
+	MinScore         float64  `json:"minScore"`
+	MaxScore         float64  `json:"maxScore"`
+	DeviceStatus     string   `json:"deviceStatus"`
+	AllowConnect     bool     `json:"allowConnect"`
+	ConnectionNotice string   `json:"connectionNotice"`
+	Strategy         string   `json:"strategy"`
+	Measures         []string `json:"measures"`
+}
+
+// RiskTierPolicy 链上风险等级策略
+type RiskTierPolicy struct {
+	Version int        `json:"version"`
+	Tiers   []RiskTier `json:"tiers"`
+}
+
+// TierForScore 根据风险评分查找所属风险等级，与链码的等级划分规则一致
+func (p *RiskTierPolicy) TierForScore(score float64) *RiskTier {
+	last := len(p.Tiers) - 1
+	for i := range p.Tiers {
+		tier := &p.Tiers[i]
+		if score >= tier.MinScore && (score < tier.MaxScore || i == last) {
+			return tier
+		}
+	}
+	return nil
+}
+
+// ScoringProfile 链上风险评分配置
+type ScoringProfile struct {
+	Version int `json:"version"`
+	Params  struct {
+		MaxScore  float64 `json:"maxScore"`
+		Delta     float64 `json:"delta"`
+		Alpha     float64 `json:"alpha"`
+		Lambda    float64 `json:"lambda"`
+		VetoScore float64 `json:"vetoScore"`
+	} `json:"params"`
+	TierPolicyVersion int       `json:"tierPolicyVersion"`
+	Description       string    `json:"description"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
 // ChainClient 区块链客户端接口
 type ChainClient interface {
 	GetDeviceInfo(did string) (*Device, error)
-	UpdateDeviceRiskScore(did string, riskScore float64, attackIndexI float64, attackProfile []string) error
+	ReportRiskBehavior(did string, behaviorType string, evidenceHash string, honeypointID string, expectedLastUpdatedAt time.Time) (*Device, error)
+	DecayAttackIndex(did string, expectedLastUpdatedAt time.Time) (*Device, error)
+	ListRiskRules() ([]RiskRule, error)
+	GetRiskTierPolicy() (*RiskTierPolicy, error)
+	GetScoringProfile() (*ScoringProfile, error)
+	GetRiskEventHistory(did, from, to string, pageSize int, bookmark string) (*RiskEventPage, error)
+	GetDeviceHistory(did string) ([]DeviceHistoryEntry, error)
+	ResolveAddress(ip, mac string, vlan int, at time.Time) (*AddressResolution, error)
 }
 
 // NewChainManager 创建新的区块链管理器
@@ -44,27 +181,64 @@ func (m *ChainManager) GetDeviceFromChain(did string) (*Device, error) {
 	return m.chainClient.GetDeviceInfo(did)
 }
 
-// UpdateDeviceAttackIndex 更新设备攻击画像指数
-func (m *ChainManager) UpdateDeviceAttackIndex(did string, attackIndexI float64) error {
-	// 先从链上获取设备信息
-	device, err := m.GetDeviceFromChain(did)
+// ListRiskRules 从区块链获取所有生效的风险规则
+func (m *ChainManager) ListRiskRules() ([]RiskRule, error) {
+	return m.chainClient.ListRiskRules()
+}
+
+// GetRiskTierPolicy 从区块链获取风险等级策略
+func (m *ChainManager) GetRiskTierPolicy() (*RiskTierPolicy, error) {
+	return m.chainClient.GetRiskTierPolicy()
+}
+
+// GetScoringProfile 从区块链获取当前生效的风险评分配置
+func (m *ChainManager) GetScoringProfile() (*ScoringProfile, error) {
+	return m.chainClient.GetScoringProfile()
+}
+
+// GetRiskEventHistory 从区块链分页查询设备的风险事件记录
+func (m *ChainManager) GetRiskEventHistory(did, from, to string, pageSize int, bookmark string) (*RiskEventPage, error) {
+	page, err := m.chainClient.GetRiskEventHistory(did, from, to, pageSize, bookmark)
 	if err != nil {
-		return fmt.Errorf("获取设备信息失败: %w", err)
+		return nil, fmt.Errorf("查询风险事件记录失败: %w", err)
 	}
 	
-	// 更新攻击画像指数
-	return m.chainClient.UpdateDeviceRiskScore(did, device.RiskScore, attackIndexI, device.AttackProfile)
+	return page, nil
 }
 
-// ResetDeviceRiskData 重置设备风险数据
-func (m *ChainManager) ResetDeviceRiskData(did string) error {
-	// 重置链上设备风险评分和攻击画像
-	emptyProfile := make([]string, 0)
-	err := m.chainClient.UpdateDeviceRiskScore(did, 0.0, 0.0, emptyProfile)
+// GetDeviceHistory 从区块链获取设备信息的全部历史版本
+func (m *ChainManager) GetDeviceHistory(did string) ([]DeviceHistoryEntry, error) {
+	history, err := m.chainClient.GetDeviceHistory(did)
 	if err != nil {
-		return fmt.Errorf("重置设备风险评分失败: %w", err)
+		return nil, fmt.Errorf("查询设备历史失败: %w", err)
 	}
 	
-	log.Printf("已成功重置设备 %s 的风险数据", did)
-	return nil
+	return history, nil
+}
+
+// ResolveAddress 将网络地址解析为设备DID，at 为观察到该地址的时间
+func (m *ChainManager) ResolveAddress(ip, mac string, vlan int, at time.Time) (*AddressResolution, error) {
+	return m.chainClient.ResolveAddress(ip, mac, vlan, at)
+}
+
+// ReportRiskBehavior 向链上上报设备风险行为，风险评分由链码计算
+// expectedLastUpdatedAt 为上报前读取到的设备最后更新时间，设备已被修改时返回 ErrConflict，零值表示不检查
+func (m *ChainManager) ReportRiskBehavior(did string, behaviorType string, evidenceHash string, honeypointID string, expectedLastUpdatedAt time.Time) (*Device, error) {
+	device, err := m.chainClient.ReportRiskBehavior(did, behaviorType, evidenceHash, honeypointID, expectedLastUpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("上报风险行为失败: %w", err)
+	}
+	
+	log.Printf("已向链上上报设备 %s 的风险行为 %s", did, behaviorType)
+	return device, nil
+}
+
+// DecayDeviceAttackIndex 触发链上设备攻击画像指数的慢速衰减，expectedLastUpdatedAt 与 ReportRiskBehavior 相同
+func (m *ChainManager) DecayDeviceAttackIndex(did string, expectedLastUpdatedAt time.Time) (*Device, error) {
+	device, err := m.chainClient.DecayAttackIndex(did, expectedLastUpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("衰减攻击画像指数失败: %w", err)
+	}
+	
+	return device, nil
 }
@@ -2,13 +2,24 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/status"
+
 	"github.com/Tittifer/IEEE/honeypoint_client/chain"
 )
 
+// preconditionFailedMessage 链码检查设备最后更新时间不一致时返回的错误信息
+const preconditionFailedMessage = "设备版本前置条件不满足"
+
 // ChainClient 区块链客户端，实现chain.ChainClient接口
 type ChainClient struct {
 	honeypointClient *HoneypointClient
@@ -32,6 +43,190 @@ func (c *ChainClient) GetDeviceInfo(did string) (*chain.Device, error) {
 		return nil, fmt.Errorf("设备DID %s 不存在", did)
 	}
 
+	return parseDevice(deviceJSON)
+}
+
+// ReportRiskBehavior 向链上上报设备风险行为，由链码计算风险评分
+func (c *ChainClient) ReportRiskBehavior(did string, behaviorType string, evidenceHash string, honeypointID string, expectedLastUpdatedAt time.Time) (*chain.Device, error) {
+	// 调用链码上报风险行为，附带蜜点ID以便追溯
+	deviceJSON, err := c.honeypointClient.contract.SubmitTransaction(
+		riskContract+":ReportRiskBehavior",
+		did,
+		behaviorType,
+		evidenceHash,
+		honeypointID,
+		formatPrecondition(expectedLastUpdatedAt),
+	)
+	if err != nil {
+		return nil, submitError(err)
+	}
+
+	device, err := parseDevice(deviceJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("链上已更新设备 %s 的风险评分为 %.2f，攻击画像指数为 %.2f", did, device.RiskScore, device.AttackIndexI)
+	return device, nil
+}
+
+// GetRiskEventHistory 从链上分页查询设备的风险事件记录
+func (c *ChainClient) GetRiskEventHistory(did, from, to string, pageSize int, bookmark string) (*chain.RiskEventPage, error) {
+	pageJSON, err := c.honeypointClient.contract.EvaluateTransaction(
+		riskContract+":GetRiskEventHistory",
+		did,
+		from,
+		to,
+		strconv.Itoa(pageSize),
+		bookmark,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var page chain.RiskEventPage
+	if err := json.Unmarshal(pageJSON, &page); err != nil {
+		return nil, fmt.Errorf("风险事件解析失败: %w", err)
+	}
+
+	return &page, nil
+}
+
+// GetDeviceHistory 从链上获取设备信息的全部历史版本，按时间升序排列
+func (c *ChainClient) GetDeviceHistory(did string) ([]chain.DeviceHistoryEntry, error) {
+	historyJSON, err := c.honeypointClient.contract.EvaluateTransaction(identityContract+":GetDeviceHistory", did)
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var history []chain.DeviceHistoryEntry
+	if err := json.Unmarshal(historyJSON, &history); err != nil {
+		return nil, fmt.Errorf("设备历史解析失败: %w", err)
+	}
+
+	return history, nil
+}
+
+// DecayAttackIndex 调用链码对设备攻击画像指数执行衰减
+func (c *ChainClient) DecayAttackIndex(did string, expectedLastUpdatedAt time.Time) (*chain.Device, error) {
+	deviceJSON, err := c.honeypointClient.contract.SubmitTransaction(riskContract+":DecayAttackIndex", did, formatPrecondition(expectedLastUpdatedAt))
+	if err != nil {
+		return nil, submitError(err)
+	}
+
+	return parseDevice(deviceJSON)
+}
+
+// ListRiskRules 从链上规则库获取所有生效的风险规则
+func (c *ChainClient) ListRiskRules() ([]chain.RiskRule, error) {
+	rulesJSON, err := c.honeypointClient.contract.EvaluateTransaction(riskContract + ":ListRiskRules")
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var rules []chain.RiskRule
+	if len(rulesJSON) == 0 {
+		return rules, nil
+	}
+	if err := json.Unmarshal(rulesJSON, &rules); err != nil {
+		return nil, fmt.Errorf("风险规则解析失败: %w", err)
+	}
+
+	return rules, nil
+}
+
+// GetRiskTierPolicy 从链上获取风险等级策略
+func (c *ChainClient) GetRiskTierPolicy() (*chain.RiskTierPolicy, error) {
+	policyJSON, err := c.honeypointClient.contract.EvaluateTransaction(riskContract + ":GetRiskTierPolicy")
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var policy chain.RiskTierPolicy
+	if err := json.Unmarshal(policyJSON, &policy); err != nil {
+		return nil, fmt.Errorf("风险等级策略解析失败: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// GetScoringProfile 从链上获取当前生效的风险评分配置
+func (c *ChainClient) GetScoringProfile() (*chain.ScoringProfile, error) {
+	profileJSON, err := c.honeypointClient.contract.EvaluateTransaction(riskContract + ":GetScoringProfile")
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var profile chain.ScoringProfile
+	if err := json.Unmarshal(profileJSON, &profile); err != nil {
+		return nil, fmt.Errorf("风险评分配置解析失败: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// ResolveAddress 按链上地址绑定将网络地址解析为设备DID
+func (c *ChainClient) ResolveAddress(ip, mac string, vlan int, at time.Time) (*chain.AddressResolution, error) {
+	atStr := ""
+	if !at.IsZero() {
+		atStr = at.UTC().Format(time.RFC3339)
+	}
+	resolutionJSON, err := c.honeypointClient.contract.EvaluateTransaction(
+		identityContract+":ResolveAddress",
+		ip,
+		mac,
+		strconv.Itoa(vlan),
+		atStr,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("评估交易失败: %w", err)
+	}
+
+	var resolution chain.AddressResolution
+	if err := json.Unmarshal(resolutionJSON, &resolution); err != nil {
+		return nil, fmt.Errorf("地址解析结果解析失败: %w", err)
+	}
+
+	return &resolution, nil
+}
+
+// formatPrecondition 将期望的设备最后更新时间转换为链码参数，零值表示不检查
+func formatPrecondition(expectedLastUpdatedAt time.Time) string {
+	if expectedLastUpdatedAt.IsZero() {
+		return ""
+	}
+	return expectedLastUpdatedAt.Format(time.RFC3339Nano)
+}
+
+// submitError 包装提交交易的错误，MVCC读冲突和设备版本前置条件不满足包装为 chain.ErrConflict
+func submitError(err error) error {
+	if isConflict(err) {
+		return fmt.Errorf("%w: %v", chain.ErrConflict, err)
+	}
+	return fmt.Errorf("提交交易失败: %w", err)
+}
+
+// isConflict 判断交易是否因设备已被其他交易修改而失败
+// 读冲突在提交时以交易验证码返回，前置条件不满足在背书时由链码返回，错误信息位于gRPC状态详情中
+func isConflict(err error) bool {
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		return commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT || commitErr.Code == peer.TxValidationCode_PHANTOM_READ_CONFLICT
+	}
+
+	if strings.Contains(err.Error(), preconditionFailedMessage) {
+		return true
+	}
+	for _, detail := range status.Convert(err).Details() {
+		if errorDetail, ok := detail.(*gateway.ErrorDetail); ok && strings.Contains(errorDetail.Message, preconditionFailedMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDevice 解析链码返回的设备信息
+func parseDevice(deviceJSON []byte) (*chain.Device, error) {
 	// 解析设备信息
 	var deviceInfo struct {
 		DID           string    `json:"did"`
@@ -42,7 +237,11 @@ func (c *ChainClient) GetDeviceInfo(did string) (*chain.Device, error) {
 		AttackIndexI  float64   `json:"attackIndexI"`
 		AttackProfile []string  `json:"attackProfile"`
 		LastEventTime time.Time `json:"lastEventTime"`
+		LastDecayTime time.Time `json:"lastDecayTime"`
+		ScoringProfileVersion int `json:"scoringProfileVersion"`
 		Status        string    `json:"status"`
+		ConnectionStatus string `json:"connectionStatus"`
+		SessionID     string    `json:"sessionId"`
 		CreatedAt     time.Time `json:"createdAt"`
 		LastUpdatedAt time.Time `json:"lastUpdatedAt"`
 	}
@@ -60,38 +259,14 @@ func (c *ChainClient) GetDeviceInfo(did string) (*chain.Device, error) {
 		AttackIndexI:  deviceInfo.AttackIndexI,
 		AttackProfile: deviceInfo.AttackProfile,
 		LastEventTime: deviceInfo.LastEventTime,
+		LastDecayTime: deviceInfo.LastDecayTime,
+		ScoringProfileVersion: deviceInfo.ScoringProfileVersion,
 		Status:        deviceInfo.Status,
+		ConnectionStatus: deviceInfo.ConnectionStatus,
+		SessionID:     deviceInfo.SessionID,
 		CreatedAt:     deviceInfo.CreatedAt,
 		LastUpdatedAt: deviceInfo.LastUpdatedAt,
 	}
 
 	return device, nil
 }
-
-// UpdateDeviceRiskScore 更新设备风险评分
-func (c *ChainClient) UpdateDeviceRiskScore(did string, riskScore float64, attackIndexI float64, attackProfile []string) error {
-	// 将浮点数转换为字符串
-	riskScoreStr := fmt.Sprintf("%.2f", riskScore)
-	attackIndexStr := fmt.Sprintf("%.2f", attackIndexI)
-
-	// 序列化攻击画像
-	attackProfileJSON, err := json.Marshal(attackProfile)
-	if err != nil {
-		return fmt.Errorf("攻击画像序列化失败: %w", err)
-	}
-
-	// 调用链码更新设备风险评分
-	_, err = c.honeypointClient.contract.SubmitTransaction(
-		"IdentityContract:UpdateDeviceRiskScore",
-		did,
-		riskScoreStr,
-		attackIndexStr,
-		string(attackProfileJSON),
-	)
-	if err != nil {
-		return fmt.Errorf("提交交易失败: %w", err)
-	}
-
-	log.Printf("已更新设备 %s 的风险评分为 %.2f，攻击画像指数为 %.2f", did, riskScore, attackIndexI)
-	return nil
-}
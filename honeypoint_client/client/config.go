@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+
+	"github.com/Tittifer/IEEE/honeypoint_client/ingest"
 )
 
 // ConnectionConfig 连接配置
@@ -19,6 +21,10 @@ type ConnectionConfig struct {
 	GatewayPeer   string `json:"gatewayPeer"`
 	ChannelName   string `json:"channelName"`
 	ChaincodeName string `json:"chaincodeName"`
+	HoneypointID  string `json:"honeypointID,omitempty"` // 蜜点ID，随风险行为一起上链，为空时使用主机名
+	Ingest        *ingest.Config `json:"ingest,omitempty"`  // 告警接入服务配置，以守护进程模式运行时使用
+	CheckpointPath  string  `json:"checkpointPath,omitempty"`  // 链码事件检查点文件，为空时使用 event_checkpoint.json，已处理交易记录保存在加 .handled 后缀的文件中
+	EventStartBlock *uint64 `json:"eventStartBlock,omitempty"` // 首次启动（尚无检查点）时接收事件的起始区块，为空时从最新区块开始
 }
 
 // LoadConfig 从文件加载配置
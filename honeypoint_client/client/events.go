@@ -0,0 +1,334 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// 事件监听重试间隔
+const (
+	reconnectDelay       = 5 * time.Second // 事件流断开后重新连接的间隔
+	handlerRetryDelay    = time.Second     // 事件处理失败后首次重试的间隔
+	maxHandlerRetryDelay = time.Minute     // 事件处理失败后重试间隔的上限
+)
+
+// handledTxCapacity 已处理交易记录保留的交易ID数量上限
+const handledTxCapacity = 4096
+
+// handledTxSuffix 已处理交易记录文件相对检查点文件的后缀
+const handledTxSuffix = ".handled"
+
+// EventHandler 链码事件处理函数
+// 返回错误表示暂时性失败，事件会被重试直到处理成功；无法处理的事件（如数据格式错误）应记录日志并返回nil，否则会阻塞后续事件。
+// 事件的所有处理函数成功后，交易ID先同步写入已处理交易记录，再推进检查点；重启或重连后重新收到的已记录事件不再交给处理函数，
+// 每个事件的处理函数只完整执行一次。程序恰好在处理函数返回与写入记录之间退出时，该事件会再执行一次，
+// 因此处理函数只能有可重复执行的副作用（记录日志、使缓存失效、查询链上状态），不能提交交易。
+type EventHandler func(event *client.ChaincodeEvent) error
+
+// HandleEvent 为指定名称的链码事件注册处理函数，同一事件的多个处理函数按注册顺序执行
+func (c *HoneypointClient) HandleEvent(eventName string, handler EventHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+
+	c.handlers[eventName] = append(c.handlers[eventName], handler)
+}
+
+// registerEventHandlers 注册蜜点后台关注的链码事件
+func (c *HoneypointClient) registerEventHandlers() {
+	c.HandleEvent("DeviceRegistered", c.handleDeviceRegistered)
+	c.HandleEvent("DevicesRegistered", c.handleDeviceRegistered)
+	c.HandleEvent("RiskScoreUpdated", c.handleRiskScoreUpdated)
+	c.HandleEvent("RiskScoreReset", c.handleRiskScoreReset)
+	c.HandleEvent("RiskRuleUpdated", c.handleRiskRuleUpdated)
+	c.HandleEvent("RiskRulesSeeded", c.handleRiskRuleUpdated)
+	c.HandleEvent("RiskTierPolicyUpdated", c.handleRiskTierPolicyUpdated)
+	c.HandleEvent("ScoringProfileUpdated", c.handleScoringProfileUpdated)
+	c.HandleEvent("DeviceConnected", c.handleDeviceSession)
+	c.HandleEvent("DeviceDisconnected", c.handleDeviceSession)
+}
+
+// eventHandlers 返回指定事件的处理函数
+func (c *HoneypointClient) eventHandlers(eventName string) []EventHandler {
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+
+	return c.handlers[eventName]
+}
+
+// listenForChaincodeEvents 通过单个事件流接收链码事件并按检查点续传，事件流断开时自动重连
+func (c *HoneypointClient) listenForChaincodeEvents() {
+	defer close(c.listenerDone)
+
+	for {
+		if err := c.consumeChaincodeEvents(); err != nil {
+			log.Printf("链码事件监听中断: %v", err)
+		}
+
+		select {
+		case <-c.stopChan:
+			return
+		case <-time.After(reconnectDelay):
+			log.Printf("重新连接链码事件流，从区块 %d 继续", c.checkpointer.BlockNumber())
+		}
+	}
+}
+
+// consumeChaincodeEvents 打开事件流并依次处理事件，直到事件流关闭或客户端停止
+func (c *HoneypointClient) consumeChaincodeEvents() error {
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	// 尚无检查点时从配置的起始区块开始，已有检查点时检查点优先
+	var options []client.ChaincodeEventsOption
+	if c.config.EventStartBlock != nil {
+		options = append(options, client.WithStartBlock(*c.config.EventStartBlock))
+	}
+	options = append(options, client.WithCheckpoint(c.checkpointer))
+
+	events, err := c.network.ChaincodeEvents(ctx, c.config.ChaincodeName, options...)
+	if err != nil {
+		return fmt.Errorf("打开链码事件流失败: %w", err)
+	}
+
+	for event := range events {
+		if c.handledTxs.contains(event.TransactionID) {
+			log.Printf("跳过已处理的链码事件 %s (区块 %d, 交易 %s)", event.EventName, event.BlockNumber, event.TransactionID)
+		} else {
+			if !c.dispatchEvent(event) {
+				return nil // 客户端已停止，不推进检查点
+			}
+			if err := c.handledTxs.record(event.TransactionID); err != nil {
+				return fmt.Errorf("保存已处理交易记录失败: %w", err)
+			}
+		}
+
+		if err := c.checkpointer.CheckpointChaincodeEvent(event); err != nil {
+			return fmt.Errorf("保存事件检查点失败: %w", err)
+		}
+		if err := c.checkpointer.Sync(); err != nil {
+			return fmt.Errorf("同步事件检查点文件失败: %w", err)
+		}
+	}
+
+	if c.ctx.Err() != nil {
+		return nil // 客户端停止时事件流随上下文关闭
+	}
+	return fmt.Errorf("链码事件流已关闭")
+}
+
+// dispatchEvent 依次执行事件的处理函数，失败的处理函数按退避间隔重试，已成功的处理函数不会重复执行
+// 客户端在事件处理完成前停止时返回false
+func (c *HoneypointClient) dispatchEvent(event *client.ChaincodeEvent) bool {
+	for _, handler := range c.eventHandlers(event.EventName) {
+		delay := handlerRetryDelay
+		for {
+			err := handler(event)
+			if err == nil {
+				break
+			}
+			log.Printf("处理链码事件 %s 失败 (区块 %d, 交易 %s)，%s后重试: %v", event.EventName, event.BlockNumber, event.TransactionID, delay, err)
+
+			select {
+			case <-c.stopChan:
+				return false
+			case <-time.After(delay):
+			}
+
+			delay *= 2
+			if delay > maxHandlerRetryDelay {
+				delay = maxHandlerRetryDelay
+			}
+		}
+	}
+	return true
+}
+
+// txSet 记录最近处理过的交易ID，超出容量时淘汰最早的记录
+type txSet struct {
+	mu    sync.Mutex
+	seen  map[string]struct{}
+	order []string
+	next  int
+}
+
+// newTxSet 创建容量为 capacity 的交易ID集合
+func newTxSet(capacity int) *txSet {
+	return &txSet{
+		seen:  make(map[string]struct{}, capacity),
+		order: make([]string, 0, capacity),
+	}
+}
+
+// add 记录交易ID，交易ID已存在时返回false
+func (s *txSet) add(txID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[txID]; ok {
+		return false
+	}
+
+	if len(s.order) < cap(s.order) {
+		s.order = append(s.order, txID)
+	} else {
+		delete(s.seen, s.order[s.next])
+		s.order[s.next] = txID
+		s.next = (s.next + 1) % len(s.order)
+	}
+	s.seen[txID] = struct{}{}
+	return true
+}
+
+// contains 判断交易ID是否已记录
+func (s *txSet) contains(txID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.seen[txID]
+	return ok
+}
+
+// list 按记录顺序从早到晚返回集合中的交易ID
+func (s *txSet) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	txIDs := make([]string, 0, len(s.order))
+	txIDs = append(txIDs, s.order[s.next:]...)
+	return append(txIDs, s.order[:s.next]...)
+}
+
+// handledTxLog 持久化的已处理交易记录，与事件检查点一起决定重启后哪些事件不再处理
+// 文件每行一个交易ID，只追加写入并在每次写入后同步到磁盘，行数超过容量的两倍时压缩为最近的 capacity 条
+type handledTxLog struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	txs      *txSet
+	capacity int
+	lines    int
+}
+
+// openHandledTxLog 打开已处理交易记录文件，文件不存在时创建
+func openHandledTxLog(path string, capacity int) (*handledTxLog, error) {
+	l := &handledTxLog{path: path, txs: newTxSet(capacity), capacity: capacity}
+
+	file, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("打开已处理交易记录文件失败: %w", err)
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if txID := strings.TrimSpace(scanner.Text()); txID != "" {
+				l.txs.add(txID)
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("读取已处理交易记录文件失败: %w", err)
+		}
+	}
+
+	if err := l.compact(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// contains 判断交易的事件是否已处理
+func (l *handledTxLog) contains(txID string) bool {
+	return l.txs.contains(txID)
+}
+
+// record 记录已处理的交易ID，返回前写入的记录已同步到磁盘
+func (l *handledTxLog) record(txID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.txs.add(txID) {
+		return nil
+	}
+	if _, err := l.file.WriteString(txID + "\n"); err != nil {
+		return fmt.Errorf("写入已处理交易记录失败: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("同步已处理交易记录文件失败: %w", err)
+	}
+
+	l.lines++
+	if l.lines >= 2*l.capacity {
+		return l.compactLocked()
+	}
+	return nil
+}
+
+// Close 关闭记录文件
+func (l *handledTxLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// compact 将记录文件重写为内存中保留的交易ID
+func (l *handledTxLog) compact() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.compactLocked()
+}
+
+// compactLocked 先写入临时文件并同步，再替换原文件，替换过程中退出不会丢失已有记录
+func (l *handledTxLog) compactLocked() error {
+	txIDs := l.txs.list()
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("创建已处理交易记录临时文件失败: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, txID := range txIDs {
+		writer.WriteString(txID + "\n")
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入已处理交易记录临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("同步已处理交易记录临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭已处理交易记录临时文件失败: %w", err)
+	}
+
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return fmt.Errorf("替换已处理交易记录文件失败: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("打开已处理交易记录文件失败: %w", err)
+	}
+	l.file = file
+	l.lines = len(txIDs)
+	return nil
+}
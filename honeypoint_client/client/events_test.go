@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTxSet(t *testing.T) {
+	set := newTxSet(3)
+	for _, txID := range []string{"tx-1", "tx-2", "tx-3"} {
+		if !set.add(txID) {
+			t.Fatalf("add(%s) = false", txID)
+		}
+	}
+	if set.add("tx-2") {
+		t.Error("重复的交易ID应返回false")
+	}
+
+	// 超出容量时淘汰最早的记录
+	set.add("tx-4")
+	set.add("tx-5")
+	if set.contains("tx-1") || set.contains("tx-2") {
+		t.Error("最早的交易ID未被淘汰")
+	}
+	if got, want := set.list(), []string{"tx-3", "tx-4", "tx-5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("list() = %v, want %v", got, want)
+	}
+}
+
+func TestHandledTxLogPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event_checkpoint.json"+handledTxSuffix)
+
+	l, err := openHandledTxLog(path, 4)
+	if err != nil {
+		t.Fatalf("openHandledTxLog() error = %v", err)
+	}
+	for _, txID := range []string{"tx-1", "tx-2", "tx-2"} {
+		if err := l.record(txID); err != nil {
+			t.Fatalf("record(%s) error = %v", txID, err)
+		}
+	}
+	// 模拟进程退出，不调用 Close
+	reopened, err := openHandledTxLog(path, 4)
+	if err != nil {
+		t.Fatalf("重新打开 error = %v", err)
+	}
+	defer reopened.Close()
+	l.Close()
+
+	for _, txID := range []string{"tx-1", "tx-2"} {
+		if !reopened.contains(txID) {
+			t.Errorf("重启后未找到已处理的交易 %s", txID)
+		}
+	}
+	if reopened.contains("tx-3") {
+		t.Error("未处理的交易不应被记录")
+	}
+}
+
+func TestHandledTxLogCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event_checkpoint.json"+handledTxSuffix)
+
+	l, err := openHandledTxLog(path, 3)
+	if err != nil {
+		t.Fatalf("openHandledTxLog() error = %v", err)
+	}
+	for i := 1; i <= 7; i++ {
+		if err := l.record(fmt.Sprintf("tx-%d", i)); err != nil {
+			t.Fatalf("record() error = %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// 第6条记录写入后压缩为最近3条，之后追加第7条
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取记录文件失败: %v", err)
+	}
+	if got, want := strings.Fields(string(data)), []string{"tx-4", "tx-5", "tx-6", "tx-7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("记录文件 = %v, want %v", got, want)
+	}
+
+	reopened, err := openHandledTxLog(path, 3)
+	if err != nil {
+		t.Fatalf("重新打开 error = %v", err)
+	}
+	defer reopened.Close()
+	if got, want := reopened.txs.list(), []string{"tx-5", "tx-6", "tx-7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("重新打开后的记录 = %v, want %v", got, want)
+	}
+}
@@ -2,12 +2,18 @@ package client
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
 	"path"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
@@ -16,6 +22,7 @@ import (
 	"google.golang.org/grpc/credentials"
 
 	"github.com/Tittifer/IEEE/honeypoint_client/chain"
+	"github.com/Tittifer/IEEE/honeypoint_client/ingest"
 	"github.com/Tittifer/IEEE/honeypoint_client/risk"
 )
 
@@ -31,29 +38,57 @@ type HoneypointClient struct {
 	network      *client.Network
 	stopChan     chan struct{}
 	isRunning    bool
+	checkpointer *client.FileCheckpointer // 链码事件检查点
+	listenerDone chan struct{}            // 事件监听结束后关闭
+	handlersMu   sync.RWMutex
+	handlers     map[string][]EventHandler // 按事件名称注册的事件处理函数
+	handledTxs   *handledTxLog             // 已处理的链码事件交易，与检查点一起持久化
 	ctx          context.Context
 	cancel       context.CancelFunc
 }
 
 // 设备事件结构体，用于解析链码事件
 type DeviceEvent struct {
-	EventType    string    `json:"eventType"`    // 事件类型
-	DID          string    `json:"did"`          // 设备DID
-	Name         string    `json:"name"`         // 设备名称
-	Timestamp    int64     `json:"timestamp"`    // 事件时间戳
-	RiskScore    float64   `json:"riskScore"`    // 风险评分
-	Category     string    `json:"category"`     // 行为类别
-	BehaviorType string    `json:"behaviorType"` // 具体行为类型
+	EventType     string  `json:"eventType"`     // 事件类型
+	DID           string  `json:"did"`           // 设备DID
+	Name          string  `json:"name"`          // 设备名称
+	Timestamp     int64   `json:"timestamp"`     // 事件时间戳
+	RiskScore     float64 `json:"riskScore"`     // 风险评分
+	Category      string  `json:"category"`      // 行为类别
+	BehaviorType  string  `json:"behaviorType"`  // 具体行为类型
+	SessionID     string  `json:"sessionId"`     // 设备连接会话ID，设备离线时为空
+	SessionClosed bool    `json:"sessionClosed"` // 风险评分进入禁止连接的等级，链码已在同一交易中关闭该会话
+}
+
+// RiskResetEvent 风险评分重置事件数据，即链上的风险评分重置记录
+type RiskResetEvent struct {
+	DID               string    `json:"did"`               // 设备DID
+	Name              string    `json:"name"`              // 设备名称
+	TxID              string    `json:"txId"`              // 重置交易ID
+	Timestamp         time.Time `json:"timestamp"`         // 重置时间
+	Mode              string    `json:"mode"`              // 重置方式: full, soft
+	Reason            string    `json:"reason"`            // 重置原因
+	ApprovedBy        string    `json:"approvedBy"`        // 批准人
+	ApprovalTxID      string    `json:"approvalTxId"`      // 批准重置的交易ID
+	ResetBy           string    `json:"resetBy"`           // 执行重置的身份
+	ScoreBefore       float64   `json:"scoreBefore"`       // 重置前的风险评分
+	AttackIndexBefore float64   `json:"attackIndexBefore"` // 重置前的攻击画像指数
+	AttackIndexAfter  float64   `json:"attackIndexAfter"`  // 重置后的攻击画像指数
+	Status            string    `json:"status"`            // 重置后的设备状态
 }
 
 // 合约名称常量
 const (
-	identityContract   = "IdentityContract"
-	riskContract       = "RiskContract"
-	configPath         = "config.json"
-	riskScoreThreshold = 50.00 // 风险评分阈值
+	identityContract = "IdentityContract"
+	riskContract     = "RiskContract"
+	configPath       = "config.json"
+	devicePageSize   = 200 // 分页查询设备时每页的设备数
+	defaultCheckpointPath = "event_checkpoint.json" // 默认的链码事件检查点文件
 )
 
+// unknownAddressMessage 链码 ResolveAddress 找不到地址对应设备时返回的错误信息
+const unknownAddressMessage = "未找到地址对应的设备"
+
 // NewHoneypointClient 创建新的蜜点后台客户端
 func NewHoneypointClient() (*HoneypointClient, error) {
 	// 加载配置
@@ -62,6 +97,20 @@ func NewHoneypointClient() (*HoneypointClient, error) {
 		return nil, fmt.Errorf("加载配置失败: %w", err)
 	}
 
+	// 未配置事件检查点文件时使用默认路径
+	if config.CheckpointPath == "" {
+		config.CheckpointPath = defaultCheckpointPath
+	}
+
+	// 未配置蜜点ID时使用主机名标识本蜜点
+	if config.HoneypointID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("获取主机名失败: %w", err)
+		}
+		config.HoneypointID = hostname
+	}
+
 	// 创建客户端证书
 	clientCert, err := loadCertificate(config.CertPath)
 	if err != nil {
@@ -138,6 +187,7 @@ func NewHoneypointClient() (*HoneypointClient, error) {
 		config:    config,
 		network:   network,
 		stopChan:  make(chan struct{}),
+		handlers:  make(map[string][]EventHandler),
 		ctx:       ctx,
 		cancel:    cancel,
 	}
@@ -154,6 +204,9 @@ func NewHoneypointClient() (*HoneypointClient, error) {
 	riskAssessor := risk.NewRiskAssessor(chainManager)
 	honeypointClient.riskAssessor = riskAssessor
 
+	// 注册链码事件处理函数
+	honeypointClient.registerEventHandlers()
+
 	return honeypointClient, nil
 }
 
@@ -185,16 +238,26 @@ func (c *HoneypointClient) StartEventListener() error {
 		return fmt.Errorf("事件监听器已经在运行")
 	}
 
-	c.isRunning = true
+	// 打开事件检查点，重启后从上次处理到的位置继续接收事件
+	checkpointer, err := client.NewFileCheckpointer(c.config.CheckpointPath)
+	if err != nil {
+		return fmt.Errorf("打开事件检查点文件失败: %w", err)
+	}
+	c.checkpointer = checkpointer
+
+	// 打开已处理交易记录，重启后跳过处理完成但检查点尚未推进的事件
+	handledTxs, err := openHandledTxLog(c.config.CheckpointPath+handledTxSuffix, handledTxCapacity)
+	if err != nil {
+		checkpointer.Close()
+		return fmt.Errorf("打开已处理交易记录失败: %w", err)
+	}
+	c.handledTxs = handledTxs
+	c.listenerDone = make(chan struct{})
 
-	// 监听设备注册事件
-	go c.listenForDeviceRegistered()
+	c.isRunning = true
 
-	// 监听风险评分更新事件
-	go c.listenForRiskScoreUpdated()
-	
-	// 监听风险评分重置事件
-	go c.listenForRiskScoreReset()
+	// 所有链码事件通过同一个事件流接收，按事件名称分发给处理函数
+	go c.listenForChaincodeEvents()
 
 	// 启动周期性维护任务
 	go c.startPeriodicMaintenance()
@@ -211,85 +274,52 @@ func (c *HoneypointClient) StopEventListener() {
 
 	close(c.stopChan)
 	c.cancel() // 取消上下文，停止所有事件监听
+	<-c.listenerDone
+	c.checkpointer.Close()
+	c.handledTxs.Close()
 	c.isRunning = false
 	log.Println("事件监听器已停止")
 }
 
-// listenForDeviceRegistered 监听设备注册事件
-func (c *HoneypointClient) listenForDeviceRegistered() {
-	log.Println("开始监听设备注册事件...")
-
-	// 使用新的API监听链码事件
-	events, err := c.network.ChaincodeEvents(c.ctx, c.config.ChaincodeName)
-	if err != nil {
-		log.Printf("注册设备注册事件监听失败: %v", err)
-		return
+// handleDeviceRegistered 处理DeviceRegistered和DevicesRegistered事件，批量注册的设备合并在一个事件中
+func (c *HoneypointClient) handleDeviceRegistered(event *client.ChaincodeEvent) error {
+	var deviceEvents []DeviceEvent
+	if event.EventName == "DevicesRegistered" {
+		if err := json.Unmarshal(event.Payload, &deviceEvents); err != nil {
+			log.Printf("解析设备批量注册事件数据失败: %v", err)
+			return nil
+		}
+	} else {
+		var deviceEvent DeviceEvent
+		if err := json.Unmarshal(event.Payload, &deviceEvent); err != nil {
+			log.Printf("解析设备注册事件数据失败: %v", err)
+			return nil
+		}
+		deviceEvents = append(deviceEvents, deviceEvent)
 	}
 
-	for {
-		select {
-		case <-c.stopChan:
-			return
-		case event, ok := <-events:
-			if !ok {
-				return
-			}
-
-			// 只处理DeviceRegistered事件
-			if event.EventName != "DeviceRegistered" {
-				continue
-			}
-
-			// 解析事件数据
-			var deviceEvent DeviceEvent
-			if err := json.Unmarshal(event.Payload, &deviceEvent); err != nil {
-				log.Printf("解析设备注册事件数据失败: %v", err)
-				continue
-			}
+	for _, deviceEvent := range deviceEvents {
+		log.Printf("收到设备注册事件: DID=%s, 名称=%s", deviceEvent.DID, deviceEvent.Name)
 
-			log.Printf("收到设备注册事件: DID=%s, 名称=%s", deviceEvent.DID, deviceEvent.Name)
-			
-			// 设备注册后立即启动风险监控
-			go c.startRiskMonitoring(deviceEvent.DID)
-		}
+		// 设备注册后立即启动风险监控
+		c.startRiskMonitoring(deviceEvent.DID)
 	}
+	return nil
 }
 
-// listenForRiskScoreUpdated 监听风险评分更新事件
-func (c *HoneypointClient) listenForRiskScoreUpdated() {
-	log.Println("开始监听风险评分更新事件...")
-
-	// 使用新的API监听链码事件
-	events, err := c.network.ChaincodeEvents(c.ctx, c.config.ChaincodeName)
-	if err != nil {
-		log.Printf("注册风险评分更新事件监听失败: %v", err)
-		return
+// handleRiskScoreUpdated 处理RiskScoreUpdated事件
+func (c *HoneypointClient) handleRiskScoreUpdated(event *client.ChaincodeEvent) error {
+	var deviceEvent DeviceEvent
+	if err := json.Unmarshal(event.Payload, &deviceEvent); err != nil {
+		log.Printf("解析风险评分更新事件数据失败: %v", err)
+		return nil
 	}
 
-	for {
-		select {
-		case <-c.stopChan:
-			return
-		case event, ok := <-events:
-			if !ok {
-				return
-			}
-
-			// 只处理RiskScoreUpdated事件
-			if event.EventName != "RiskScoreUpdated" {
-				continue
-			}
-
-			// 解析事件数据
-			var deviceEvent DeviceEvent
-			if err := json.Unmarshal(event.Payload, &deviceEvent); err != nil {
-				log.Printf("解析风险评分更新事件数据失败: %v", err)
-				continue
-			}
-
-			log.Printf("收到风险评分更新事件: DID=%s, 名称=%s, 风险评分=%.2f", deviceEvent.DID, deviceEvent.Name, deviceEvent.RiskScore)
-		}
+	log.Printf("收到风险评分更新事件: DID=%s, 名称=%s, 风险评分=%.2f", deviceEvent.DID, deviceEvent.Name, deviceEvent.RiskScore)
+	if deviceEvent.SessionClosed {
+		log.Printf("设备 %s 进入禁止连接的风险等级，链码已关闭其连接会话 %s", deviceEvent.DID, deviceEvent.SessionID)
 	}
+	return nil
 }
 
 // startRiskMonitoring 启动风险监控
@@ -303,88 +333,176 @@ func (c *HoneypointClient) startRiskMonitoring(did string) {
 		return
 	}
 
-	// 列出可用的风险行为（仅用于验证规则加载成功）
-	_ = c.riskAssessor.ListAvailableRiskBehaviors()
-
-	// 日志已禁用，避免输出过多信息
-	// log.Printf("可用的风险行为类型:")
-	// for i, rule := range rules {
-	// 	log.Printf("%d. %s - %s (得分: %.2f, 类别: %s, 权重: %.2f)", i+1, rule.BehaviorType, rule.Description, rule.Score, rule.Category, rule.Weight)
-	// }
+	// 加载链上风险规则（仅用于验证规则加载成功）
+	if _, err := c.riskAssessor.ListAvailableRiskBehaviors(); err != nil {
+		log.Printf("加载风险规则失败: %v", err)
+	}
 
 	log.Printf("设备 %s 已开始风险监控", did)
 }
 
-// ProcessRiskBehavior 处理设备风险行为
-func (c *HoneypointClient) ProcessRiskBehavior(did string, behaviorType string) error {
-	// 评估风险
-	newScore, newAttackIndex, updatedProfile, err := c.riskAssessor.AssessRisk(did, behaviorType)
+// ProcessRiskBehavior 处理本蜜点捕获的设备风险行为
+// evidence 为蜜点捕获的原始证据，仅将其SHA256哈希上链
+func (c *HoneypointClient) ProcessRiskBehavior(did string, behaviorType string, evidence string) error {
+	return c.processRiskBehavior(did, behaviorType, evidence, c.config.HoneypointID)
+}
+
+// ProcessAlert 处理蜜点传感器通过告警接入服务上报的告警，实现 ingest.AlertProcessor 接口
+// 告警未携带DID时按链上地址绑定将源地址解析为设备，未绑定的地址归属到所在子网的边缘设备
+func (c *HoneypointClient) ProcessAlert(alert *ingest.Alert) (*ingest.AlertResult, error) {
+	log.Printf("处理蜜点 %s 的告警: 源IP=%s, 捕获时间=%s", alert.HoneypointID, alert.SourceIP, alert.Timestamp.Format(time.RFC3339))
+
+	result := &ingest.AlertResult{DID: alert.DID, MatchedBy: ingest.MatchedByAlert}
+	if alert.DID == "" {
+		resolution, err := c.ResolveAddress(alert.SourceIP, alert.MAC, alert.VLAN, alert.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		result.DID = resolution.DID
+		result.MatchedBy = resolution.MatchedBy
+		if resolution.MatchedBy == chain.AddressMatchEdge && resolution.Subnet != nil {
+			log.Printf("源地址 %s 未绑定设备，归属到子网 %s 的边缘设备 %s", alert.SourceIP, resolution.Subnet.CIDR, resolution.DID)
+		} else {
+			log.Printf("源地址 %s 按 %s 匹配到设备 %s", alert.SourceIP, resolution.MatchedBy, resolution.DID)
+		}
+	}
+
+	if err := c.processRiskBehavior(result.DID, alert.BehaviorType, alert.Evidence, alert.HoneypointID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolveAddress 按链上地址绑定将网络地址解析为设备DID，at 为观察到该地址的时间，零值表示当前时间
+// 地址既未绑定也不在任何已设置边缘设备的子网内时返回 ingest.ErrUnknownAddress
+func (c *HoneypointClient) ResolveAddress(ip, mac string, vlan int, at time.Time) (*chain.AddressResolution, error) {
+	resolution, err := c.chainManager.ResolveAddress(ip, mac, vlan, at)
+	if err != nil {
+		if strings.Contains(err.Error(), unknownAddressMessage) {
+			return nil, fmt.Errorf("%w: %v", ingest.ErrUnknownAddress, err)
+		}
+		return nil, fmt.Errorf("解析地址 %s 失败: %w", ip, err)
+	}
+	return resolution, nil
+}
+
+// processRiskBehavior 向链上上报设备风险行为，并按链上风险等级策略判断处置措施
+func (c *HoneypointClient) processRiskBehavior(did string, behaviorType string, evidence string, honeypointID string) error {
+	// 计算证据哈希
+	evidenceHash := ""
+	if evidence != "" {
+		sum := sha256.Sum256([]byte(evidence))
+		evidenceHash = hex.EncodeToString(sum[:])
+	}
+
+	// 向链上上报风险行为，由链码计算风险评分
+	device, err := c.riskAssessor.AssessRisk(did, behaviorType, evidenceHash, honeypointID)
 	if err != nil {
 		return fmt.Errorf("风险评估失败: %w", err)
 	}
 
-	// 无论风险评分是否超过阈值，都立即向链上报告
-	log.Printf("设备 %s 的风险评分为 %.2f，攻击画像指数为 %.2f，立即向链上报告", did, newScore, newAttackIndex)
+	log.Printf("链上设备 %s 的风险评分为 %.2f，攻击画像指数为 %.2f", did, device.RiskScore, device.AttackIndexI)
 
-	// 更新链上设备风险评分
-	err = c.chainClient.UpdateDeviceRiskScore(did, newScore, newAttackIndex, updatedProfile)
+	// 根据链上风险等级策略判断设备所处等级
+	tier, err := c.riskAssessor.GetRiskTier(device.RiskScore)
 	if err != nil {
-		return fmt.Errorf("向链上报告风险评分失败: %w", err)
+		return fmt.Errorf("获取风险等级失败: %w", err)
+	}
+	log.Printf("设备 %s 当前处于 %s 风险等级，响应策略: %s", did, tier.Label, tier.Strategy)
+	if !tier.AllowConnect {
+		// 设备在线时链码已在评分更新的同一交易中关闭其连接会话
+		log.Printf("设备 %s 的风险评分 %.2f 达到 %s 等级，禁止连接，当前连接状态: %s", did, device.RiskScore, tier.Label, device.ConnectionStatus)
 	}
 
-	log.Printf("已向链上报告设备 %s 的风险评分 %.2f", did, newScore)
+	return nil
+}
 
-	// 检查风险评分是否超过阈值
-	if newScore >= riskScoreThreshold {
-		log.Printf("设备 %s 的风险评分 %.2f 超过阈值 %.2f，可能会被限制访问", did, newScore, riskScoreThreshold)
+// handleRiskScoreReset 处理RiskScoreReset事件
+// 重置已由链码在一个交易内完成，客户端只记录日志，不再提交任何交易
+func (c *HoneypointClient) handleRiskScoreReset(event *client.ChaincodeEvent) error {
+	var resetEvent RiskResetEvent
+	if err := json.Unmarshal(event.Payload, &resetEvent); err != nil {
+		log.Printf("解析风险评分重置事件数据失败: %v", err)
+		return nil
 	}
 
+	log.Printf("收到风险评分重置事件: DID=%s, 名称=%s, 方式=%s, 原因=%s, 批准人=%s (交易 %s), 执行者=%s, 重置前评分=%.2f, 攻击画像指数 %.4f -> %.4f, 状态=%s",
+		resetEvent.DID, resetEvent.Name, resetEvent.Mode, resetEvent.Reason, resetEvent.ApprovedBy, resetEvent.ApprovalTxID, resetEvent.ResetBy,
+		resetEvent.ScoreBefore, resetEvent.AttackIndexBefore, resetEvent.AttackIndexAfter, resetEvent.Status)
+	return nil
+}
+
+// handleRiskRuleUpdated 处理风险规则变更事件，使本地规则缓存失效
+func (c *HoneypointClient) handleRiskRuleUpdated(event *client.ChaincodeEvent) error {
+	log.Printf("收到风险规则变更事件: %s", event.EventName)
+	c.riskAssessor.InvalidateRules()
 	return nil
 }
 
-// listenForRiskScoreReset 监听风险评分重置事件
-func (c *HoneypointClient) listenForRiskScoreReset() {
-	log.Println("开始监听风险评分重置事件...")
+// handleRiskTierPolicyUpdated 处理风险等级策略变更事件，使本地策略缓存失效
+func (c *HoneypointClient) handleRiskTierPolicyUpdated(event *client.ChaincodeEvent) error {
+	log.Println("收到风险等级策略变更事件")
+	c.riskAssessor.InvalidatePolicy()
+	return nil
+}
 
-	// 使用新的API监听链码事件
-	events, err := c.network.ChaincodeEvents(c.ctx, c.config.ChaincodeName)
-	if err != nil {
-		log.Printf("注册风险评分重置事件监听失败: %v", err)
-		return
+// handleScoringProfileUpdated 处理风险评分配置变更事件，配置可能随新的风险等级策略一起发布，同时使本地策略缓存失效
+func (c *HoneypointClient) handleScoringProfileUpdated(event *client.ChaincodeEvent) error {
+	var profile chain.ScoringProfile
+	if err := json.Unmarshal(event.Payload, &profile); err != nil {
+		log.Printf("解析风险评分配置变更事件数据失败: %v", err)
+		return nil
 	}
 
-	for {
-		select {
-		case <-c.stopChan:
-			return
-		case event, ok := <-events:
-			if !ok {
-				return
-			}
+	log.Printf("收到风险评分配置变更事件，版本 %d，风险等级策略版本 %d", profile.Version, profile.TierPolicyVersion)
+	c.riskAssessor.InvalidatePolicy()
+	return nil
+}
 
-			// 只处理RiskScoreReset事件
-			if event.EventName != "RiskScoreReset" {
-				continue
-			}
+// handleDeviceSession 处理DeviceConnected和DeviceDisconnected事件
+func (c *HoneypointClient) handleDeviceSession(event *client.ChaincodeEvent) error {
+	var deviceEvent DeviceEvent
+	if err := json.Unmarshal(event.Payload, &deviceEvent); err != nil {
+		log.Printf("解析设备连接事件数据失败: %v", err)
+		return nil
+	}
 
-			// 解析事件数据
-			var deviceEvent DeviceEvent
-			if err := json.Unmarshal(event.Payload, &deviceEvent); err != nil {
-				log.Printf("解析风险评分重置事件数据失败: %v", err)
-				continue
-			}
+	if event.EventName == "DeviceConnected" {
+		log.Printf("设备上线: DID=%s, 名称=%s, 会话ID=%s, 风险评分=%.2f", deviceEvent.DID, deviceEvent.Name, deviceEvent.SessionID, deviceEvent.RiskScore)
+	} else {
+		log.Printf("设备下线: DID=%s, 名称=%s, 会话ID=%s", deviceEvent.DID, deviceEvent.Name, deviceEvent.SessionID)
+	}
+	return nil
+}
 
-			log.Printf("收到风险评分重置事件: DID=%s, 名称=%s", deviceEvent.DID, deviceEvent.Name)
+// IngestConfig 返回告警接入服务配置，未配置时为nil
+func (c *HoneypointClient) IngestConfig() *ingest.Config {
+	return c.config.Ingest
+}
 
-			// 重置设备风险数据
-			if err := c.chainManager.ResetDeviceRiskData(deviceEvent.DID); err != nil {
-				log.Printf("重置设备风险数据失败: %v", err)
-				continue
-			}
+// ListRiskRules 获取链上所有生效的风险规则
+func (c *HoneypointClient) ListRiskRules() ([]risk.RiskRule, error) {
+	return c.riskAssessor.ListAvailableRiskBehaviors()
+}
 
-			log.Printf("设备 %s 的风险数据已重置", deviceEvent.DID)
-		}
-	}
+// GetScoringProfile 获取链上当前生效的风险评分配置
+func (c *HoneypointClient) GetScoringProfile() (*chain.ScoringProfile, error) {
+	return c.chainManager.GetScoringProfile()
+}
+
+// GetRiskTierPolicy 获取链上当前生效的风险等级策略
+func (c *HoneypointClient) GetRiskTierPolicy() (*chain.RiskTierPolicy, error) {
+	return c.chainManager.GetRiskTierPolicy()
+}
+
+// GetRiskEventHistory 分页查询设备的风险事件记录
+func (c *HoneypointClient) GetRiskEventHistory(did, from, to string, pageSize int, bookmark string) (*chain.RiskEventPage, error) {
+	return c.chainManager.GetRiskEventHistory(did, from, to, pageSize, bookmark)
+}
+
+// GetDeviceHistory 获取设备信息的全部历史版本
+func (c *HoneypointClient) GetDeviceHistory(did string) ([]chain.DeviceHistoryEntry, error) {
+	return c.chainManager.GetDeviceHistory(did)
 }
 
 // startPeriodicMaintenance 启动周期性维护任务
@@ -409,6 +527,11 @@ func (c *HoneypointClient) startPeriodicMaintenance() {
 
 			// 对每个设备执行维护任务
 			for _, device := range devices {
+				// 已退役的设备和攻击画像指数为0的设备无需维护
+				if device.Status == chain.StatusDecommissioned || device.AttackIndexI == 0 {
+					continue
+				}
+
 				// 执行攻击画像指数的慢速衰减
 				err := c.riskAssessor.PerformBackgroundMaintenance(device.DID)
 				if err != nil {
@@ -423,16 +546,29 @@ func (c *HoneypointClient) startPeriodicMaintenance() {
 
 // getAllDevices 获取所有设备
 func (c *HoneypointClient) getAllDevices() ([]*chain.Device, error) {
-	// 调用链码获取所有设备
-	devicesJSON, err := c.contract.EvaluateTransaction("IdentityContract:GetAllDevices")
-	if err != nil {
-		return nil, fmt.Errorf("评估交易失败: %w", err)
-	}
-
-	// 解析设备列表
 	var devices []*chain.Device
-	if err := json.Unmarshal(devicesJSON, &devices); err != nil {
-		return nil, fmt.Errorf("设备列表解析失败: %w", err)
+	bookmark := ""
+	for {
+		// 分页调用链码获取设备，避免单次查询返回全部设备
+		pageJSON, err := c.contract.EvaluateTransaction(identityContract+":GetAllDevicesWithPagination", strconv.Itoa(devicePageSize), bookmark)
+		if err != nil {
+			return nil, fmt.Errorf("评估交易失败: %w", err)
+		}
+
+		// 解析设备列表
+		var page struct {
+			Devices  []*chain.Device `json:"devices"`
+			Bookmark string          `json:"bookmark"`
+		}
+		if err := json.Unmarshal(pageJSON, &page); err != nil {
+			return nil, fmt.Errorf("设备列表解析失败: %w", err)
+		}
+		devices = append(devices, page.Devices...)
+
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
 	}
 
 	return devices, nil
@@ -4,5 +4,6 @@ go 1.16
 
 require (
 	github.com/hyperledger/fabric-gateway v1.1.1
+	github.com/hyperledger/fabric-protos-go-apiv2 v0.0.0-20220615102044-467be1c7b2e7
 	google.golang.org/grpc v1.53.0
 )
@@ -0,0 +1,84 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+)
+
+// maxEvidenceSize 告警证据的最大长度，证据只有哈希上链
+const maxEvidenceSize = 512 * 1024
+
+// didPattern 设备DID格式，第一版后缀为16位十六进制字符，第二版后缀为 z 开头的 base58btc 多重哈希
+var didPattern = regexp.MustCompile(`^did:ieee:device:([0-9a-f]{16}|z[1-9A-HJ-NP-Za-km-z]{46})$`)
+
+// behaviorTypePattern 风险行为类型格式，与链上规则库的行为类型一致
+var behaviorTypePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// maxVLAN 最大的VLAN ID
+const maxVLAN = 4094
+
+// MatchedByAlert 告警自带设备DID时的归属方式，其余归属方式取自链上地址解析结果: mac, ip, edge
+const MatchedByAlert = "alert"
+
+// ErrUnknownAddress 告警的源地址既未绑定设备，也不在任何已设置边缘设备的子网内
+var ErrUnknownAddress = errors.New("无法将告警归属到设备")
+
+// Alert 蜜点传感器上报的告警
+type Alert struct {
+	SourceIP     string    `json:"sourceIp"`      // 触发告警的源IP
+	MAC          string    `json:"mac,omitempty"` // 触发告警的源MAC地址
+	VLAN         int       `json:"vlan"`          // 源地址所在VLAN，0表示不带标签
+	DID          string    `json:"did,omitempty"` // 设备DID，为空时按源地址解析
+	HoneypointID string    `json:"honeypointId"`  // 捕获告警的蜜点ID
+	BehaviorType string    `json:"behaviorType"`  // 风险行为类型
+	Timestamp    time.Time `json:"timestamp"`     // 蜜点捕获告警的时间（RFC3339）
+	Evidence     string    `json:"evidence"`      // 原始证据，仅将其SHA256哈希上链
+}
+
+// AlertResult 告警的处理结果
+type AlertResult struct {
+	DID       string `json:"did"`       // 告警归属的设备DID
+	MatchedBy string `json:"matchedBy"` // 归属方式
+}
+
+// AlertProcessor 告警处理器，由蜜点后台客户端实现
+type AlertProcessor interface {
+	ProcessAlert(alert *Alert) (*AlertResult, error)
+}
+
+// Validate 检查告警字段，now 为服务端当前时间
+func (a *Alert) Validate(now time.Time) error {
+	if net.ParseIP(a.SourceIP) == nil {
+		return fmt.Errorf("无效的源IP: %q", a.SourceIP)
+	}
+	if a.MAC != "" {
+		if _, err := net.ParseMAC(a.MAC); err != nil {
+			return fmt.Errorf("无效的MAC地址: %q", a.MAC)
+		}
+	}
+	if a.VLAN < 0 || a.VLAN > maxVLAN {
+		return fmt.Errorf("无效的VLAN ID: %d", a.VLAN)
+	}
+	if a.DID != "" && !didPattern.MatchString(a.DID) {
+		return fmt.Errorf("无效的设备DID: %q", a.DID)
+	}
+	if a.HoneypointID == "" {
+		return fmt.Errorf("蜜点ID不能为空")
+	}
+	if !behaviorTypePattern.MatchString(a.BehaviorType) {
+		return fmt.Errorf("无效的风险行为类型: %q", a.BehaviorType)
+	}
+	if a.Timestamp.IsZero() {
+		return fmt.Errorf("告警时间不能为空")
+	}
+	if a.Timestamp.After(now.Add(maxClockSkew)) {
+		return fmt.Errorf("告警时间 %s 晚于服务端当前时间", a.Timestamp.Format(time.RFC3339))
+	}
+	if len(a.Evidence) > maxEvidenceSize {
+		return fmt.Errorf("证据长度超过 %d 字节", maxEvidenceSize)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxClockSkew 请求时间戳与服务端时间的最大允许偏差，超出时拒绝请求
+const maxClockSkew = 5 * time.Minute
+
+// 传感器认证请求头
+const (
+	headerSensorID  = "X-Sensor-ID"
+	headerTimestamp = "X-Timestamp"
+	headerSignature = "X-Signature"
+)
+
+// Sensor 允许接入的蜜点传感器
+type Sensor struct {
+	ID            string   `json:"id"`                      // 传感器ID
+	Secret        string   `json:"secret"`                  // 与传感器共享的HMAC密钥
+	HoneypointIDs []string `json:"honeypointIds,omitempty"` // 传感器可以代为上报的蜜点ID，为空时只能使用传感器ID
+}
+
+// canReport 检查传感器是否可以上报指定蜜点的告警
+func (s *Sensor) canReport(honeypointID string) bool {
+	if len(s.HoneypointIDs) == 0 {
+		return honeypointID == s.ID
+	}
+	for _, id := range s.HoneypointIDs {
+		if id == honeypointID {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticator 按传感器共享密钥校验请求签名，并拒绝重放的请求
+type authenticator struct {
+	sensors map[string]*Sensor
+
+	mu   sync.Mutex
+	seen map[string]time.Time // 时间窗口内已使用的签名及其过期时间
+}
+
+// newAuthenticator 创建请求认证器
+func newAuthenticator(sensors []Sensor) (*authenticator, error) {
+	if len(sensors) == 0 {
+		return nil, fmt.Errorf("未配置任何蜜点传感器")
+	}
+
+	index := make(map[string]*Sensor, len(sensors))
+	for i := range sensors {
+		sensor := &sensors[i]
+		if sensor.ID == "" || sensor.Secret == "" {
+			return nil, fmt.Errorf("第 %d 个传感器的ID和密钥不能为空", i+1)
+		}
+		if _, ok := index[sensor.ID]; ok {
+			return nil, fmt.Errorf("传感器ID重复: %s", sensor.ID)
+		}
+		index[sensor.ID] = sensor
+	}
+
+	return &authenticator{
+		sensors: index,
+		seen:    make(map[string]time.Time),
+	}, nil
+}
+
+// authenticate 校验请求签名，签名为 HMAC-SHA256(密钥, 时间戳 + "\n" + 请求体) 的十六进制编码
+func (a *authenticator) authenticate(sensorID, timestamp, signature string, body []byte, now time.Time) (*Sensor, error) {
+	sensor, ok := a.sensors[sensorID]
+	if !ok {
+		return nil, fmt.Errorf("未知的传感器: %q", sensorID)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的请求时间戳: %q", timestamp)
+	}
+	requestTime := time.Unix(seconds, 0)
+	if requestTime.Before(now.Add(-maxClockSkew)) || requestTime.After(now.Add(maxClockSkew)) {
+		return nil, fmt.Errorf("请求时间戳超出允许范围")
+	}
+
+	expected := signRequest(sensor.Secret, timestamp, body)
+	provided, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(provided, expected) {
+		return nil, fmt.Errorf("请求签名无效")
+	}
+
+	// 时间窗口内同一签名只能使用一次，过期的签名会被时间戳检查拒绝
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, expiry := range a.seen {
+		if now.After(expiry) {
+			delete(a.seen, key)
+		}
+	}
+	replayKey := sensorID + ":" + hex.EncodeToString(provided)
+	if _, ok := a.seen[replayKey]; ok {
+		return nil, fmt.Errorf("重放的请求")
+	}
+	a.seen[replayKey] = requestTime.Add(maxClockSkew)
+
+	return sensor, nil
+}
+
+// signRequest 计算请求签名
+func signRequest(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
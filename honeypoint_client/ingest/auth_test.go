@@ -0,0 +1,150 @@
+package ingest
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+	auth, err := newAuthenticator([]Sensor{
+		{ID: "sensor-1", Secret: "secret-1"},
+		{ID: "sensor-2", Secret: "secret-2"},
+	})
+	if err != nil {
+		t.Fatalf("newAuthenticator() error = %v", err)
+	}
+	return auth
+}
+
+// sign 按传感器的方式计算请求签名
+func sign(secret string, requestTime time.Time, body []byte) (string, string) {
+	timestamp := strconv.FormatInt(requestTime.Unix(), 10)
+	return timestamp, hex.EncodeToString(signRequest(secret, timestamp, body))
+}
+
+func TestAuthenticate(t *testing.T) {
+	now := time.Unix(1772323200, 0)
+	body := []byte(`{"sourceIp":"10.0.0.1"}`)
+
+	tests := []struct {
+		name        string
+		sensorID    string
+		secret      string
+		requestTime time.Time
+		body        []byte
+		wantErr     bool
+	}{
+		{name: "有效请求", sensorID: "sensor-1", secret: "secret-1", requestTime: now},
+		{name: "时间戳在允许偏差内（较早）", sensorID: "sensor-1", secret: "secret-1", requestTime: now.Add(-maxClockSkew)},
+		{name: "时间戳在允许偏差内（较晚）", sensorID: "sensor-1", secret: "secret-1", requestTime: now.Add(maxClockSkew)},
+		{name: "时间戳过早", sensorID: "sensor-1", secret: "secret-1", requestTime: now.Add(-maxClockSkew - time.Second), wantErr: true},
+		{name: "时间戳过晚", sensorID: "sensor-1", secret: "secret-1", requestTime: now.Add(maxClockSkew + time.Second), wantErr: true},
+		{name: "未知的传感器", sensorID: "sensor-3", secret: "secret-1", requestTime: now, wantErr: true},
+		{name: "使用其他传感器的密钥", sensorID: "sensor-1", secret: "secret-2", requestTime: now, wantErr: true},
+		{name: "请求体被篡改", sensorID: "sensor-1", secret: "secret-1", requestTime: now, body: []byte(`{"sourceIp":"10.0.0.2"}`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := newTestAuthenticator(t)
+			timestamp, signature := sign(tt.secret, tt.requestTime, body)
+			requestBody := body
+			if tt.body != nil {
+				requestBody = tt.body
+			}
+
+			sensor, err := auth.authenticate(tt.sensorID, timestamp, signature, requestBody, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && sensor.ID != tt.sensorID {
+				t.Errorf("sensor.ID = %s, want %s", sensor.ID, tt.sensorID)
+			}
+		})
+	}
+}
+
+func TestAuthenticateInvalidHeaders(t *testing.T) {
+	now := time.Unix(1772323200, 0)
+	body := []byte(`{}`)
+	timestamp, signature := sign("secret-1", now, body)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+	}{
+		{name: "时间戳不是整数", timestamp: "2026-03-01T00:00:00Z", signature: signature},
+		{name: "时间戳为空", timestamp: "", signature: signature},
+		{name: "签名不是十六进制", timestamp: timestamp, signature: "zz"},
+		{name: "签名为空", timestamp: timestamp, signature: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := newTestAuthenticator(t)
+			if _, err := auth.authenticate("sensor-1", tt.timestamp, tt.signature, body, now); err == nil {
+				t.Error("authenticate() 应返回错误")
+			}
+		})
+	}
+}
+
+func TestAuthenticateReplay(t *testing.T) {
+	now := time.Unix(1772323200, 0)
+	body := []byte(`{"sourceIp":"10.0.0.1"}`)
+	auth := newTestAuthenticator(t)
+	timestamp, signature := sign("secret-1", now, body)
+
+	if _, err := auth.authenticate("sensor-1", timestamp, signature, body, now); err != nil {
+		t.Fatalf("首次请求 error = %v", err)
+	}
+	if _, err := auth.authenticate("sensor-1", timestamp, signature, body, now.Add(time.Minute)); err == nil {
+		t.Error("时间窗口内重放的请求应被拒绝")
+	}
+
+	// 同一请求体在新的时间戳下签名不同，不属于重放
+	nextTimestamp, nextSignature := sign("secret-1", now.Add(time.Second), body)
+	if _, err := auth.authenticate("sensor-1", nextTimestamp, nextSignature, body, now.Add(time.Second)); err != nil {
+		t.Errorf("新时间戳的请求 error = %v", err)
+	}
+
+	// 时间窗口过后，已记录的签名被清理，旧请求仍因时间戳过期而被拒绝
+	later := now.Add(maxClockSkew + time.Minute)
+	if _, err := auth.authenticate("sensor-1", timestamp, signature, body, later); err == nil {
+		t.Error("时间窗口外的旧请求应被拒绝")
+	}
+	laterTimestamp, laterSignature := sign("secret-2", later, body)
+	if _, err := auth.authenticate("sensor-2", laterTimestamp, laterSignature, body, later); err != nil {
+		t.Fatalf("新请求 error = %v", err)
+	}
+	if len(auth.seen) != 1 {
+		t.Errorf("过期的签名未被清理, 剩余 %d 个", len(auth.seen))
+	}
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name    string
+		sensors []Sensor
+		wantErr bool
+	}{
+		{name: "有效配置", sensors: []Sensor{{ID: "sensor-1", Secret: "secret-1"}}},
+		{name: "未配置传感器", sensors: nil, wantErr: true},
+		{name: "缺少ID", sensors: []Sensor{{Secret: "secret-1"}}, wantErr: true},
+		{name: "缺少密钥", sensors: []Sensor{{ID: "sensor-1"}}, wantErr: true},
+		{name: "ID重复", sensors: []Sensor{{ID: "sensor-1", Secret: "a"}, {ID: "sensor-1", Secret: "b"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAuthenticator(tt.sensors)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newAuthenticator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// alertsPath 告警接入接口路径
+const alertsPath = "/v1/alerts"
+
+// maxRequestSize 告警请求体的最大长度
+const maxRequestSize = 1024 * 1024
+
+// Config 告警接入服务配置
+type Config struct {
+	TLSCertPath string   `json:"tlsCertPath,omitempty"` // 服务端TLS证书，为空时使用HTTP
+	TLSKeyPath  string   `json:"tlsKeyPath,omitempty"`  // 服务端TLS私钥
+	Sensors     []Sensor `json:"sensors"`               // 允许接入的蜜点传感器
+}
+
+// Server 蜜点告警接入服务
+type Server struct {
+	config    *Config
+	auth      *authenticator
+	processor AlertProcessor
+}
+
+// NewServer 创建新的告警接入服务
+func NewServer(config *Config, processor AlertProcessor) (*Server, error) {
+	if config == nil {
+		return nil, fmt.Errorf("未配置告警接入服务")
+	}
+	if (config.TLSCertPath == "") != (config.TLSKeyPath == "") {
+		return nil, fmt.Errorf("TLS证书和私钥必须同时配置")
+	}
+
+	auth, err := newAuthenticator(config.Sensors)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		config:    config,
+		auth:      auth,
+		processor: processor,
+	}, nil
+}
+
+// ListenAndServe 在指定地址启动告警接入服务
+func (s *Server) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(alertsPath, s.handleAlert)
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: time.Minute, // 告警在提交交易并等待上链后才返回
+	}
+
+	log.Printf("告警接入服务已启动，监听地址: %s，接口: POST %s，已配置 %d 个传感器", addr, alertsPath, len(s.auth.sensors))
+	if s.config.TLSCertPath != "" {
+		return server.ListenAndServeTLS(s.config.TLSCertPath, s.config.TLSKeyPath)
+	}
+	return server.ListenAndServe()
+}
+
+// handleAlert 处理 POST /v1/alerts
+func (s *Server) handleAlert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "仅支持POST请求")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "请求体过大")
+		return
+	}
+
+	now := time.Now()
+	sensor, err := s.auth.authenticate(r.Header.Get(headerSensorID), r.Header.Get(headerTimestamp), r.Header.Get(headerSignature), body, now)
+	if err != nil {
+		log.Printf("拒绝来自 %s 的告警: %v", r.RemoteAddr, err)
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var alert Alert
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&alert); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("告警JSON解析失败: %v", err))
+		return
+	}
+	if err := alert.Validate(now); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !sensor.canReport(alert.HoneypointID) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("传感器 %s 不能上报蜜点 %s 的告警", sensor.ID, alert.HoneypointID))
+		return
+	}
+
+	log.Printf("收到传感器 %s 的告警: 蜜点=%s, 源IP=%s, 行为=%s", sensor.ID, alert.HoneypointID, alert.SourceIP, alert.BehaviorType)
+	result, err := s.processor.ProcessAlert(&alert)
+	if err != nil {
+		log.Printf("处理传感器 %s 的告警失败: %v", sensor.ID, err)
+		if errors.Is(err, ErrUnknownAddress) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":    "accepted",
+		"did":       result.DID,
+		"matchedBy": result.MatchedBy,
+	})
+}
+
+// writeError 以JSON格式返回错误
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeJSON 以JSON格式返回响应
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	body, _ := json.Marshal(value)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -2,15 +2,40 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Tittifer/IEEE/honeypoint_client/chain"
 	"github.com/Tittifer/IEEE/honeypoint_client/client"
+	"github.com/Tittifer/IEEE/honeypoint_client/ingest"
+	"github.com/Tittifer/IEEE/honeypoint_client/risk"
 )
 
+// 攻击阶段，按风险行为类别的主类别分组显示
+var attackStages = []struct {
+	Category string
+	Name     string
+}{
+	{"Recon", "侦察阶段"},
+	{"InitialAccess", "初始接入阶段"},
+	{"Execution", "执行阶段"},
+	{"Persistence", "持久化阶段"},
+	{"DefenseEvasion", "防御规避阶段"},
+	{"CredentialAccess", "凭证访问阶段"},
+	{"LateralMovement", "横向移动阶段"},
+	{"Collection", "数据收集阶段"},
+	{"Exfiltration", "渗出阶段"},
+}
+
 func main() {
+	ingestAddr := flag.String("ingest", "", "以告警接入守护进程模式运行的监听地址，例如 :9090")
+	flag.Parse()
+
 	// 创建蜜点客户端
 	honeypointClient, err := client.NewHoneypointClient()
 	if err != nil {
@@ -26,6 +51,15 @@ func main() {
 		log.Fatalf("启动事件监听失败: %v", err)
 	}
 
+	// 守护进程模式下不读取命令行输入，告警由蜜点传感器通过网络接口上报
+	if *ingestAddr != "" {
+		server, err := ingest.NewServer(honeypointClient.IngestConfig(), honeypointClient)
+		if err != nil {
+			log.Fatalf("创建告警接入服务失败: %v", err)
+		}
+		log.Fatal(server.ListenAndServe(*ingestAddr))
+	}
+
 	// 命令行交互
 	scanner := bufio.NewScanner(os.Stdin)
 
@@ -48,49 +82,80 @@ func main() {
 		case "help":
 			printHelp()
 		case "risk":
-			if len(args) != 3 {
-				fmt.Println("用法: risk <设备DID> <风险行为类型>")
+			if len(args) < 3 {
+				fmt.Println("用法: risk <设备DID> <风险行为类型> [证据]")
 				continue
 			}
 			did := args[1]
 			behaviorType := args[2]
+			evidence := strings.Join(args[3:], " ")
 			
-			err := honeypointClient.ProcessRiskBehavior(did, behaviorType)
+			err := honeypointClient.ProcessRiskBehavior(did, behaviorType, evidence)
 			if err != nil {
 				fmt.Printf("处理风险行为失败: %v\n", err)
 			} else {
 				fmt.Printf("已成功处理设备 %s 的风险行为 %s\n", did, behaviorType)
 			}
+		case "resolve":
+			if len(args) < 2 || len(args) > 4 {
+				fmt.Println("用法: resolve <IP> [MAC] [VLAN]")
+				continue
+			}
+			mac, vlan := "", 0
+			for _, arg := range args[2:] {
+				if n, err := strconv.Atoi(arg); err == nil {
+					vlan = n
+				} else {
+					mac = arg
+				}
+			}
+			resolution, err := honeypointClient.ResolveAddress(args[1], mac, vlan, time.Time{})
+			if err != nil {
+				fmt.Printf("解析地址失败: %v\n", err)
+				continue
+			}
+			if resolution.MatchedBy == chain.AddressMatchEdge && resolution.Subnet != nil {
+				fmt.Printf("地址 %s 未绑定设备，归属到子网 %s 的边缘设备: %s\n", args[1], resolution.Subnet.CIDR, resolution.DID)
+			} else {
+				fmt.Printf("地址 %s 按 %s 匹配到设备: %s\n", args[1], resolution.MatchedBy, resolution.DID)
+			}
+		case "events":
+			if len(args) < 2 {
+				fmt.Println("用法: events <设备DID> [起始时间] [结束时间]")
+				continue
+			}
+			from, to := "", ""
+			if len(args) > 2 {
+				from = args[2]
+			}
+			if len(args) > 3 {
+				to = args[3]
+			}
+			if err := printRiskEvents(honeypointClient, args[1], from, to); err != nil {
+				fmt.Printf("查询风险事件失败: %v\n", err)
+			}
+		case "history":
+			if len(args) != 2 {
+				fmt.Println("用法: history <设备DID>")
+				continue
+			}
+			history, err := honeypointClient.GetDeviceHistory(args[1])
+			if err != nil {
+				fmt.Printf("查询设备历史失败: %v\n", err)
+				continue
+			}
+			printDeviceHistory(history)
+		case "profile":
+			if err := printScoringProfile(honeypointClient); err != nil {
+				fmt.Printf("获取风险评分配置失败: %v\n", err)
+			}
 		case "list":
-			fmt.Println("可用的风险行为类型:")
-			fmt.Println("侦察阶段:")
-			fmt.Println("  visit_trap_ip - 访问陷阱IP")
-			fmt.Println("  connect_bait_wifi - 连接诱饵WiFi")
-			fmt.Println("  port_scan_honeypot - 对蜜点进行端口扫描")
-			fmt.Println("初始接入阶段:")
-			fmt.Println("  weak_password_login - 尝试弱口令登录")
-			fmt.Println("  exploit_known_vulnerability - 利用已知漏洞攻击")
-			fmt.Println("执行阶段:")
-			fmt.Println("  execute_info_gathering - 执行信息收集命令")
-			fmt.Println("  upload_script - 上传脚本文件")
-			fmt.Println("  upload_known_backdoor - 上传已知后门程序")
-			fmt.Println("  modify_config_file - 修改系统配置文件")
-			fmt.Println("持久化阶段:")
-			fmt.Println("  create_scheduled_task - 创建定时任务")
-			fmt.Println("  modify_system_service - 修改系统服务")
-			fmt.Println("防御规避阶段:")
-			fmt.Println("  clear_stop_log_service - 清空或停止日志服务")
-			fmt.Println("  use_rootkit - 使用Rootkit技术")
-			fmt.Println("凭证访问阶段:")
-			fmt.Println("  read_fake_credential - 读取伪造的凭证文件")
-			fmt.Println("  attempt_memory_credential - 尝试内存抓取凭证")
-			fmt.Println("横向移动阶段:")
-			fmt.Println("  login_with_stolen_credential - 使用窃取的凭证登录")
-			fmt.Println("数据收集阶段:")
-			fmt.Println("  compress_sensitive_files - 打包压缩敏感文件")
-			fmt.Println("渗出阶段:")
-			fmt.Println("  transfer_data_outside - 向外网传输数据")
-			fmt.Println("  trigger_bait_file_callback - 触发诱饵文件回调")
+			rules, err := honeypointClient.ListRiskRules()
+			if err != nil {
+				fmt.Printf("获取风险规则失败: %v\n", err)
+				continue
+			}
+			printRiskRules(rules)
 		case "exit":
 			fmt.Println("退出程序")
 			return
@@ -108,7 +173,124 @@ func main() {
 func printHelp() {
 	fmt.Println("可用命令:")
 	fmt.Println("  help                       - 显示帮助信息")
-	fmt.Println("  risk <设备DID> <风险行为类型>  - 模拟设备风险行为")
-	fmt.Println("  list                       - 列出可用的风险行为类型")
+	fmt.Println("  risk <设备DID> <风险行为类型> [证据] - 模拟设备风险行为")
+	fmt.Println("  resolve <IP> [MAC] [VLAN]  - 按链上地址绑定查询网络地址对应的设备")
+	fmt.Println("  events <设备DID> [起始时间] [结束时间] - 查询设备的风险事件时间线，时间格式为RFC3339")
+	fmt.Println("  history <设备DID>          - 查看设备信息的变更历史")
+	fmt.Println("  list                       - 列出链上可用的风险行为类型")
+	fmt.Println("  profile                    - 查看链上当前生效的风险评分配置和风险等级")
 	fmt.Println("  exit                       - 退出程序")
-}
\ No newline at end of file
+}
+
+// 按时间顺序打印设备的全部风险事件，自动翻页
+func printRiskEvents(honeypointClient *client.HoneypointClient, did, from, to string) error {
+	count := 0
+	bookmark := ""
+	for {
+		page, err := honeypointClient.GetRiskEventHistory(did, from, to, 0, bookmark)
+		if err != nil {
+			return err
+		}
+		for _, event := range page.Events {
+			fmt.Printf("%s  %s (%s) 评分: %.2f -> %.2f, 攻击画像指数: %.2f -> %.2f, 等级: %s, 蜜点: %s\n",
+				event.Timestamp.Local().Format("2006-01-02 15:04:05"), event.BehaviorType, event.Category,
+				event.ScoreBefore, event.ScoreAfter, event.AttackIndexBefore, event.AttackIndexAfter, event.Tier, event.HoneypointID)
+			fmt.Printf("    规则版本: %d, 评分配置版本: %d, 风险等级策略版本: %d\n",
+				event.RuleVersion, event.ScoringProfileVersion, event.TierPolicyVersion)
+			if event.EvidenceHash != "" {
+				fmt.Printf("    证据哈希: %s\n", event.EvidenceHash)
+			}
+		}
+		count += len(page.Events)
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	fmt.Printf("共 %d 条风险事件\n", count)
+	return nil
+}
+
+// 打印链上当前生效的风险评分配置和风险等级划分
+func printScoringProfile(honeypointClient *client.HoneypointClient) error {
+	profile, err := honeypointClient.GetScoringProfile()
+	if err != nil {
+		return err
+	}
+	policy, err := honeypointClient.GetRiskTierPolicy()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("风险评分配置版本: %d\n", profile.Version)
+	if profile.Description != "" {
+		fmt.Printf("    说明: %s\n", profile.Description)
+	}
+	fmt.Printf("    S_max: %.2f, δ: %g, α: %g, λ: %g, 一票否决分数: %.2f\n",
+		profile.Params.MaxScore, profile.Params.Delta, profile.Params.Alpha, profile.Params.Lambda, profile.Params.VetoScore)
+	fmt.Printf("风险等级策略版本: %d\n", policy.Version)
+	for _, tier := range policy.Tiers {
+		fmt.Printf("    %s (%s): [%.2f, %.2f)\n", tier.Label, tier.Name, tier.MinScore, tier.MaxScore)
+	}
+	return nil
+}
+
+// 按时间顺序打印设备信息的变更记录
+func printDeviceHistory(history []chain.DeviceHistoryEntry) {
+	for i, entry := range history {
+		fmt.Printf("[%d] %s  交易ID: %s\n", i+1, entry.Timestamp.Local().Format("2006-01-02 15:04:05"), entry.TxID)
+		switch {
+		case entry.IsDelete:
+			fmt.Println("    设备信息已删除")
+		case i == 0 || history[i-1].IsDelete:
+			fmt.Printf("    创建设备信息，状态: %s\n", entry.Device.Status)
+		case len(entry.Changes) == 0:
+			fmt.Println("    字段无变化")
+		default:
+			for _, change := range entry.Changes {
+				fmt.Printf("    %s: %s -> %s\n", change.Field, displayValue(change.OldValue), displayValue(change.NewValue))
+			}
+		}
+	}
+	fmt.Printf("共 %d 个版本\n", len(history))
+}
+
+// 显示字段值，空值显示为 -
+func displayValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// 按攻击阶段打印风险规则
+func printRiskRules(rules []risk.RiskRule) {
+	// 按主类别分组
+	groups := make(map[string][]risk.RiskRule)
+	for _, rule := range rules {
+		stage := strings.SplitN(rule.Category, ".", 2)[0]
+		groups[stage] = append(groups[stage], rule)
+	}
+
+	fmt.Println("可用的风险行为类型:")
+	for _, stage := range attackStages {
+		printStageRules(stage.Name, groups[stage.Category])
+		delete(groups, stage.Category)
+	}
+	// 链上新增的其他类别
+	for stage, stageRules := range groups {
+		printStageRules(stage, stageRules)
+	}
+}
+
+// 打印单个攻击阶段的风险规则
+func printStageRules(name string, rules []risk.RiskRule) {
+	if len(rules) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", name)
+	for _, rule := range rules {
+		fmt.Printf("  %s - %s (得分: %.2f, 权重: %.2f, 版本: %d)\n", rule.BehaviorType, rule.Description, rule.Score, rule.Weight, rule.Version)
+	}
+}
@@ -0,0 +1,67 @@
+package risk
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/Tittifer/IEEE/honeypoint_client/chain"
+)
+
+// PolicyCache 链上风险等级策略的本地缓存
+type PolicyCache struct {
+	chainManager *chain.ChainManager
+
+	mu     sync.RWMutex
+	policy *chain.RiskTierPolicy
+}
+
+// NewPolicyCache 创建新的风险等级策略缓存
+func NewPolicyCache(chainManager *chain.ChainManager) *PolicyCache {
+	return &PolicyCache{
+		chainManager: chainManager,
+	}
+}
+
+// Get 获取风险等级策略，缓存为空时从链上加载
+func (c *PolicyCache) Get() (*chain.RiskTierPolicy, error) {
+	c.mu.RLock()
+	policy := c.policy
+	c.mu.RUnlock()
+	if policy != nil {
+		return policy, nil
+	}
+
+	policy, err := c.chainManager.GetRiskTierPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("获取链上风险等级策略失败: %w", err)
+	}
+
+	c.mu.Lock()
+	c.policy = policy
+	c.mu.Unlock()
+
+	log.Printf("已从链上加载风险等级策略，版本 %d，共 %d 个等级", policy.Version, len(policy.Tiers))
+	return policy, nil
+}
+
+// Invalidate 使缓存失效，下次访问时重新加载
+func (c *PolicyCache) Invalidate() {
+	c.mu.Lock()
+	c.policy = nil
+	c.mu.Unlock()
+}
+
+// TierForScore 根据风险评分查找所属风险等级
+func (c *PolicyCache) TierForScore(score float64) (*chain.RiskTier, error) {
+	policy, err := c.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	tier := policy.TierForScore(score)
+	if tier == nil {
+		return nil, fmt.Errorf("风险评分 %.2f 不属于任何风险等级", score)
+	}
+	return tier, nil
+}
@@ -0,0 +1,86 @@
+package risk
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Tittifer/IEEE/honeypoint_client/chain"
+)
+
+// 并发冲突重试参数
+const (
+	maxConflictAttempts = 5                      // 设备被并发修改时的最大尝试次数
+	conflictRetryDelay  = 200 * time.Millisecond // 首次重试的间隔，之后每次加倍
+)
+
+// deviceQueue 按设备DID串行执行任务，同一设备的任务按提交顺序逐个执行，不同设备的任务并发执行
+type deviceQueue struct {
+	mu      sync.Mutex
+	workers map[string]*deviceWorker
+}
+
+// deviceWorker 单个设备的任务队列
+type deviceWorker struct {
+	tasks   chan func()
+	pending int // 已提交但尚未执行完的任务数，为0时工作协程退出
+}
+
+// newDeviceQueue 创建设备任务队列
+func newDeviceQueue() *deviceQueue {
+	return &deviceQueue{
+		workers: make(map[string]*deviceWorker),
+	}
+}
+
+// do 将任务加入设备的队列并等待其执行完成，返回任务的错误
+func (q *deviceQueue) do(did string, task func() error) error {
+	q.mu.Lock()
+	worker, ok := q.workers[did]
+	if !ok {
+		worker = &deviceWorker{tasks: make(chan func())}
+		q.workers[did] = worker
+		go q.run(did, worker)
+	}
+	worker.pending++
+	q.mu.Unlock()
+
+	done := make(chan error, 1)
+	worker.tasks <- func() {
+		done <- task()
+	}
+	return <-done
+}
+
+// run 依次执行设备的任务，队列为空时退出
+func (q *deviceQueue) run(did string, worker *deviceWorker) {
+	for task := range worker.tasks {
+		task()
+
+		q.mu.Lock()
+		worker.pending--
+		if worker.pending == 0 {
+			delete(q.workers, did)
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+	}
+}
+
+// retryOnConflict 执行 attempt，设备被其他交易并发修改时按退避间隔重试
+// attempt 每次都应重新读取设备，并以读取到的最后更新时间作为前置条件提交交易
+func retryOnConflict(did string, attempt func() error) error {
+	delay := conflictRetryDelay
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || !errors.Is(err, chain.ErrConflict) || i == maxConflictAttempts {
+			return err
+		}
+
+		log.Printf("设备 %s 已被并发修改，%s后重新读取并重试（第 %d 次）: %v", did, delay, i, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}